@@ -1,36 +1,64 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
+	"mime"
 	"net/http"
 	"project/domain/model"
+	"project/domain/searchquery"
 	"project/domain/service"
-	"project/infrastructure/mysql"
+	"project/infrastructure/blobstore"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// EmailResponse es la respuesta de GET /emails. Con cursor no se incluyen
+// page, total ni total_pages, porque el total no se calcula.
 type EmailResponse struct {
 	Emails     []model.Email `json:"emails"`
 	HasNext    bool          `json:"has_next"`
 	HasPrev    bool          `json:"has_prev"`
-	Page       int           `json:"page"`
-	Total      int           `json:"total"`
-	TotalPages int           `json:"total_pages"`
+	Page       *int          `json:"page,omitempty"`
+	Total      *int          `json:"total,omitempty"`
+	TotalPages *int          `json:"total_pages,omitempty"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+	PrevCursor string        `json:"prev_cursor,omitempty"`
+}
+
+// SearchResponse es la respuesta de GET /emails/search: cada correo incluye
+// además Score, Highlights y Snippet. Facets solo se incluye con facets=true.
+// Backend es el motor que respondió: el configurado o la base de datos si
+// ZincSearch no estaba disponible, en cuyo caso las facetas vienen vacías.
+type SearchResponse struct {
+	Emails     []model.SearchHit `json:"emails"`
+	Facets     *model.Facets     `json:"facets,omitempty"`
+	HasNext    bool              `json:"has_next"`
+	HasPrev    bool              `json:"has_prev"`
+	Page       *int              `json:"page,omitempty"`
+	Total      int               `json:"total"`
+	TotalPages *int              `json:"total_pages,omitempty"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+	PrevCursor string            `json:"prev_cursor,omitempty"`
+	Backend    string            `json:"backend"`
 }
 
 type EmailController struct {
 	emailService *service.EmailService
+	store        *blobstore.Store
+	cursors      *service.CursorCodec
 }
 
-func NewEmailController() *EmailController {
-	mysqlDB, err := mysql.InitMySQL()
-	if err != nil {
-		panic("Error al conectar a la base de datos")
-	}
+// NewEmailController crea el controlador con sus dependencias: el servicio de
+// correos, el almacén de adjuntos y el codificador de los cursores.
+func NewEmailController(emailService *service.EmailService, store *blobstore.Store, cursors *service.CursorCodec) *EmailController {
 	return &EmailController{
-		emailService: service.NewEmailService(mysqlDB),
+		emailService: emailService,
+		store:        store,
+		cursors:      cursors,
 	}
 }
 
@@ -61,10 +89,53 @@ func (ec *EmailController) GetEmails(c *gin.Context) {
 		return
 	}
 
+	// Filtro opcional por participante y rol
+	filter := service.EmailFilter{
+		Participant: strings.TrimSpace(c.Query("participant")),
+		Role:        strings.ToLower(strings.TrimSpace(c.Query("role"))),
+	}
+	if filter.Role != "" {
+		if !model.ValidRole(filter.Role) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Rol inválido", "valid_roles": []string{
+				model.RoleFrom, model.RoleTo, model.RoleCc, model.RoleBcc}})
+			return
+		}
+		if filter.Participant == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El filtro role requiere el parámetro participant"})
+			return
+		}
+	}
+
+	if err := parseEmailFilter(c, &filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Orden opcional: sort=id|date|sender|subject y order=asc|desc
+	order := service.EmailSort{Field: c.DefaultQuery("sort", "id")}
+	if !service.ValidSortField(order.Field) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Campo de orden inválido", "valid_sorts": service.SortFields()})
+		return
+	}
+	switch c.DefaultQuery("order", "asc") {
+	case "asc":
+	case "desc":
+		order.Desc = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Orden inválido: debe ser asc o desc"})
+		return
+	}
+
+	// Con cursor se pagina por clave y no se cuenta el total; sin cursor, por número de página
+	if token := c.Query("cursor"); token != "" {
+		ec.getEmailsByCursor(c, filter, token, limitInt)
+		return
+	}
+
 	offset := (pageInt - 1) * limitInt
 
 	// Obtener los correos electrónicos con paginación
-	emails, total, err := ec.emailService.GetEmailsWithPagination(offset, limitInt)
+	emails, total, err := ec.emailService.GetEmailsWithPagination(filter, order, offset, limitInt)
 	if err != nil {
 		fmt.Printf("Error en GetEmailsHandler: %v\n", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los correos electrónicos"})
@@ -72,23 +143,120 @@ func (ec *EmailController) GetEmails(c *gin.Context) {
 	}
 
 	totalPages := (total + limitInt - 1) / limitInt
-	if pageInt > totalPages {
+	if totalPages > 0 && pageInt > totalPages {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Página fuera de rango", "max_pages": totalPages})
 		return
 	}
 
 	response := EmailResponse{
 		Emails:     emails,
-		Total:      total,
-		Page:       pageInt,
-		TotalPages: totalPages,
+		Total:      &total,
+		Page:       &pageInt,
+		TotalPages: &totalPages,
 		HasPrev:    pageInt > 1,
 		HasNext:    pageInt < totalPages,
 	}
+	if err := ec.setEmailCursors(&response, order); err != nil {
+		fmt.Printf("Error en GetEmailsHandler: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los correos electrónicos"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// getEmailsByCursor responde GET /emails?cursor=... con los correos siguientes o anteriores al cursor.
+func (ec *EmailController) getEmailsByCursor(c *gin.Context, filter service.EmailFilter, token string, limit int) {
+	var cursor service.Cursor
+	if err := ec.cursors.Decode(token, &cursor); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cursor inválido"})
+		return
+	}
+
+	emails, hasMore, err := ec.emailService.GetEmailsByCursor(filter, cursor, limit)
+	if errors.Is(err, service.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cursor inválido"})
+		return
+	}
+	if err != nil {
+		fmt.Printf("Error en GetEmailsHandler: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los correos electrónicos"})
+		return
+	}
+
+	response := EmailResponse{Emails: emails}
+	if len(emails) > 0 {
+		// hasMore se refiere a la dirección pedida; en la otra está al menos el correo del cursor
+		response.HasNext = hasMore || cursor.Before
+		response.HasPrev = hasMore || !cursor.Before
+	}
+	if err := ec.setEmailCursors(&response, cursor.Sort()); err != nil {
+		fmt.Printf("Error en GetEmailsHandler: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los correos electrónicos"})
+		return
+	}
 
 	c.JSON(http.StatusOK, response)
 }
 
+// setEmailCursors agrega los cursores de la página siguiente y anterior, si existen.
+func (ec *EmailController) setEmailCursors(response *EmailResponse, order service.EmailSort) error {
+	if len(response.Emails) == 0 {
+		return nil
+	}
+
+	var err error
+	if response.HasNext {
+		response.NextCursor, err = ec.cursors.Encode(order.CursorAfter(response.Emails[len(response.Emails)-1]))
+		if err != nil {
+			return err
+		}
+	}
+	if response.HasPrev {
+		response.PrevCursor, err = ec.cursors.Encode(order.CursorBefore(response.Emails[0]))
+	}
+	return err
+}
+
+// parseEmailFilter lee los filtros del listado: sender, receiver, folder, owner,
+// date_from y date_to (AAAA-MM-DD, ambos inclusive), has_body y content_type.
+func parseEmailFilter(c *gin.Context, filter *service.EmailFilter) error {
+	filter.Sender = strings.TrimSpace(c.Query("sender"))
+	filter.Receiver = strings.TrimSpace(c.Query("receiver"))
+	filter.Folder = strings.TrimSpace(c.Query("folder"))
+	filter.Owner = strings.TrimSpace(c.Query("owner"))
+	filter.ContentType = strings.ToLower(strings.TrimSpace(c.Query("content_type")))
+
+	if value := c.Query("date_from"); value != "" {
+		date, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return fmt.Errorf("date_from inválido: use AAAA-MM-DD")
+		}
+		filter.DateFrom = date
+	}
+	if value := c.Query("date_to"); value != "" {
+		date, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return fmt.Errorf("date_to inválido: use AAAA-MM-DD")
+		}
+		// Se incluye el día completo
+		filter.DateTo = date.AddDate(0, 0, 1)
+	}
+	if !filter.DateFrom.IsZero() && !filter.DateTo.IsZero() && !filter.DateFrom.Before(filter.DateTo) {
+		return fmt.Errorf("date_from debe ser anterior o igual a date_to")
+	}
+
+	if value := c.Query("has_body"); value != "" {
+		hasBody, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("has_body inválido: debe ser true o false")
+		}
+		filter.HasBody = &hasBody
+	}
+
+	return nil
+}
+
 // GetEmailByID maneja la ruta GET /emails/:id y devuelve un correo electrónico específico por ID.
 func (ec *EmailController) GetEmailByID(c *gin.Context) {
 	id := c.Param("id")
@@ -117,7 +285,7 @@ func (ec *EmailController) GetEmailByID(c *gin.Context) {
 	c.JSON(http.StatusOK, email)
 }
 
-// Realiza la búsqueda de correos electrónicos en zincsearch
+// Realiza la búsqueda de correos electrónicos con el motor de búsqueda configurado
 func (ec *EmailController) SearchEmails(c *gin.Context) {
 	query := c.DefaultQuery("query", "")
 	if query == "" {
@@ -128,12 +296,6 @@ func (ec *EmailController) SearchEmails(c *gin.Context) {
 	page := c.DefaultQuery("page", "1")
 	limit := c.DefaultQuery("limit", "10")
 
-	pageInt, err := strconv.Atoi(page)
-	if err != nil || pageInt <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Página inválida"})
-		return
-	}
-
 	limitInt, err := strconv.Atoi(limit)
 	if err != nil || limitInt <= 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Límite inválido"})
@@ -150,26 +312,221 @@ func (ec *EmailController) SearchEmails(c *gin.Context) {
 		return
 	}
 
+	options, err := parseSearchOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if token := c.Query("cursor"); token != "" {
+		ec.searchEmailsByCursor(c, query, options, token, limitInt)
+		return
+	}
+
+	pageInt, err := strconv.Atoi(page)
+	if err != nil || pageInt <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Página inválida"})
+		return
+	}
+
 	offset := (pageInt - 1) * limitInt
 
 	// Realizar la búsqueda en el servicio con paginación
-	emails, total, err := ec.emailService.SearchEmailsWithPagination(query, offset, limitInt)
-	if err != nil {
-		fmt.Printf("Error en SearchEmailsHandler: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los correos electrónicos"})
+	result, err := ec.emailService.SearchEmailsWithPagination(query, options, offset, limitInt)
+	if writeSearchError(c, err) {
 		return
 	}
 
-	totalPages := (total + limitInt - 1) / limitInt
+	totalPages := (result.Total + limitInt - 1) / limitInt
 
-	response := EmailResponse{
-		Emails:     emails,
-		Total:      total,
-		Page:       pageInt,
-		TotalPages: totalPages,
+	response := SearchResponse{
+		Emails:     result.Hits,
+		Facets:     result.Facets,
+		Total:      result.Total,
+		Page:       &pageInt,
+		TotalPages: &totalPages,
 		HasPrev:    pageInt > 1,
 		HasNext:    pageInt < totalPages,
+		Backend:    result.Backend,
+	}
+	if err := ec.setSearchCursors(&response, query, options); err != nil {
+		fmt.Printf("Error en SearchEmailsHandler: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los correos electrónicos"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// searchEmailsByCursor responde la búsqueda a partir de un cursor de
+// next_cursor o prev_cursor, sin calcular el número de página.
+func (ec *EmailController) searchEmailsByCursor(c *gin.Context, query string, options service.SearchOptions, token string, limit int) {
+	var cursor service.SearchCursor
+	if err := ec.cursors.Decode(token, &cursor); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cursor inválido"})
+		return
+	}
+
+	result, hasMore, err := ec.emailService.SearchEmailsByCursor(query, options, cursor, limit)
+	if errors.Is(err, service.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El cursor no corresponde a esta búsqueda"})
+		return
+	}
+	if writeSearchError(c, err) {
+		return
+	}
+
+	response := SearchResponse{
+		Emails:  result.Hits,
+		Facets:  result.Facets,
+		Total:   result.Total,
+		Backend: result.Backend,
+	}
+	if len(result.Hits) > 0 {
+		response.HasNext = hasMore || cursor.Before
+		response.HasPrev = hasMore || !cursor.Before
+	}
+	if err := ec.setSearchCursors(&response, query, options); err != nil {
+		fmt.Printf("Error en SearchEmailsHandler: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los correos electrónicos"})
+		return
 	}
 
 	c.JSON(http.StatusOK, response)
 }
+
+// writeSearchError responde el error de la búsqueda, si lo hay: 400 si la
+// consulta no se pudo interpretar y 500 en otro caso.
+func writeSearchError(c *gin.Context, err error) bool {
+	if err == nil {
+		return false
+	}
+	var parseErr *searchquery.ParseError
+	if errors.As(err, &parseErr) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":    "Consulta inválida: " + parseErr.Msg,
+			"position": parseErr.Pos,
+		})
+		return true
+	}
+	fmt.Printf("Error en SearchEmailsHandler: %v\n", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los correos electrónicos"})
+	return true
+}
+
+// setSearchCursors agrega los cursores de los resultados siguientes y anteriores, si existen.
+func (ec *EmailController) setSearchCursors(response *SearchResponse, query string, options service.SearchOptions) error {
+	if len(response.Emails) == 0 {
+		return nil
+	}
+
+	var err error
+	if response.HasNext {
+		response.NextCursor, err = ec.cursors.Encode(service.NewSearchCursor(query, options, response.Backend, response.Emails[len(response.Emails)-1], false))
+		if err != nil {
+			return err
+		}
+	}
+	if response.HasPrev {
+		response.PrevCursor, err = ec.cursors.Encode(service.NewSearchCursor(query, options, response.Backend, response.Emails[0], true))
+	}
+	return err
+}
+
+// parseSearchOptions lee los parámetros de facetas de la búsqueda:
+// facets=true, facet_size, interval y los filtros facet_sender, facet_receiver,
+// facet_folder y facet_date, que se pueden repetir.
+func parseSearchOptions(c *gin.Context) (service.SearchOptions, error) {
+	options := service.SearchOptions{
+		Facets:   c.Query("facets") == "true",
+		Interval: c.DefaultQuery("interval", service.DefaultFacetInterval),
+		Filters: service.FacetFilters{
+			Senders:   c.QueryArray("facet_sender"),
+			Receivers: c.QueryArray("facet_receiver"),
+			Folders:   c.QueryArray("facet_folder"),
+		},
+	}
+
+	if !service.ValidFacetInterval(options.Interval) {
+		return options, fmt.Errorf("Intervalo inválido: debe ser %s", strings.Join(service.FacetIntervals(), ", "))
+	}
+
+	const maxFacetSize = 100
+	facetSize, err := strconv.Atoi(c.DefaultQuery("facet_size", "10"))
+	if err != nil || facetSize <= 0 || facetSize > maxFacetSize {
+		return options, fmt.Errorf("Tamaño de faceta inválido: debe estar entre 1 y %d", maxFacetSize)
+	}
+	options.FacetSize = facetSize
+
+	for _, value := range c.QueryArray("facet_date") {
+		date, err := service.ParseFacetDate(value)
+		if err != nil {
+			return options, fmt.Errorf("Fecha de faceta inválida: %q (use AAAA-MM-DD o el valor de la faceta)", value)
+		}
+		options.Filters.Dates = append(options.Filters.Dates, date)
+	}
+
+	return options, nil
+}
+
+// GetAttachments maneja la ruta GET /emails/:id/attachments y devuelve los adjuntos de un correo.
+func (ec *EmailController) GetAttachments(c *gin.Context) {
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	email, err := ec.emailService.GetEmailByID(idInt)
+	if err != nil {
+		fmt.Printf("Error en GetAttachmentsHandler: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los adjuntos"})
+		return
+	}
+
+	if email == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Correo no encontrado"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"attachments": email.Attachments})
+}
+
+// DownloadAttachment maneja la ruta GET /emails/:id/attachments/:attachmentId y devuelve el archivo.
+func (ec *EmailController) DownloadAttachment(c *gin.Context) {
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	attachmentID, err := strconv.Atoi(c.Param("attachmentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de adjunto inválido"})
+		return
+	}
+
+	attachment, err := ec.emailService.GetAttachment(idInt, attachmentID)
+	if err != nil {
+		fmt.Printf("Error en DownloadAttachmentHandler: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el adjunto"})
+		return
+	}
+
+	if attachment == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Adjunto no encontrado"})
+		return
+	}
+
+	file, err := ec.store.Open(attachment.Hash)
+	if err != nil {
+		fmt.Printf("Error en DownloadAttachmentHandler: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al leer el adjunto"})
+		return
+	}
+	defer file.Close()
+
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, file, map[string]string{
+		"Content-Disposition": mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}),
+	})
+}
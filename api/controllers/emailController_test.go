@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"project/domain/model"
+	"project/domain/service"
+	"project/infrastructure/memory"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestRouter crea el router de /emails sobre un repositorio en memoria con
+// cinco correos de distintos remitentes, carpetas, dueños y fechas.
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	date := func(day int) sql.NullTime {
+		return sql.NullTime{Time: time.Date(2001, 5, day, 12, 0, 0, 0, time.UTC), Valid: true}
+	}
+	email := func(i int, sender, receiver, owner, folder string, date sql.NullTime, body, contentType string) model.Email {
+		return model.Email{
+			MessageID:   fmt.Sprintf("<%d@enron.com>", i),
+			ContentHash: fmt.Sprint(i),
+			Sender:      sender,
+			Subject:     fmt.Sprintf("Asunto %d", i),
+			Body:        body,
+			ContentType: contentType,
+			Date:        date,
+			Participants: []model.Participant{
+				{Email: sender, Role: model.RoleFrom},
+				{Email: receiver, Role: model.RoleTo},
+			},
+			Locations: []model.MessageLocation{{Folder: folder, FilePath: fmt.Sprintf("%s/%d.", owner, i), Owner: owner}},
+		}
+	}
+	repository := memory.NewEmailRepository()
+	_, err := repository.Insert([]model.Email{
+		email(1, "alice@enron.com", "bob@enron.com", "alice", `\alice\inbox`, date(1), "Hola", "text/plain; charset=us-ascii"),
+		email(2, "bob@enron.com", "alice@enron.com", "bob", `\bob\sent`, date(2), "", "text/html"),
+		email(3, "carol@enron.com", "alice@enron.com", "alice", `\alice\inbox`, date(3), "Texto", "text/plain"),
+		email(4, "alice@enron.com", "carol@enron.com", "alice", `\alice\sent`, sql.NullTime{}, "Otro", "text/plain"),
+		email(5, "bob@enron.com", "carol@enron.com", "bob", `\bob\inbox`, date(2), "Fin", "text/plain"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	controller := NewEmailController(service.NewEmailService(repository, nil), nil, service.NewCursorCodec("secreto"))
+	r.GET("/emails", controller.GetEmails)
+	return r
+}
+
+func TestGetEmailsFiltersAndSorts(t *testing.T) {
+	r := newTestRouter(t)
+
+	tests := []struct {
+		query  string
+		status int
+		want   []int
+	}{
+		{"", http.StatusOK, []int{1, 2, 3, 4, 5}},
+		{"sender=ALICE@enron.com", http.StatusOK, []int{1, 4}},
+		{"receiver=alice@enron.com", http.StatusOK, []int{2, 3}},
+		{"participant=carol@enron.com&role=to", http.StatusOK, []int{4, 5}},
+		{"folder=inbox", http.StatusOK, []int{1, 3, 5}},
+		{`folder=\alice\sent`, http.StatusOK, []int{4}},
+		{"owner=bob", http.StatusOK, []int{2, 5}},
+		{"date_from=2001-05-02&date_to=2001-05-02", http.StatusOK, []int{2, 5}},
+		{"date_to=2001-05-02", http.StatusOK, []int{1, 2, 5}},
+		{"has_body=false", http.StatusOK, []int{2}},
+		{"content_type=TEXT/PLAIN", http.StatusOK, []int{1, 3, 4, 5}},
+		{"owner=alice&folder=inbox&sort=date&order=desc", http.StatusOK, []int{3, 1}},
+		{"sort=date", http.StatusOK, []int{4, 1, 2, 5, 3}},
+		{"sort=sender&order=desc", http.StatusOK, []int{3, 5, 2, 4, 1}},
+		{"sort=body", http.StatusBadRequest, nil},
+		{"order=up", http.StatusBadRequest, nil},
+		{"role=to", http.StatusBadRequest, nil},
+		{"participant=alice@enron.com&role=sender", http.StatusBadRequest, nil},
+		{"date_from=01/05/2001", http.StatusBadRequest, nil},
+		{"date_from=2001-05-03&date_to=2001-05-02", http.StatusBadRequest, nil},
+		{"has_body=quizás", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("GET", "/emails?"+tt.query, nil))
+			if w.Code != tt.status {
+				t.Fatalf("código %d, se esperaba %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Emails []struct{ ID int }
+				Total  int
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			got := []int{}
+			for _, email := range response.Emails {
+				got = append(got, email.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) || response.Total != len(tt.want) {
+				t.Errorf("correos %v (total %d), se esperaba %v", got, response.Total, tt.want)
+			}
+		})
+	}
+}
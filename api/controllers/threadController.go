@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"project/domain/model"
+	"project/domain/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ThreadResponse struct {
+	Threads    []model.Thread `json:"threads"`
+	HasNext    bool           `json:"has_next"`
+	HasPrev    bool           `json:"has_prev"`
+	Page       int            `json:"page"`
+	Total      int            `json:"total"`
+	TotalPages int            `json:"total_pages"`
+}
+
+type ThreadController struct {
+	threadService *service.ThreadService
+}
+
+// NewThreadController crea el controlador sobre el servicio de hilos indicado.
+func NewThreadController(threadService *service.ThreadService) *ThreadController {
+	return &ThreadController{
+		threadService: threadService,
+	}
+}
+
+// GetThreads maneja la ruta GET /threads y devuelve los hilos con paginación.
+func (tc *ThreadController) GetThreads(c *gin.Context) {
+	pageInt, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || pageInt < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Página inválida"})
+		return
+	}
+
+	limitInt, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limitInt < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Límite inválido"})
+		return
+	}
+
+	const maxLimit = 100
+	if limitInt > maxLimit {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":           "El límite máximo de registros es 100",
+			"max_limit":       maxLimit,
+			"requested_limit": limitInt,
+		})
+		return
+	}
+
+	offset := (pageInt - 1) * limitInt
+
+	threads, total, err := tc.threadService.GetThreadsWithPagination(offset, limitInt)
+	if err != nil {
+		fmt.Printf("Error en GetThreadsHandler: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los hilos"})
+		return
+	}
+
+	totalPages := (total + limitInt - 1) / limitInt
+
+	c.JSON(http.StatusOK, ThreadResponse{
+		Threads:    threads,
+		Total:      total,
+		Page:       pageInt,
+		TotalPages: totalPages,
+		HasPrev:    pageInt > 1,
+		HasNext:    pageInt < totalPages,
+	})
+}
+
+// GetThreadByID maneja la ruta GET /threads/:id y devuelve el hilo con todos sus correos.
+func (tc *ThreadController) GetThreadByID(c *gin.Context) {
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	thread, err := tc.threadService.GetThreadByID(idInt)
+	if err != nil {
+		fmt.Printf("Error en GetThreadByIDHandler: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el hilo"})
+		return
+	}
+
+	if thread == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Hilo no encontrado"})
+		return
+	}
+
+	c.JSON(http.StatusOK, thread)
+}
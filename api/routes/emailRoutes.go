@@ -6,15 +6,15 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func SetupEmailRoutes(r *gin.Engine) {
-	emailController := controllers.NewEmailController()
-
+func SetupEmailRoutes(r *gin.Engine, emailController *controllers.EmailController) {
 	r.GET("/", func(c *gin.Context) {
 		c.JSON(200, gin.H{"message": "Bienvenido a la API de correos electrónicos"})
 	})
 
 	r.GET("/emails", emailController.GetEmails)
 	r.GET("/emails/:id", emailController.GetEmailByID)
+	r.GET("/emails/:id/attachments", emailController.GetAttachments)
+	r.GET("/emails/:id/attachments/:attachmentId", emailController.DownloadAttachment)
 
 	r.GET("/emails/search", emailController.SearchEmails)
 }
@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"project/api/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupThreadRoutes(r *gin.Engine, threadController *controllers.ThreadController) {
+	r.GET("/threads", threadController.GetThreads)
+	r.GET("/threads/:id", threadController.GetThreadByID)
+}
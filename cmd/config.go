@@ -0,0 +1,210 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"project/domain/service"
+	"project/infrastructure/mysql"
+	"project/infrastructure/searchindex"
+	"project/infrastructure/sqlite"
+	"project/infrastructure/sqlstore"
+	zinc "project/infrastructure/zincsearch"
+
+	"github.com/joho/godotenv"
+)
+
+// loadEnv carga el archivo .env si existe. Las variables ya definidas en el
+// entorno tienen prioridad, lo que permite usar los comandos desde scripts.
+func loadEnv() error {
+	path := os.Getenv("ENV_FILE")
+	if path == "" {
+		path = ".env"
+	}
+
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return godotenv.Load(path)
+}
+
+// newFlagSet crea el conjunto de flags de un comando con un mensaje de uso común.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Uso: labora %s [flags]\n\n%s\n\nFlags:\n", name, commands[name].description)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags interpreta los flags y devuelve el código de salida si hay que terminar.
+func parseFlags(fs *flag.FlagSet, args []string) (int, bool) {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK, false
+		}
+		return exitUsage, false
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "Argumentos inesperados: %v\n", fs.Args())
+		fs.Usage()
+		return exitUsage, false
+	}
+	return exitOK, true
+}
+
+// Tiempo durante el que se reutiliza el nombre del índice activo de ZincSearch
+const indexPointerTTL = 5 * time.Second
+
+// openDB abre la base configurada en STORAGE_DRIVER: MySQL (MYSQL_DSN, por
+// defecto) o un archivo SQLite (SQLITE_PATH). Si AUTO_MIGRATE=true aplica antes
+// las migraciones pendientes.
+func openDB() (*sqlstore.DB, error) {
+	var dbConn *sqlstore.DB
+	var err error
+	switch driver := envOrDefault("STORAGE_DRIVER", "mysql"); driver {
+	case "mysql":
+		dbConn, err = mysql.Open()
+	case "sqlite":
+		dbConn, err = sqlite.Open()
+	default:
+		err = fmt.Errorf("STORAGE_DRIVER inválido: %q (use mysql o sqlite)", driver)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error inicializando base de datos: %w", err)
+	}
+
+	if os.Getenv("AUTO_MIGRATE") == "true" {
+		migrator, err := dbConn.Migrator()
+		if err == nil {
+			var applied []sqlstore.Migration
+			applied, err = migrator.Up(0)
+			for _, m := range applied {
+				fmt.Printf("Migración aplicada: %04d_%s\n", m.Version, m.Name)
+			}
+		}
+		if err != nil {
+			dbConn.Close()
+			return nil, fmt.Errorf("error aplicando migraciones: %w", err)
+		}
+	}
+
+	// El índice de ZincSearch activo se registra en la base (ver reindex)
+	zinc.UseIndexPointer(dbConn.SearchIndexes(), indexPointerTTL)
+
+	return dbConn, nil
+}
+
+// searchBackendName devuelve el motor de búsqueda configurado en SEARCH_BACKEND:
+// zinc (ZincSearch, por defecto) o embedded (índice local en SEARCH_INDEX_DIR).
+func searchBackendName() (string, error) {
+	switch name := envOrDefault("SEARCH_BACKEND", "zinc"); name {
+	case "zinc", "embedded":
+		return name, nil
+	default:
+		return "", fmt.Errorf("SEARCH_BACKEND inválido: %q (use zinc o embedded)", name)
+	}
+}
+
+// newSearchBackend crea el motor de búsqueda configurado en SEARCH_BACKEND.
+// Salvo que SEARCH_FALLBACK=false, si ZincSearch falla se busca en el índice
+// de texto de la base; después de SEARCH_BREAKER_THRESHOLD errores seguidos se
+// deja de consultar ZincSearch durante SEARCH_BREAKER_COOLDOWN.
+func newSearchBackend(dbConn *sqlstore.DB) (service.SearchBackend, error) {
+	name, err := searchBackendName()
+	if err != nil {
+		return nil, err
+	}
+	if name == "embedded" {
+		return openEmbeddedSearch(searchindex.Open)
+	}
+
+	zincSearch := service.NewZincSearchBackend()
+	fallback := dbConn.FullTextSearch()
+	if os.Getenv("SEARCH_FALLBACK") == "false" || fallback == nil {
+		return zincSearch, nil
+	}
+	breaker := service.NewCircuitBreaker(envInt("SEARCH_BREAKER_THRESHOLD", 3), envDuration("SEARCH_BREAKER_COOLDOWN", 30*time.Second))
+	return service.NewFailoverSearch(zincSearch, fallback, breaker), nil
+}
+
+// openEmbeddedSearch abre el índice local de SEARCH_INDEX_DIR con el idioma de
+// SEARCH_LANGUAGE (english, spanish o none). open es searchindex.Open para
+// buscar, searchindex.OpenWriter para agregar correos (ingest) o
+// searchindex.Create para reemplazar todo el contenido al confirmar (reindex).
+func openEmbeddedSearch(open func(string, *searchindex.Analyzer) (*searchindex.Index, error)) (*service.EmbeddedSearch, error) {
+	analyzer, err := searchindex.NewAnalyzer(envOrDefault("SEARCH_LANGUAGE", "english"))
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := open(envOrDefault("SEARCH_INDEX_DIR", "./data/search"), analyzer)
+	if err != nil {
+		return nil, fmt.Errorf("error abriendo el índice local: %w", err)
+	}
+	return service.NewEmbeddedSearch(index), nil
+}
+
+// envOrDefault devuelve la variable de entorno o el valor por defecto si no está definida.
+func envOrDefault(key, def string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return def
+}
+
+// envInt devuelve la variable de entorno como entero o el valor por defecto.
+func envInt(key string, def int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// envDuration devuelve la variable de entorno como duración (por ejemplo "2s") o el valor por defecto.
+func envDuration(key string, def time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// newBulkIndexer crea el indexador por lotes sobre el índice activo de ZincSearch.
+// Antes crea el índice o comprueba que su mapping sea el esperado.
+func newBulkIndexer() (*zinc.BulkIndexer, error) {
+	client := zinc.NewZincSearchClient()
+	if client == nil {
+		return nil, fmt.Errorf("ZINC_URL, ZINC_USERNAME o ZINC_PASSWORD no están configurados")
+	}
+	if err := client.EnsureIndex(); err != nil {
+		return nil, err
+	}
+
+	return client.NewBulkIndexer(bulkConfig()), nil
+}
+
+// bulkConfig devuelve la configuración del indexador por lotes según ZINC_BULK_SIZE y ZINC_BULK_RETRIES.
+func bulkConfig() zinc.BulkConfig {
+	def := zinc.DefaultBulkConfig()
+	return zinc.BulkConfig{
+		BatchSize:  envInt("ZINC_BULK_SIZE", def.BatchSize),
+		MaxRetries: envInt("ZINC_BULK_RETRIES", def.MaxRetries),
+		Backoff:    def.Backoff,
+	}
+}
+
+// reportBulkFailures muestra los documentos que no se pudieron indexar y devuelve cuántos fueron.
+func reportBulkFailures(indexer *zinc.BulkIndexer) int {
+	failed := indexer.Stats().Failed
+	for _, f := range failed {
+		fmt.Printf("Error indexando en ZincSearch: %v\n", f)
+	}
+	return len(failed)
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"project/domain/model"
+	"project/domain/service"
+	"project/infrastructure/blobstore"
+	"project/infrastructure/searchindex"
+	"project/infrastructure/sqlstore"
+	zinc "project/infrastructure/zincsearch"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+func withSemaphore(sem chan struct{}, f func()) {
+	sem <- struct{}{}
+	defer func() { <-sem }()
+	f()
+}
+
+// emailSource identifica un correo en los mensajes de error por el archivo del
+// que se leyó o, si no lo tiene, por su Message-ID.
+func emailSource(email model.Email) string {
+	if len(email.Locations) > 0 {
+		return email.Locations[0].FilePath
+	}
+	return email.MessageID
+}
+
+// runIngest recorre el directorio de correos y guarda cada mensaje en la base de
+// datos y en el motor de búsqueda configurado (ZincSearch o el índice local).
+func runIngest(args []string) int {
+	fs := newFlagSet("ingest")
+	baseDir := fs.String("dir", os.Getenv("BASE_DIR"), "directorio con los correos (por defecto BASE_DIR)")
+	workers := fs.Int("workers", 100, "cantidad máxima de archivos procesados en paralelo")
+	dryRun := fs.Bool("dry-run", false, "solo interpreta los archivos, sin escribir en la base de datos ni en el motor de búsqueda")
+	noIndex := fs.Bool("no-index", false, "no indexar en el motor de búsqueda")
+	noThreads := fs.Bool("no-threads", false, "no reconstruir los hilos al terminar")
+	batchSize := fs.Int("batch-size", envInt("BATCH_SIZE", service.DefaultBatchConfig().Size),
+		"cantidad de correos por transacción de la base de datos (por defecto BATCH_SIZE)")
+	flushInterval := fs.Duration("flush-interval", envDuration("BATCH_FLUSH_INTERVAL", service.DefaultBatchConfig().FlushInterval),
+		"tiempo máximo antes de escribir un lote incompleto (por defecto BATCH_FLUSH_INTERVAL)")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	if *baseDir == "" {
+		fmt.Fprintln(os.Stderr, "BASE_DIR no está definida en las variables de entorno y no se indicó -dir.")
+		return exitUsage
+	}
+	if *workers < 1 || *batchSize < 1 || *flushInterval <= 0 {
+		fmt.Fprintln(os.Stderr, "-workers, -batch-size y -flush-interval deben ser mayores a 0")
+		return exitUsage
+	}
+	if _, err := os.Stat(*baseDir); os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "El directorio %s no existe\n", *baseDir)
+		return exitError
+	}
+
+	var dbConn *sqlstore.DB
+	var emails service.EmailRepository
+	var store *blobstore.Store
+	var indexer *zinc.BulkIndexer
+	var embedded *service.EmbeddedSearch
+	indexName := "ZincSearch"
+	if !*dryRun {
+		var err error
+		dbConn, err = openDB()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
+		}
+		defer dbConn.Close()
+		emails = dbConn.Emails()
+
+		// Las ubicaciones guardadas antes de registrar el dueño del buzón se completan con este BASE_DIR
+		filled, err := dbConn.Emails().FillLocationOwners(*baseDir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
+		}
+		if filled > 0 {
+			fmt.Printf("Se completó el dueño del buzón de %d ubicaciones ya registradas\n", filled)
+		}
+
+		// Almacén en disco para los adjuntos
+		store, err = blobstore.NewStore()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error inicializando el almacén de adjuntos: %v\n", err)
+			return exitError
+		}
+
+		if !*noIndex {
+			var backend string
+			backend, err = searchBackendName()
+			if err == nil && backend == "embedded" {
+				indexName = "el índice local"
+				embedded, err = openEmbeddedSearch(searchindex.OpenWriter)
+			} else if err == nil {
+				indexer, err = newBulkIndexer()
+			}
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return exitError
+			}
+			if embedded != nil {
+				defer embedded.Close()
+			}
+		}
+	}
+
+	sem := make(chan struct{}, *workers)
+
+	var wg sync.WaitGroup
+	results := make(chan model.Email, 100)
+
+	// Captura el tiempo antes de comenzar el procesamiento
+	startTime := time.Now()
+
+	// Goroutine para recolectar los correos y enviarlos al escritor por lotes
+	emailsRead := 0
+	invalidDates := 0
+	var fileErrors int64
+	toWrite := make(chan model.Email, *batchSize)
+	collectorDone := make(chan struct{})
+	go func() {
+		defer close(collectorDone)
+		defer close(toWrite)
+		for email := range results {
+			emailsRead++
+			if email.DateRaw != "" && !email.Date.Valid {
+				invalidDates++
+			}
+			for i := range email.Locations {
+				email.Locations[i].Owner = service.MailboxOwner(*baseDir, email.Locations[i].FilePath)
+			}
+			if !*dryRun {
+				toWrite <- email
+			}
+		}
+	}()
+
+	// Goroutine que guarda los lotes en la base de datos e indexa los correos nuevos
+	emailsSaved := 0
+	duplicates := 0
+	emailsIndexed := 0
+	failures := 0
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		if *dryRun {
+			for range toWrite {
+			}
+			return
+		}
+
+		writer := service.NewEmailWriter(emails, store, service.BatchConfig{
+			Size:          *batchSize,
+			FlushInterval: *flushInterval,
+			MaxRetries:    service.DefaultBatchConfig().MaxRetries,
+		})
+		writer.Run(toWrite, func(batch service.BatchResult) {
+			if batch.Err != nil {
+				fmt.Printf("Error guardando el lote %d (%d correos, %d intentos): %v\n",
+					batch.Number, batch.Emails, batch.Attempts, batch.Err)
+				failures += batch.Emails
+				return
+			}
+			for _, rejected := range batch.Rejected {
+				fmt.Printf("Error guardando el correo %s del lote %d: %v\n",
+					emailSource(rejected.Email), batch.Number, rejected.Err)
+			}
+			failures += len(batch.Rejected)
+			emailsSaved += len(batch.Created)
+			duplicates += batch.Duplicates
+
+			if *noIndex {
+				return
+			}
+
+			if embedded != nil {
+				// El índice local escribe un segmento cada varios lotes y al confirmar
+				if err := embedded.AddEmails(batch.Created...); err != nil {
+					fmt.Printf("Error indexando en el índice local: %v\n", err)
+					failures += len(batch.Created)
+				} else {
+					emailsIndexed += len(batch.Created)
+				}
+				return
+			}
+
+			// Indexar en ZincSearch con la API _bulk; cada lote guardado se envía al terminar
+			for _, email := range batch.Created {
+				if err := indexer.AddEmail(email); err != nil {
+					fmt.Printf("Error indexando en ZincSearch: %v\n", err)
+				}
+			}
+			if err := indexer.Flush(); err != nil {
+				fmt.Printf("Error indexando en ZincSearch: %v\n", err)
+			}
+		})
+
+		if indexer != nil {
+			emailsIndexed = indexer.Stats().Indexed
+			failures += reportBulkFailures(indexer)
+		}
+		if embedded != nil {
+			if err := embedded.Commit(); err != nil {
+				fmt.Printf("Error indexando en el índice local: %v\n", err)
+				failures += emailsIndexed
+				emailsIndexed = 0
+			}
+		}
+	}()
+
+	// Recorrer los archivos del directorio
+	fmt.Println("Procesando los datos...")
+	err := filepath.Walk(*baseDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() {
+			wg.Add(1)
+
+			go withSemaphore(sem, func() {
+				defer wg.Done()
+				err := service.ProcessFile(path, results, &wg)
+				if err != nil {
+					fmt.Printf("Error procesando archivo %s: %v\n", path, err)
+					atomic.AddInt64(&fileErrors, 1)
+				}
+			})
+		}
+		return nil
+	})
+
+	wg.Wait()
+
+	close(results)
+	// Esperar a que se guarden los últimos lotes
+	<-collectorDone
+	<-writerDone
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error recorriendo el directorio: %v\n", err)
+		return exitError
+	}
+
+	// Calcular tiempo total de ejecución del procesamiento
+	elapsedTime := time.Since(startTime)
+	fmt.Printf("Todos los correos fueron procesados en: %v\n", elapsedTime)
+	fmt.Printf("Se leyeron %d correos.\n", emailsRead)
+	if invalidDates > 0 {
+		fmt.Printf("%d correos tienen una fecha que no se pudo interpretar (se guardaron sin fecha).\n", invalidDates)
+	}
+
+	if *dryRun {
+		fmt.Println("Modo dry-run: no se escribió nada en la base de datos ni en el motor de búsqueda.")
+		if fileErrors > 0 {
+			return exitPartial
+		}
+		return exitOK
+	}
+
+	fmt.Printf("Se guardaron %d correos en la base de datos correctamente.\n", emailsSaved)
+	fmt.Printf("Se indexaron %d correos en %s correctamente.\n", emailsIndexed, indexName)
+	fmt.Printf("Se omitieron %d copias de correos ya guardados.\n", duplicates)
+
+	if !*noThreads {
+		// Reconstruir los hilos de conversación con todos los correos guardados
+		threadCount, err := service.NewThreadService(dbConn.Threads(), emails).RebuildThreads()
+		if err != nil {
+			fmt.Printf("Error reconstruyendo los hilos: %v\n", err)
+			failures++
+		} else {
+			fmt.Printf("Se reconstruyeron %d hilos de conversación.\n", threadCount)
+		}
+	}
+
+	if fileErrors > 0 || failures > 0 {
+		fmt.Printf("Hubo %d archivos con errores y %d correos que no se pudieron guardar o indexar.\n", fileErrors, failures)
+		return exitPartial
+	}
+	return exitOK
+}
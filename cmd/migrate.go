@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// runMigrate aplica o revierte las migraciones embebidas del esquema.
+func runMigrate(args []string) int {
+	fs := newFlagSet("migrate")
+	down := fs.Int("down", 0, "revierte las últimas N migraciones aplicadas")
+	to := fs.Int("to", 0, "aplica las migraciones hasta esta versión (0 = todas)")
+	status := fs.Bool("status", false, "muestra el estado de cada migración sin aplicar nada")
+	dryRun := fs.Bool("dry-run", false, "muestra las migraciones pendientes sin aplicarlas")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if *down < 0 || *to < 0 {
+		fmt.Fprintln(os.Stderr, "-down y -to no pueden ser negativos")
+		return exitUsage
+	}
+	if *down > 0 && *to > 0 {
+		fmt.Fprintln(os.Stderr, "-down y -to no se pueden usar juntos")
+		return exitUsage
+	}
+
+	dbConn, err := openDB()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	defer dbConn.Close()
+
+	migrator, err := dbConn.Migrator()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	if *status || *dryRun {
+		statuses, err := migrator.Status()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
+		}
+		for _, s := range statuses {
+			if !s.Applied {
+				fmt.Printf("pendiente  %04d_%s\n", s.Version, s.Name)
+			} else if *status {
+				fmt.Printf("aplicada   %04d_%s (%s)\n", s.Version, s.Name, s.AppliedAt.Time.Format("2006-01-02 15:04:05"))
+			}
+		}
+		return exitOK
+	}
+
+	if *down > 0 {
+		reverted, err := migrator.Down(*down)
+		for _, m := range reverted {
+			fmt.Printf("Revertida %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
+		}
+		return exitOK
+	}
+
+	applied, err := migrator.Up(*to)
+	for _, m := range applied {
+		fmt.Printf("Aplicada %04d_%s\n", m.Version, m.Name)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	if len(applied) == 0 {
+		fmt.Println("El esquema está actualizado.")
+	}
+	return exitOK
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"project/domain/model"
+	"project/domain/service"
+	"project/infrastructure/searchindex"
+	zinc "project/infrastructure/zincsearch"
+	"time"
+)
+
+// runReindex indexa en el motor de búsqueda todos los correos guardados en la base de datos.
+//
+// Por defecto construye una nueva versión del índice (por ejemplo emails_v3),
+// comprueba que tenga tantos documentos como correos hay en la base y recién
+// entonces cambia el índice activo, por lo que la búsqueda sigue funcionando
+// mientras tanto. Con -in-place se reindexa sobre el índice activo.
+//
+// Con SEARCH_BACKEND=embedded reconstruye el índice local, que también se
+// reemplaza recién al terminar; -in-place, -drop-old y -count-timeout no se usan.
+func runReindex(args []string) int {
+	fs := newFlagSet("reindex")
+	batchSize := fs.Int("batch-size", 500, "cantidad de correos leídos de la base de datos por consulta")
+	dryRun := fs.Bool("dry-run", false, "solo cuenta los correos que se indexarían")
+	inPlace := fs.Bool("in-place", false, "indexar sobre el índice activo en lugar de crear una nueva versión")
+	dropOld := fs.Bool("drop-old", false, "eliminar el índice anterior después de cambiar al nuevo")
+	countTimeout := fs.Duration("count-timeout", time.Minute, "tiempo máximo de espera para que ZincSearch informe todos los documentos")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if *batchSize < 1 {
+		fmt.Fprintln(os.Stderr, "-batch-size debe ser mayor a 0")
+		return exitUsage
+	}
+	if *inPlace && *dropOld {
+		fmt.Fprintln(os.Stderr, "-drop-old no se puede usar con -in-place")
+		return exitUsage
+	}
+
+	dbConn, err := openDB()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	defer dbConn.Close()
+
+	emailService := service.NewEmailService(dbConn.Emails(), nil)
+
+	backend, err := searchBackendName()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	if backend == "embedded" {
+		return reindexEmbedded(emailService, *batchSize, *dryRun)
+	}
+
+	indexStore := dbConn.SearchIndexes()
+	alias := zinc.IndexAlias()
+
+	active, err := indexStore.ActiveIndex(alias)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	if active == "" {
+		active = alias
+	}
+
+	target := active
+	if !*inPlace {
+		target = zinc.VersionedIndexName(alias, zinc.NextIndexVersion(alias, active))
+	}
+
+	var client *zinc.ZincSearchClient
+	var indexer *zinc.BulkIndexer
+	if !*dryRun {
+		client = zinc.NewZincSearchClient()
+		if client == nil {
+			fmt.Fprintln(os.Stderr, "ZINC_URL, ZINC_USERNAME o ZINC_PASSWORD no están configurados")
+			return exitError
+		}
+		client = client.WithIndex(target)
+
+		if !*inPlace {
+			// Una versión que quedó a medias en una ejecución anterior no está activa y se puede descartar
+			exists, err := client.IndexExists()
+			if err == nil && exists {
+				fmt.Printf("El índice %s ya existe y no está activo; se elimina.\n", target)
+				err = client.DeleteIndex()
+			}
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return exitError
+			}
+		}
+		if err := client.EnsureIndex(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
+		}
+
+		indexer = client.NewBulkIndexer(bulkConfig())
+	}
+
+	fmt.Printf("Indexando en %s (índice activo: %s)...\n", target, active)
+	startTime := time.Now()
+
+	indexBatch := func(emails []model.Email) error {
+		for _, email := range emails {
+			if *dryRun {
+				continue
+			}
+			if err := indexer.AddEmail(email); err != nil {
+				fmt.Printf("Error indexando en ZincSearch: %v\n", err)
+			}
+		}
+		return nil
+	}
+	read, lastID, err := forEachEmailBatch(emailService, *batchSize, 0, indexBatch)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	if *dryRun {
+		fmt.Printf("Modo dry-run: se indexarían %d correos en %s.\n", read, target)
+		return exitOK
+	}
+
+	if err := indexer.Flush(); err != nil {
+		fmt.Printf("Error indexando en ZincSearch: %v\n", err)
+	}
+	failures := reportBulkFailures(indexer)
+
+	stats := indexer.Stats()
+	fmt.Printf("Se indexaron %d correos en %v (%d reintentos).\n", stats.Indexed, time.Since(startTime), stats.Retries)
+	if failures > 0 {
+		fmt.Printf("%d correos no se pudieron indexar.\n", failures)
+		if !*inPlace {
+			fmt.Printf("No se cambió el índice activo; sigue siendo %s.\n", active)
+		}
+		return exitPartial
+	}
+	if *inPlace {
+		return exitOK
+	}
+
+	// ZincSearch actualiza las estadísticas del índice con cierta demora
+	docs, err := waitForDocumentCount(client, read, *countTimeout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\nNo se cambió el índice activo; sigue siendo %s.\n", err, active)
+		return exitError
+	}
+
+	// Los correos que ingest guardó mientras tanto solo llegaron al índice
+	// activo: se agregan al nuevo justo antes de cambiarlo
+	caughtUp, _, err := forEachEmailBatch(emailService, *batchSize, lastID, indexBatch)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\nNo se cambió el índice activo; sigue siendo %s.\n", err, active)
+		return exitError
+	}
+	if caughtUp > 0 {
+		if err := indexer.Flush(); err != nil {
+			fmt.Printf("Error indexando en ZincSearch: %v\n", err)
+		}
+		if failures := reportBulkFailures(indexer); failures > 0 {
+			fmt.Printf("%d correos no se pudieron indexar.\nNo se cambió el índice activo; sigue siendo %s.\n", failures, active)
+			return exitPartial
+		}
+		fmt.Printf("Se indexaron %d correos guardados durante la reindexación.\n", caughtUp)
+		read += caughtUp
+		if docs, err = waitForDocumentCount(client, read, *countTimeout); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\nNo se cambió el índice activo; sigue siendo %s.\n", err, active)
+			return exitError
+		}
+	}
+
+	previous, err := indexStore.SwitchIndex(alias, target, docs)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	fmt.Printf("El índice activo de %s ahora es %s (%d documentos).\n", alias, target, docs)
+
+	if previous == "" {
+		previous = active
+	}
+	if *dropOld && previous != target {
+		old := client.WithIndex(previous)
+		exists, err := old.IndexExists()
+		if err == nil && exists {
+			err = old.DeleteIndex()
+		}
+		if err != nil {
+			fmt.Printf("No se pudo eliminar el índice anterior: %v\n", err)
+			return exitPartial
+		}
+		fmt.Printf("Se eliminó el índice anterior %s.\n", previous)
+	}
+	return exitOK
+}
+
+// reindexEmbedded reconstruye el índice local con todos los correos de la base.
+// Los demás procesos siguen usando el índice anterior hasta el Commit final.
+func reindexEmbedded(emailService *service.EmailService, batchSize int, dryRun bool) int {
+	var embedded *service.EmbeddedSearch
+	if !dryRun {
+		var err error
+		embedded, err = openEmbeddedSearch(searchindex.Create)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
+		}
+		defer embedded.Close()
+	}
+
+	fmt.Println("Reconstruyendo el índice local...")
+	startTime := time.Now()
+
+	read, _, err := forEachEmailBatch(emailService, batchSize, 0, func(emails []model.Email) error {
+		if dryRun {
+			return nil
+		}
+		return embedded.AddEmails(emails...)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\nNo se modificó el índice local.\n", err)
+		return exitError
+	}
+	if dryRun {
+		fmt.Printf("Modo dry-run: se indexarían %d correos en el índice local.\n", read)
+		return exitOK
+	}
+
+	if err := embedded.Commit(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	fmt.Printf("Se indexaron %d correos en el índice local en %v.\n", embedded.Count(), time.Since(startTime))
+	return exitOK
+}
+
+// forEachEmailBatch recorre por id los correos de la base posteriores a
+// afterID, en lotes de batchSize, y devuelve cuántos leyó y el id del último.
+func forEachEmailBatch(emailService *service.EmailService, batchSize, afterID int, f func([]model.Email) error) (int, int, error) {
+	read, lastID := 0, afterID
+	for {
+		emails, err := emailService.GetEmailsAfterID(lastID, batchSize)
+		if err != nil {
+			return read, lastID, fmt.Errorf("error leyendo correos desde la base de datos: %w", err)
+		}
+		if len(emails) == 0 {
+			return read, lastID, nil
+		}
+		if err := f(emails); err != nil {
+			return read, lastID, err
+		}
+		lastID = emails[len(emails)-1].ID
+		read += len(emails)
+	}
+}
+
+// waitForDocumentCount espera a que el índice informe la cantidad de documentos esperada.
+func waitForDocumentCount(client *zinc.ZincSearchClient, expected int, timeout time.Duration) (int, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		docs, err := client.DocumentCount()
+		if err != nil {
+			return 0, err
+		}
+		if docs == expected {
+			return docs, nil
+		}
+		if time.Now().After(deadline) {
+			return docs, fmt.Errorf("el índice %s tiene %d documentos y se esperaban %d", client.Index(), docs, expected)
+		}
+		time.Sleep(2 * time.Second)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"project/api/controllers"
+	"project/api/routes"
+	"project/domain/service"
+	"project/infrastructure/blobstore"
+	zinc "project/infrastructure/zincsearch"
+
+	"github.com/gin-gonic/gin"
+)
+
+// runServe inicia la API HTTP sin procesar los correos.
+func runServe(args []string) int {
+	fs := newFlagSet("serve")
+	port := fs.String("port", envOrDefault("PORT", "8080"), "puerto en el que escucha la API (por defecto PORT o 8080)")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	// La conexión también registra el puntero al índice activo de ZincSearch
+	dbConn, err := openDB()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	defer dbConn.Close()
+
+	search, err := newSearchBackend(dbConn)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	// Crear el índice de ZincSearch o comprobar su mapping antes de aceptar
+	// búsquedas. Con el respaldo en la base se puede empezar sin ZincSearch,
+	// pero no con un índice cuyo mapping difiere del esperado.
+	if search.Name() == "zinc" {
+		if client := zinc.NewZincSearchClient(); client != nil {
+			if err := client.EnsureIndex(); err != nil {
+				if _, failover := search.(*service.FailoverSearch); !failover || !zinc.IsUnavailable(err) {
+					fmt.Fprintf(os.Stderr, "Error preparando el índice de ZincSearch: %v\n", err)
+					return exitError
+				}
+				fmt.Printf("ZincSearch no está disponible, se buscará en la base de datos: %v\n", err)
+			}
+		}
+	}
+
+	store, err := blobstore.NewStore()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error inicializando el almacén de adjuntos: %v\n", err)
+		return exitError
+	}
+
+	secret := os.Getenv("CURSOR_SECRET")
+	if secret == "" {
+		fmt.Println("CURSOR_SECRET no está definida: los cursores de paginación dejarán de ser válidos al reiniciar la API.")
+	}
+
+	emails := dbConn.Emails()
+	emailController := controllers.NewEmailController(service.NewEmailService(emails, search), store, service.NewCursorCodec(secret))
+	threadController := controllers.NewThreadController(service.NewThreadService(dbConn.Threads(), emails))
+
+	// Iniciar el servidor de Gin
+	r := gin.Default()
+	routes.SetupEmailRoutes(r, emailController)
+	routes.SetupThreadRoutes(r, threadController)
+
+	if err := r.Run(":" + *port); err != nil {
+		fmt.Fprintf(os.Stderr, "Error al iniciar el servidor: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+type stats struct {
+	Emails       int        `json:"emails"`
+	Copies       int        `json:"copies"`
+	Contacts     int        `json:"contacts"`
+	Threads      int        `json:"threads"`
+	Attachments  int        `json:"attachments"`
+	InvalidDates int        `json:"invalid_dates"`
+	Folders      int        `json:"folders"`
+	FirstDate    *time.Time `json:"first_date,omitempty"`
+	LastDate     *time.Time `json:"last_date,omitempty"`
+}
+
+// runStats muestra cuántos correos, contactos, hilos y adjuntos hay guardados.
+func runStats(args []string) int {
+	fs := newFlagSet("stats")
+	asJSON := fs.Bool("json", false, "muestra el resultado en formato JSON")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	dbConn, err := openDB()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	defer dbConn.Close()
+
+	var s stats
+	var firstDate, lastDate sql.NullTime
+	queries := []struct {
+		query string
+		dest  []interface{}
+	}{
+		{"SELECT COUNT(*) FROM emails", []interface{}{&s.Emails}},
+		{"SELECT COUNT(*) FROM message_locations", []interface{}{&s.Copies}},
+		{"SELECT COUNT(DISTINCT folder) FROM message_locations", []interface{}{&s.Folders}},
+		{"SELECT COUNT(*) FROM contacts", []interface{}{&s.Contacts}},
+		{"SELECT COUNT(*) FROM threads", []interface{}{&s.Threads}},
+		{"SELECT COUNT(*) FROM attachments", []interface{}{&s.Attachments}},
+		{"SELECT COUNT(*) FROM emails WHERE date IS NULL AND date_raw <> ''", []interface{}{&s.InvalidDates}},
+	}
+	for _, q := range queries {
+		if err := dbConn.QueryRow(q.query).Scan(q.dest...); err != nil {
+			fmt.Fprintf(os.Stderr, "Error obteniendo estadísticas: %v\n", err)
+			return exitError
+		}
+	}
+
+	// Las fechas extremas se leen de la columna y no con MIN y MAX, porque SQLite
+	// devuelve el resultado de esas funciones como texto y no como fecha
+	dateQueries := []struct {
+		query string
+		dest  *sql.NullTime
+	}{
+		{"SELECT date FROM emails WHERE date IS NOT NULL ORDER BY date LIMIT 1", &firstDate},
+		{"SELECT date FROM emails WHERE date IS NOT NULL ORDER BY date DESC LIMIT 1", &lastDate},
+	}
+	for _, q := range dateQueries {
+		if err := dbConn.QueryRow(q.query).Scan(q.dest); err != nil && err != sql.ErrNoRows {
+			fmt.Fprintf(os.Stderr, "Error obteniendo estadísticas: %v\n", err)
+			return exitError
+		}
+	}
+
+	if firstDate.Valid {
+		s.FirstDate, s.LastDate = &firstDate.Time, &lastDate.Time
+	}
+
+	if *asJSON {
+		if err := json.NewEncoder(os.Stdout).Encode(s); err != nil {
+			return exitError
+		}
+		return exitOK
+	}
+
+	fmt.Printf("Correos:            %d\n", s.Emails)
+	fmt.Printf("Copias en carpetas: %d\n", s.Copies)
+	fmt.Printf("Carpetas:           %d\n", s.Folders)
+	fmt.Printf("Contactos:          %d\n", s.Contacts)
+	fmt.Printf("Hilos:              %d\n", s.Threads)
+	fmt.Printf("Adjuntos:           %d\n", s.Attachments)
+	fmt.Printf("Fechas inválidas:   %d\n", s.InvalidDates)
+	if s.FirstDate != nil {
+		fmt.Printf("Rango de fechas:    %s - %s\n", s.FirstDate.Format("2006-01-02"), s.LastDate.Format("2006-01-02"))
+	}
+	return exitOK
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"project/infrastructure/blobstore"
+	"project/infrastructure/searchindex"
+	"project/infrastructure/sqlstore"
+	zincSearchClient "project/infrastructure/zincsearch"
+)
+
+// runVerify comprueba que la base de datos, el motor de búsqueda y el almacén de adjuntos estén
+// disponibles y sean consistentes entre sí. Devuelve exitPartial si encuentra
+// inconsistencias, para poder usarlo en scripts de monitoreo.
+func runVerify(args []string) int {
+	fs := newFlagSet("verify")
+	skipZinc := fs.Bool("skip-zinc", false, "no comprobar el motor de búsqueda (ZincSearch o el índice local)")
+	skipBlobs := fs.Bool("skip-blobs", false, "no comprobar los archivos de adjuntos")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	problems := 0
+	report := func(ok bool, format string, a ...interface{}) {
+		status := "OK   "
+		if !ok {
+			status = "ERROR"
+			problems++
+		}
+		fmt.Printf("[%s] %s\n", status, fmt.Sprintf(format, a...))
+	}
+
+	dbConn, err := openDB()
+	if err != nil {
+		report(false, "Conexión a la base de datos: %v", err)
+		return exitError
+	}
+	defer dbConn.Close()
+	report(true, "Conexión a %s", dbConn.Dialect.Name)
+
+	migrator, err := dbConn.Migrator()
+	if err != nil {
+		report(false, "Migraciones: %v", err)
+		return exitError
+	}
+	statuses, err := migrator.Status()
+	if err != nil {
+		// Incluye migraciones modificadas después de aplicarse (checksum distinto)
+		report(false, "Esquema: %v", err)
+		return exitPartial
+	}
+	if pending := sqlstore.Pending(statuses); len(pending) > 0 {
+		report(false, "Esquema: %d migraciones pendientes (ejecute \"labora migrate\")", len(pending))
+		return exitPartial
+	}
+	report(true, "Esquema")
+
+	var emailCount int
+	if err := dbConn.QueryRow("SELECT COUNT(*) FROM emails").Scan(&emailCount); err != nil {
+		report(false, "Conteo de correos: %v", err)
+		return exitError
+	}
+
+	var orphans int
+	err = dbConn.QueryRow(`SELECT COUNT(*) FROM emails e
+		WHERE NOT EXISTS (SELECT 1 FROM message_locations l WHERE l.email_id = e.id)`).Scan(&orphans)
+	if err != nil {
+		report(false, "Ubicaciones: %v", err)
+	} else {
+		report(orphans == 0, "Ubicaciones: %d correos sin carpeta registrada", orphans)
+	}
+
+	if !*skipZinc {
+		switch backend, err := searchBackendName(); {
+		case err != nil:
+			report(false, "Motor de búsqueda: %v", err)
+		case backend == "embedded":
+			if embedded, err := openEmbeddedSearch(searchindex.Open); err != nil {
+				report(false, "Índice local: %v", err)
+			} else {
+				docs := embedded.Count()
+				report(docs == emailCount, "Índice local: %d documentos indexados, %d correos en la base", docs, emailCount)
+			}
+		default:
+			client := zincSearchClient.NewZincSearchClient()
+			if client == nil {
+				report(false, "ZincSearch: faltan las variables ZINC_URL, ZINC_USERNAME o ZINC_PASSWORD")
+			} else if docs, err := client.DocumentCount(); err != nil {
+				report(false, "ZincSearch: %v", err)
+			} else {
+				report(docs == emailCount, "ZincSearch: %d documentos indexados, %d correos en la base", docs, emailCount)
+				if missing, err := client.CheckMapping(); err != nil {
+					report(false, "ZincSearch: %v", err)
+				} else {
+					report(len(missing) == 0, "ZincSearch: mapping del índice %s (%d campos faltantes)", client.Index(), len(missing))
+				}
+			}
+		}
+	}
+
+	if !*skipBlobs {
+		store, err := blobstore.NewStore()
+		if err != nil {
+			report(false, "Adjuntos: %v", err)
+		} else {
+			missingBlobs, err := countMissingBlobs(dbConn.DB, store)
+			if err != nil {
+				report(false, "Adjuntos: %v", err)
+			} else {
+				report(missingBlobs == 0, "Adjuntos: %d archivos faltantes en el almacén", missingBlobs)
+			}
+		}
+	}
+
+	if problems > 0 {
+		fmt.Printf("Se encontraron %d problemas.\n", problems)
+		return exitPartial
+	}
+	return exitOK
+}
+
+// countMissingBlobs cuenta los adjuntos registrados cuyo archivo no está en el almacén.
+func countMissingBlobs(dbConn *sql.DB, store *blobstore.Store) (int, error) {
+	rows, err := dbConn.Query("SELECT DISTINCT hash FROM attachments")
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	missing := 0
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return 0, err
+		}
+		if _, err := os.Stat(store.Path(hash)); err != nil {
+			missing++
+		}
+	}
+	return missing, rows.Err()
+}
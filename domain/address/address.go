@@ -0,0 +1,160 @@
+package address
+
+import (
+	"net/mail"
+	"regexp"
+	"strings"
+
+	"project/domain/mimeparse"
+)
+
+// Address es una dirección de correo con su nombre visible opcional.
+type Address struct {
+	Name  string // Nombre visible, por ejemplo "Alice Smith"
+	Email string // Dirección en minúsculas, por ejemplo "alice@example.com"
+}
+
+var (
+	angleAddrRe = regexp.MustCompile(`<([^<>]*)>`)
+	groupRe     = regexp.MustCompile(`^[^"<>@,]*:`)
+)
+
+// ParseList interpreta una lista de direcciones de una cabecera From, To, Cc
+// o Bcc según RFC 5322: grupos ("Equipo: a@x.com, b@x.com;"), nombres entre
+// comillas y direcciones entre ángulos. Si la lista no cumple la norma se usa
+// un análisis tolerante que separa por comas fuera de comillas y ángulos.
+// Las direcciones repetidas se devuelven una sola vez.
+func ParseList(value string) []Address {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+
+	var result []Address
+	if list, err := mail.ParseAddressList(value); err == nil {
+		for _, addr := range list {
+			result = append(result, Address{Name: strings.TrimSpace(addr.Name), Email: addr.Address})
+		}
+	} else {
+		for _, item := range splitList(value) {
+			if addr, ok := parseLoose(item); ok {
+				result = append(result, addr)
+			}
+		}
+	}
+
+	return normalize(result)
+}
+
+// splitList separa la lista por comas que no estén dentro de comillas, ángulos
+// o comentarios, y elimina la sintaxis de grupos.
+func splitList(value string) []string {
+	var items []string
+	var current strings.Builder
+	inQuotes := false
+	angle, comment := 0, 0
+
+	flush := func() {
+		item := strings.TrimSpace(current.String())
+		current.Reset()
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c == '\\' && inQuotes && i+1 < len(value):
+			current.WriteByte(c)
+			i++
+			c = value[i]
+		case c == '"':
+			inQuotes = !inQuotes
+		case inQuotes:
+		case c == '<':
+			angle++
+		case c == '>' && angle > 0:
+			angle--
+		case c == '(':
+			comment++
+		case c == ')' && comment > 0:
+			comment--
+		case (c == ',' || c == ';') && angle == 0 && comment == 0:
+			flush()
+			continue
+		case c == ':' && angle == 0 && comment == 0:
+			// "nombre-de-grupo:" inicia un grupo; se descarta el nombre
+			if groupRe.MatchString(current.String() + ":") {
+				current.Reset()
+				continue
+			}
+		}
+		current.WriteByte(c)
+	}
+	flush()
+
+	return items
+}
+
+// parseLoose interpreta un único elemento con formato "Nombre <dir>", "<dir>"
+// o "dir", tolerando nombres sin comillas y direcciones entre comillas simples.
+func parseLoose(item string) (Address, bool) {
+	if addr, err := mail.ParseAddress(item); err == nil {
+		return Address{Name: strings.TrimSpace(addr.Name), Email: addr.Address}, true
+	}
+
+	if m := angleAddrRe.FindStringSubmatchIndex(item); m != nil {
+		email := cleanEmail(item[m[2]:m[3]])
+		name := strings.TrimSpace(item[:m[0]] + " " + item[m[1]:])
+		name = strings.Trim(name, `"' `)
+		return Address{Name: mimeparse.DecodeHeader(name), Email: email}, email != ""
+	}
+
+	email := cleanEmail(item)
+	if !strings.Contains(email, "@") {
+		return Address{}, false
+	}
+	return Address{Email: email}, true
+}
+
+func cleanEmail(value string) string {
+	value = strings.TrimSpace(value)
+	value = strings.Trim(value, `"'<> `)
+	if idx := strings.IndexAny(value, " \t"); idx >= 0 {
+		value = value[:idx]
+	}
+	return value
+}
+
+// normalize pasa las direcciones a minúsculas y elimina duplicados.
+func normalize(list []Address) []Address {
+	seen := make(map[string]bool)
+	var result []Address
+	for _, addr := range list {
+		addr.Email = strings.ToLower(strings.Trim(addr.Email, `'" `))
+		if addr.Email == "" || seen[addr.Email] {
+			continue
+		}
+		seen[addr.Email] = true
+		result = append(result, addr)
+	}
+	return result
+}
+
+// Emails devuelve las direcciones de una o varias cabeceras, en minúsculas,
+// una por elemento y sin repetir. Nunca devuelve nil, para que los documentos
+// indexados serialicen una lista vacía.
+func Emails(values ...string) []string {
+	result := []string{}
+	seen := make(map[string]bool)
+	for _, value := range values {
+		for _, addr := range ParseList(value) {
+			if !seen[addr.Email] {
+				seen[addr.Email] = true
+				result = append(result, addr.Email)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,79 @@
+package address
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseList(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []Address
+	}{
+		{"vacía", "  ", nil},
+		{"dirección sola", "alice@example.com", []Address{{Email: "alice@example.com"}}},
+		{"mayúsculas", "Alice.Smith@Example.COM", []Address{{Email: "alice.smith@example.com"}}},
+		{
+			"nombres y ángulos",
+			`"Smith, Alice" <alice@example.com>, Bob <bob@example.com>`,
+			[]Address{{Name: "Smith, Alice", Email: "alice@example.com"}, {Name: "Bob", Email: "bob@example.com"}},
+		},
+		{
+			"grupo",
+			"Equipo: a@example.com, b@example.com;",
+			[]Address{{Email: "a@example.com"}, {Email: "b@example.com"}},
+		},
+		{
+			"duplicados con otra capitalización",
+			"a@example.com, A@EXAMPLE.com",
+			[]Address{{Email: "a@example.com"}},
+		},
+		{
+			"nombre codificado",
+			"=?iso-8859-1?q?Jos=E9?= <jose@example.com>",
+			[]Address{{Name: "José", Email: "jose@example.com"}},
+		},
+		{
+			// Sin comillas la coma separa elementos: "Smith" se descarta por no ser una dirección
+			"coma sin comillas en el nombre",
+			"Smith, John <john.smith@enron.com>, jane.doe@enron.com",
+			[]Address{{Name: "John", Email: "john.smith@enron.com"}, {Email: "jane.doe@enron.com"}},
+		},
+		{
+			"direcciones entre comillas simples",
+			"'alice@example.com', bob@example.com",
+			[]Address{{Email: "alice@example.com"}, {Email: "bob@example.com"}},
+		},
+		{
+			"elementos que no son direcciones",
+			"undisclosed-recipients, , alice@example.com",
+			[]Address{{Email: "alice@example.com"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseList(tt.value); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseList(%q) = %#v, se esperaba %#v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmails(t *testing.T) {
+	tests := []struct {
+		values []string
+		want   []string
+	}{
+		{nil, []string{}},
+		{[]string{""}, []string{}},
+		{[]string{"A@x.com, b@x.com"}, []string{"a@x.com", "b@x.com"}},
+		{[]string{"a@x.com", "B@X.com, a@x.com"}, []string{"a@x.com", "b@x.com"}},
+	}
+	for _, tt := range tests {
+		if got := Emails(tt.values...); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Emails(%q) = %q, se esperaba %q", tt.values, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package header
+
+import (
+	"bufio"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"regexp"
+	"strings"
+)
+
+// Header contiene todas las cabeceras de un correo. Las claves se guardan en
+// forma canónica (por ejemplo "X-Cc" para "X-cc") y cada cabecera puede tener
+// varios valores si aparece más de una vez en el mensaje.
+type Header map[string][]string
+
+// CanonicalKey devuelve la forma canónica de una clave de cabecera.
+func CanonicalKey(key string) string {
+	return textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(key))
+}
+
+// Add agrega un valor a la cabecera indicada sin reemplazar los existentes.
+func (h Header) Add(key, value string) {
+	key = CanonicalKey(key)
+	h[key] = append(h[key], value)
+}
+
+// Get devuelve el primer valor de la cabecera o "" si no existe.
+func (h Header) Get(key string) string {
+	values := h[CanonicalKey(key)]
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// Values devuelve todos los valores de la cabecera indicada.
+func (h Header) Values(key string) []string {
+	return h[CanonicalKey(key)]
+}
+
+// Has indica si la cabecera aparece en el mensaje, aunque su valor sea vacío.
+func (h Header) Has(key string) bool {
+	_, ok := h[CanonicalKey(key)]
+	return ok
+}
+
+// Value serializa las cabeceras como JSON para guardarlas en MySQL.
+func (h Header) Value() (driver.Value, error) {
+	if h == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(h)
+	if err != nil {
+		return nil, fmt.Errorf("error serializando cabeceras: %w", err)
+	}
+	return string(data), nil
+}
+
+// Scan reconstruye las cabeceras a partir de la columna JSON de MySQL.
+func (h *Header) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*h = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("tipo no soportado para cabeceras: %T", src)
+	}
+
+	if len(data) == 0 {
+		*h = nil
+		return nil
+	}
+	return json.Unmarshal(data, h)
+}
+
+// Parse lee el bloque de cabeceras de un mensaje RFC 5322 hasta la primera
+// línea vacía, dejando el lector posicionado al comienzo del cuerpo.
+// Las líneas de continuación (que empiezan con espacio o tabulador) se unen
+// a la cabecera anterior separadas por un único espacio. Las líneas sin ":"
+// que no son continuación se ignoran.
+func Parse(r *bufio.Reader) (Header, error) {
+	h := make(Header)
+	var key string
+	var value strings.Builder
+
+	flush := func() {
+		if key != "" {
+			h.Add(key, strings.TrimSpace(value.String()))
+		}
+		key = ""
+		value.Reset()
+	}
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("error leyendo cabeceras: %w", err)
+		}
+		atEOF := err == io.EOF
+		line = strings.TrimRight(line, "\r\n")
+
+		if line == "" {
+			// Línea vacía: fin de las cabeceras (o fin del archivo)
+			flush()
+			return h, nil
+		}
+
+		if line[0] == ' ' || line[0] == '\t' {
+			if key != "" {
+				if value.Len() > 0 {
+					value.WriteString(" ")
+				}
+				value.WriteString(strings.TrimSpace(line))
+			}
+		} else if idx := strings.Index(line, ":"); idx > 0 {
+			flush()
+			key = line[:idx]
+			value.WriteString(strings.TrimSpace(line[idx+1:]))
+		}
+
+		if atEOF {
+			flush()
+			return h, nil
+		}
+	}
+}
+
+var messageIDRe = regexp.MustCompile(`<[^<>\s]+>`)
+
+// MessageIDs extrae los identificadores "<...>" de cabeceras como References
+// o In-Reply-To, en el orden en que aparecen.
+func MessageIDs(value string) []string {
+	return messageIDRe.FindAllString(value, -1)
+}
@@ -0,0 +1,105 @@
+package header
+
+import (
+	"bufio"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  Header
+		body  string // Lo que queda en el lector después de las cabeceras
+	}{
+		{
+			name:  "cabeceras simples",
+			input: "From: alice@example.com\r\nSubject: Hola\r\n\r\ncuerpo",
+			want:  Header{"From": {"alice@example.com"}, "Subject": {"Hola"}},
+			body:  "cuerpo",
+		},
+		{
+			name:  "línea de continuación con espacio",
+			input: "Subject: Reunión\n  de presupuesto\n\n",
+			want:  Header{"Subject": {"Reunión de presupuesto"}},
+		},
+		{
+			name:  "varias continuaciones con tabulador",
+			input: "To: a@example.com,\n\tb@example.com,\n\t c@example.com\n\n",
+			want:  Header{"To": {"a@example.com, b@example.com, c@example.com"}},
+		},
+		{
+			name:  "cabeceras repetidas y claves en otro formato",
+			input: "received: uno\nRECEIVED: dos\nx-folder: inbox\n\n",
+			want:  Header{"Received": {"uno", "dos"}, "X-Folder": {"inbox"}},
+		},
+		{
+			name:  "valor vacío que continúa en la línea siguiente",
+			input: "Subject:\n Hola\n\n",
+			want:  Header{"Subject": {"Hola"}},
+		},
+		{
+			name:  "líneas sin dos puntos y continuación sin cabecera",
+			input: " huérfana\nbasura\nFrom: a@example.com\n\n",
+			want:  Header{"From": {"a@example.com"}},
+		},
+		{
+			name:  "fin del archivo sin línea vacía",
+			input: "From: a@example.com\nSubject: sin cuerpo",
+			want:  Header{"From": {"a@example.com"}, "Subject": {"sin cuerpo"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := bufio.NewReader(strings.NewReader(tt.input))
+			got, err := Parse(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %v, se esperaba %v", got, tt.want)
+			}
+			rest, _ := io.ReadAll(r)
+			if string(rest) != tt.body {
+				t.Errorf("cuerpo %q, se esperaba %q", rest, tt.body)
+			}
+		})
+	}
+}
+
+func TestHeaderGet(t *testing.T) {
+	h := Header{}
+	h.Add("message-id", "<1@example.com>")
+	h.Add("Message-ID", "<2@example.com>")
+
+	if got := h.Get("MESSAGE-ID"); got != "<1@example.com>" {
+		t.Errorf("Get() = %q", got)
+	}
+	if got := h.Values("Message-Id"); len(got) != 2 {
+		t.Errorf("Values() = %v", got)
+	}
+	if h.Has("References") {
+		t.Error("Has(References) = true")
+	}
+}
+
+func TestMessageIDs(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{"<a@x> <b@x>\n\t<c@x>", []string{"<a@x>", "<b@x>", "<c@x>"}},
+		{"Re: <a@x> (comentario)", []string{"<a@x>"}},
+		{"<con espacios@x> <b@x>", []string{"<b@x>"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := MessageIDs(tt.value); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("MessageIDs(%q) = %v, se esperaba %v", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package maildate
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formatos aceptados una vez normalizada la fecha (sin día de la semana ni comentarios).
+// Incluyen las variantes obsoletas de RFC 2822: año de dos dígitos, segundos
+// opcionales y zona horaria alfabética o ausente.
+var layouts = []string{
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04 -0700",
+	"2 Jan 06 15:04:05 -0700",
+	"2 Jan 06 15:04 -0700",
+	"2 Jan 2006 15:04:05",
+	"2 Jan 2006 15:04",
+	"2 Jan 06 15:04:05",
+	"2 Jan 06 15:04",
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05",
+	"Jan 2 15:04:05 2006",
+	"Jan 2 2006 15:04:05 -0700",
+}
+
+// Zonas horarias alfabéticas definidas por RFC 822 y las más habituales en correos
+var zones = map[string]string{
+	"UT": "+0000", "UTC": "+0000", "GMT": "+0000", "Z": "+0000",
+	"EST": "-0500", "EDT": "-0400",
+	"CST": "-0600", "CDT": "-0500",
+	"MST": "-0700", "MDT": "-0600",
+	"PST": "-0800", "PDT": "-0700",
+	"CET": "+0100", "CEST": "+0200",
+	"BST": "+0100", "IST": "+0530", "JST": "+0900",
+}
+
+var (
+	commentRe   = regexp.MustCompile(`\([^()]*\)`)
+	spacesRe    = regexp.MustCompile(`\s+`)
+	dayOfWeekRe = regexp.MustCompile(`^(?i)(mon|tue|wed|thu|fri|sat|sun)[a-z]*,?\s*`)
+	offsetRe    = regexp.MustCompile(`^([+-])(\d{1,2}):?(\d{2})$`)
+)
+
+// Parse interpreta el valor de una cabecera Date. Devuelve la fecha con el
+// desplazamiento horario original; para normalizarla se usa UTC().
+func Parse(value string) (time.Time, error) {
+	normalized := normalize(value)
+	if normalized == "" {
+		return time.Time{}, fmt.Errorf("fecha vacía")
+	}
+
+	for _, layout := range layouts {
+		t, err := time.Parse(layout, normalized)
+		if err != nil {
+			continue
+		}
+		if !strings.Contains(layout, "2006") {
+			t = fixTwoDigitYear(t)
+		}
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("formato de fecha no reconocido: %q", value)
+}
+
+// normalize elimina comentarios, el día de la semana y espacios sobrantes, y
+// reemplaza las zonas horarias alfabéticas por su desplazamiento numérico.
+func normalize(value string) string {
+	value = commentRe.ReplaceAllString(value, " ")
+	value = strings.TrimSpace(spacesRe.ReplaceAllString(value, " "))
+	value = dayOfWeekRe.ReplaceAllString(value, "")
+
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	last := fields[len(fields)-1]
+	if offset, ok := zones[strings.ToUpper(last)]; ok {
+		fields[len(fields)-1] = offset
+	} else if m := offsetRe.FindStringSubmatch(last); m != nil && len(fields) > 1 {
+		// Acepta "+7:00", "+07:00" o "-700" y los lleva a "+0700"
+		hours, _ := strconv.Atoi(m[2])
+		fields[len(fields)-1] = fmt.Sprintf("%s%02d%s", m[1], hours, m[3])
+	} else if len(last) == 1 && isLetter(last[0]) {
+		// Zonas militares de una letra: RFC 2822 indica tratarlas como -0000
+		fields[len(fields)-1] = "+0000"
+	}
+
+	return strings.Join(fields, " ")
+}
+
+// fixTwoDigitYear aplica la regla de RFC 2822: 00-49 son 20xx y 50-99 son 19xx.
+func fixTwoDigitYear(t time.Time) time.Time {
+	year := t.Year() % 100
+	century := 2000
+	if year >= 50 {
+		century = 1900
+	}
+	return t.AddDate(century+year-t.Year(), 0, 0)
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// OffsetMinutes devuelve el desplazamiento horario de la fecha en minutos.
+func OffsetMinutes(t time.Time) int {
+	_, offset := t.Zone()
+	return offset / 60
+}
@@ -0,0 +1,53 @@
+package maildate
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value      string
+		want       string // RFC 3339 con el desplazamiento original
+		wantOffset int
+	}{
+		{"Mon, 14 May 2001 16:39:00 -0700 (PDT)", "2001-05-14T16:39:00-07:00", -420},
+		{"14 May 2001 16:39:00 -0700", "2001-05-14T16:39:00-07:00", -420},
+		{"Tuesday, 15 May 2001 09:00 +0200", "2001-05-15T09:00:00+02:00", 120},
+		{"Wed, 2 Jan 02 10:00:00 GMT", "2002-01-02T10:00:00Z", 0},
+		{"Thu, 1 Jan 98 10:00:00 EST", "1998-01-01T10:00:00-05:00", -300},
+		{"1 Jan 49 00:00 +0000", "2049-01-01T00:00:00Z", 0},
+		{"1 Jan 50 00:00 +0000", "1950-01-01T00:00:00Z", 0},
+		{"Fri,  3   Aug 2001  11:22:33   +7:00", "2001-08-03T11:22:33+07:00", 420},
+		{"3 Aug 2001 11:22:33 -05:30", "2001-08-03T11:22:33-05:30", -330},
+		{"3 Aug 2001 11:22:33 Z", "2001-08-03T11:22:33Z", 0},
+		{"3 Aug 2001 11:22:33 A", "2001-08-03T11:22:33Z", 0},
+		{"3 Aug 2001 11:22:33", "2001-08-03T11:22:33Z", 0},
+		{"2001-08-03T11:22:33+01:00", "2001-08-03T11:22:33+01:00", 60},
+		{"2001-08-03 11:22:33", "2001-08-03T11:22:33Z", 0},
+		{"Aug 3 11:22:33 2001", "2001-08-03T11:22:33Z", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := Parse(tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s := got.Format(time.RFC3339); s != tt.want {
+				t.Errorf("Parse() = %s, se esperaba %s", s, tt.want)
+			}
+			if offset := OffsetMinutes(got); offset != tt.wantOffset {
+				t.Errorf("OffsetMinutes() = %d, se esperaba %d", offset, tt.wantOffset)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, value := range []string{"", "   ", "(solo un comentario)", "mañana", "32 Foo 2001 10:00"} {
+		if got, err := Parse(value); err == nil {
+			t.Errorf("Parse(%q) = %v, se esperaba un error", value, got)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package mimeparse
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// ToUTF8 convierte el contenido desde el charset indicado a UTF-8.
+// Los textos declarados como us-ascii o utf-8 que contienen bytes inválidos
+// se interpretan como windows-1252, que es el caso habitual en correos antiguos.
+func ToUTF8(charset string, data []byte) (string, error) {
+	name := strings.ToLower(strings.Trim(strings.TrimSpace(charset), `"`))
+
+	switch name {
+	case "", "us-ascii", "ascii", "utf-8", "utf8":
+		if utf8.Valid(data) {
+			return string(data), nil
+		}
+		name = "windows-1252"
+	}
+
+	// htmlindex sigue la tabla de WHATWG: iso-8859-1 y us-ascii se tratan
+	// como windows-1252, que es un superconjunto compatible.
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		// Charset desconocido: se conserva el texto reemplazando los bytes inválidos
+		return strings.ToValidUTF8(string(data), "�"), nil
+	}
+
+	decoded, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return "", fmt.Errorf("error convirtiendo desde %s: %w", name, err)
+	}
+	return string(decoded), nil
+}
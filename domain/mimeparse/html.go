@@ -0,0 +1,76 @@
+package mimeparse
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Elementos HTML que generan un salto de línea al convertir a texto
+var blockElements = map[string]bool{
+	"p": true, "div": true, "br": true, "tr": true, "li": true, "table": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"blockquote": true, "pre": true, "hr": true, "ul": true, "ol": true,
+}
+
+// Elementos cuyo contenido no se muestra como texto
+var skippedElements = map[string]bool{
+	"script": true, "style": true, "head": true, "title": true,
+}
+
+var (
+	spacesRe     = regexp.MustCompile(`[ \t\r\f\v]+`)
+	blankLinesRe = regexp.MustCompile(`\n{3,}`)
+)
+
+// HTMLToText convierte un documento HTML en texto plano legible: elimina
+// etiquetas, scripts y estilos, decodifica entidades y respeta los saltos
+// de línea de los elementos de bloque.
+func HTMLToText(content string) string {
+	tokenizer := html.NewTokenizer(strings.NewReader(content))
+	var sb strings.Builder
+	skipDepth := 0
+
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case html.ErrorToken:
+			return cleanText(sb.String())
+		case html.TextToken:
+			if skipDepth == 0 {
+				sb.WriteString(spacesRe.ReplaceAllString(strings.ReplaceAll(string(tokenizer.Text()), "\n", " "), " "))
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := tokenizer.TagName()
+			tag := string(name)
+			if skippedElements[tag] && tokenType == html.StartTagToken {
+				skipDepth++
+			}
+			if blockElements[tag] {
+				sb.WriteString("\n")
+			}
+			if tag == "li" {
+				sb.WriteString("- ")
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			tag := string(name)
+			if skippedElements[tag] && skipDepth > 0 {
+				skipDepth--
+			}
+			if blockElements[tag] {
+				sb.WriteString("\n")
+			}
+		}
+	}
+}
+
+func cleanText(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	text = strings.Join(lines, "\n")
+	return strings.TrimSpace(blankLinesRe.ReplaceAllString(text, "\n\n"))
+}
@@ -0,0 +1,251 @@
+package mimeparse
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"strings"
+)
+
+// Profundidad máxima de anidamiento de multiparts que se acepta
+const maxDepth = 10
+
+// Part es una parte "hoja" (no multipart) de un mensaje, ya decodificada.
+type Part struct {
+	MediaType   string            // Tipo MIME en minúsculas, por ejemplo "text/plain"
+	Params      map[string]string // Parámetros del Content-Type (charset, name, ...)
+	Disposition string            // "inline", "attachment" o vacío
+	Filename    string            // Nombre de archivo (Content-Disposition o parámetro name)
+	ContentID   string            // Content-ID sin los signos < >
+	Content     []byte            // Contenido sin codificación de transferencia; en UTF-8 para partes de texto
+}
+
+// IsText indica si la parte es texto plano o HTML.
+func (p Part) IsText() bool {
+	return p.MediaType == "text/plain" || p.MediaType == "text/html"
+}
+
+// IsAttachment indica si la parte debe tratarse como adjunto y no como cuerpo.
+func (p Part) IsAttachment() bool {
+	if p.Disposition == "attachment" {
+		return true
+	}
+	if p.Disposition == "inline" && p.IsText() {
+		return false
+	}
+	return p.Filename != "" || !p.IsText()
+}
+
+// Message es el resultado de decodificar el cuerpo MIME de un correo.
+type Message struct {
+	Parts []Part // Todas las partes hoja en orden de aparición
+	Text  string // Cuerpo de texto elegido (text/plain o HTML convertido a texto)
+	HTML  string // Primer cuerpo HTML encontrado, si existe
+}
+
+// Attachments devuelve las partes que son adjuntos.
+func (m *Message) Attachments() []Part {
+	var attachments []Part
+	for _, part := range m.Parts {
+		if part.IsAttachment() {
+			attachments = append(attachments, part)
+		}
+	}
+	return attachments
+}
+
+// Parse decodifica el cuerpo de un mensaje a partir de sus cabeceras
+// Content-Type y Content-Transfer-Encoding. Entiende multipart/* anidados,
+// quoted-printable, base64 y convierte los charsets de texto a UTF-8.
+func Parse(contentType, transferEncoding string, body []byte) (*Message, error) {
+	msg := &Message{}
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Type", contentType)
+	h.Set("Content-Transfer-Encoding", transferEncoding)
+	if err := parsePart(msg, h, body, 0); err != nil {
+		return nil, err
+	}
+
+	msg.Text = chooseText(msg.Parts)
+	for _, part := range msg.Parts {
+		if part.MediaType == "text/html" && !part.IsAttachment() {
+			msg.HTML = string(part.Content)
+			break
+		}
+	}
+	return msg, nil
+}
+
+func parsePart(msg *Message, h textproto.MIMEHeader, body []byte, depth int) error {
+	mediaType, params, err := parseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		if depth >= maxDepth {
+			return fmt.Errorf("demasiados niveles de multipart anidados")
+		}
+		boundary := params["boundary"]
+		if boundary == "" {
+			return fmt.Errorf("multipart sin boundary")
+		}
+		return parseMultipart(msg, boundary, body, depth)
+	}
+
+	content, err := decodeTransfer(h.Get("Content-Transfer-Encoding"), body)
+	if err != nil {
+		return err
+	}
+
+	part := Part{
+		MediaType: mediaType,
+		Params:    params,
+		Filename:  decodeWord(params["name"]),
+		ContentID: strings.Trim(h.Get("Content-Id"), "<> "),
+	}
+
+	if disposition := h.Get("Content-Disposition"); disposition != "" {
+		dispType, dispParams, err := mime.ParseMediaType(disposition)
+		if err == nil {
+			part.Disposition = dispType
+			if filename := dispParams["filename"]; filename != "" {
+				part.Filename = decodeWord(filename)
+			}
+		}
+	}
+
+	if part.IsText() && !part.IsAttachment() {
+		text, err := ToUTF8(params["charset"], content)
+		if err != nil {
+			return err
+		}
+		content = []byte(text)
+	}
+	part.Content = content
+
+	msg.Parts = append(msg.Parts, part)
+	return nil
+}
+
+func parseMultipart(msg *Message, boundary string, body []byte, depth int) error {
+	reader := multipart.NewReader(bytes.NewReader(body), boundary)
+	for {
+		// NextRawPart no aplica la decodificación quoted-printable automática,
+		// así todas las codificaciones se tratan en decodeTransfer.
+		p, err := reader.NextRawPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error leyendo parte multipart: %w", err)
+		}
+
+		content, err := io.ReadAll(p)
+		if err != nil {
+			return fmt.Errorf("error leyendo parte multipart: %w", err)
+		}
+
+		if err := parsePart(msg, p.Header, content, depth+1); err != nil {
+			return err
+		}
+	}
+}
+
+// parseMediaType interpreta un Content-Type; si está vacío se asume text/plain
+// en us-ascii como indica RFC 2045.
+func parseMediaType(contentType string) (string, map[string]string, error) {
+	if strings.TrimSpace(contentType) == "" {
+		return "text/plain", map[string]string{"charset": "us-ascii"}, nil
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil && mediaType == "" {
+		return "", nil, fmt.Errorf("content-type inválido %q: %w", contentType, err)
+	}
+	if params == nil {
+		params = map[string]string{}
+	}
+	return strings.ToLower(mediaType), params, nil
+}
+
+// decodeTransfer elimina la codificación de transferencia del contenido.
+func decodeTransfer(encoding string, body []byte) ([]byte, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "quoted-printable":
+		decoded, err := io.ReadAll(quotedprintable.NewReader(bytes.NewReader(body)))
+		if err != nil {
+			return nil, fmt.Errorf("error decodificando quoted-printable: %w", err)
+		}
+		return decoded, nil
+	case "base64":
+		// Se eliminan saltos de línea y espacios antes de decodificar
+		clean := bytes.Map(func(r rune) rune {
+			if r == '\r' || r == '\n' || r == ' ' || r == '\t' {
+				return -1
+			}
+			return r
+		}, body)
+		clean = bytes.TrimRight(clean, "=")
+		decoded, err := base64.RawStdEncoding.DecodeString(string(clean))
+		if err != nil {
+			return nil, fmt.Errorf("error decodificando base64: %w", err)
+		}
+		return decoded, nil
+	default:
+		// 7bit, 8bit, binary o vacío: el contenido se usa tal cual
+		return body, nil
+	}
+}
+
+// chooseText elige el cuerpo de texto del mensaje: la primera parte text/plain
+// que no sea adjunto o, si no existe, la primera parte HTML convertida a texto.
+func chooseText(parts []Part) string {
+	for _, part := range parts {
+		if part.MediaType == "text/plain" && !part.IsAttachment() {
+			return string(part.Content)
+		}
+	}
+	for _, part := range parts {
+		if part.MediaType == "text/html" && !part.IsAttachment() {
+			return HTMLToText(string(part.Content))
+		}
+	}
+	return ""
+}
+
+// DecodeHeader decodifica las palabras codificadas (RFC 2047) de una cabecera,
+// por ejemplo "=?iso-8859-1?q?Reuni=F3n?=".
+func DecodeHeader(value string) string {
+	return decodeWord(value)
+}
+
+func decodeWord(value string) string {
+	if !strings.Contains(value, "=?") {
+		return value
+	}
+	decoder := mime.WordDecoder{CharsetReader: charsetReader}
+	decoded, err := decoder.DecodeHeader(value)
+	if err != nil {
+		return value
+	}
+	return decoded
+}
+
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	data, err := io.ReadAll(bufio.NewReader(input))
+	if err != nil {
+		return nil, err
+	}
+	text, err := ToUTF8(charset, data)
+	if err != nil {
+		return nil, err
+	}
+	return strings.NewReader(text), nil
+}
@@ -0,0 +1,167 @@
+package mimeparse
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name             string
+		contentType      string
+		transferEncoding string
+		body             string
+		wantText         string
+		wantAttachments  []string
+	}{
+		{
+			name:        "texto plano sin codificación",
+			contentType: "text/plain; charset=us-ascii",
+			body:        "Hola mundo",
+			wantText:    "Hola mundo",
+		},
+		{
+			name:        "sin Content-Type",
+			contentType: "",
+			body:        "Texto",
+			wantText:    "Texto",
+		},
+		{
+			name:             "quoted-printable con salto suave",
+			contentType:      "text/plain; charset=utf-8",
+			transferEncoding: "quoted-printable",
+			body:             "Reuni=C3=B3n de presu=\r\npuesto",
+			wantText:         "Reunión de presupuesto",
+		},
+		{
+			name:             "quoted-printable en iso-8859-1",
+			contentType:      `text/plain; charset="iso-8859-1"`,
+			transferEncoding: "Quoted-Printable",
+			body:             "Reuni=F3n",
+			wantText:         "Reunión",
+		},
+		{
+			name:             "base64 con saltos de línea",
+			contentType:      "text/plain; charset=utf-8",
+			transferEncoding: "base64",
+			body:             "SG9sYSwg\r\nbWFuZG8=\r\n",
+			wantText:         "Hola, mando",
+		},
+		{
+			name:             "base64 sin relleno",
+			contentType:      "text/plain",
+			transferEncoding: "base64",
+			body:             "SG9sYQ",
+			wantText:         "Hola",
+		},
+		{
+			name:        "windows-1252",
+			contentType: "text/plain; charset=windows-1252",
+			body:        "\x93comillas\x94",
+			wantText:    "“comillas”",
+		},
+		{
+			name:        "utf-8 declarado con bytes inválidos",
+			contentType: "text/plain; charset=utf-8",
+			body:        "caf\xe9",
+			wantText:    "café",
+		},
+		{
+			name:        "charset desconocido",
+			contentType: "text/plain; charset=x-inventado",
+			body:        "ok\xff",
+			wantText:    "ok�",
+		},
+		{
+			name:        "HTML convertido a texto",
+			contentType: "text/html; charset=utf-8",
+			body:        "<p>Hola <b>mundo</b></p>",
+			wantText:    "Hola mundo",
+		},
+		{
+			name:        "multipart/alternative prefiere texto plano",
+			contentType: `multipart/alternative; boundary="b1"`,
+			body: "--b1\r\nContent-Type: text/html\r\n\r\n<p>html</p>\r\n" +
+				"--b1\r\nContent-Type: text/plain\r\n\r\nplano\r\n--b1--\r\n",
+			wantText: "plano",
+		},
+		{
+			name:        "multipart/mixed anidado con adjunto en base64",
+			contentType: `multipart/mixed; boundary="outer"`,
+			body: "--outer\r\nContent-Type: multipart/alternative; boundary=\"inner\"\r\n\r\n" +
+				"--inner\r\nContent-Type: text/plain; charset=iso-8859-1\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\nA=F1o\r\n" +
+				"--inner--\r\n" +
+				"--outer\r\nContent-Type: application/pdf; name=\"informe.pdf\"\r\n" +
+				"Content-Disposition: attachment; filename=\"informe.pdf\"\r\nContent-Transfer-Encoding: base64\r\n\r\nJVBERg==\r\n" +
+				"--outer--\r\n",
+			wantText:        "Año",
+			wantAttachments: []string{"informe.pdf"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := Parse(tt.contentType, tt.transferEncoding, []byte(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSpace(msg.Text); got != tt.wantText {
+				t.Errorf("Text = %q, se esperaba %q", got, tt.wantText)
+			}
+
+			attachments := msg.Attachments()
+			if len(attachments) != len(tt.wantAttachments) {
+				t.Fatalf("%d adjuntos, se esperaban %d", len(attachments), len(tt.wantAttachments))
+			}
+			for i, attachment := range attachments {
+				if attachment.Filename != tt.wantAttachments[i] {
+					t.Errorf("adjunto %d: %q, se esperaba %q", i, attachment.Filename, tt.wantAttachments[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseAttachmentContent(t *testing.T) {
+	body := "--b\r\nContent-Type: text/plain\r\n\r\ncuerpo\r\n" +
+		"--b\r\nContent-Type: application/octet-stream\r\nContent-Transfer-Encoding: base64\r\n" +
+		"Content-ID: <img1@example.com>\r\n\r\nAAEC\r\n--b--\r\n"
+	msg, err := Parse(`multipart/mixed; boundary=b`, "", []byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	attachments := msg.Attachments()
+	if len(attachments) != 1 {
+		t.Fatalf("%d adjuntos, se esperaba 1", len(attachments))
+	}
+	if got := attachments[0].Content; string(got) != "\x00\x01\x02" {
+		t.Errorf("contenido %v", got)
+	}
+	if got := attachments[0].ContentID; got != "img1@example.com" {
+		t.Errorf("ContentID = %q", got)
+	}
+}
+
+func TestParseInvalidBase64(t *testing.T) {
+	if _, err := Parse("text/plain", "base64", []byte("no es base64!")); err == nil {
+		t.Error("se esperaba un error")
+	}
+}
+
+func TestDecodeHeader(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"Sin codificar", "Sin codificar"},
+		{"=?iso-8859-1?q?Reuni=F3n?=", "Reunión"},
+		{"=?UTF-8?B?w5FhbmTDug==?=", "Ñandú"},
+		{"=?windows-1252?Q?=93hola=94?= mundo", "“hola” mundo"},
+		{"=?utf-8?x?roto?=", "=?utf-8?x?roto?="},
+	}
+	for _, tt := range tests {
+		if got := DecodeHeader(tt.value); got != tt.want {
+			t.Errorf("DecodeHeader(%q) = %q, se esperaba %q", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package model
+
+// Attachment es un archivo adjunto extraído de un correo. El contenido se
+// guarda en el almacén de blobs bajo su hash SHA-256.
+type Attachment struct {
+	ID          int
+	EmailID     int
+	Filename    string // Nombre del archivo
+	ContentType string // Tipo MIME
+	Size        int64  // Tamaño en bytes
+	Hash        string // SHA-256 del contenido
+	ContentID   string // Content-ID para adjuntos inline
+	Content     []byte `json:"-"` // Contenido decodificado, solo durante la ingesta
+}
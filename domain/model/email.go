@@ -2,18 +2,33 @@ package model
 
 import (
 	"database/sql"
+	"project/domain/header"
 )
 
 type Email struct {
-	ID          int
-	MessageID   string         // Message-ID
-	Sender      string         // From
-	Receiver    string         // To
-	Subject     string         // Subject
-	MimeVersion string         // Mime-Version
-	ContentType string         // Content-Type
-	Encoding    string         // Content-Transfer-Encoding
-	Folder      string         // X-Folder
-	Body        string         // Contenido del email
-	Date        sql.NullString // Date
+	ID           int
+	MessageID    string            // Message-ID
+	Sender       string            // From
+	SenderName   string            // X-From
+	Receiver     string            // To
+	ReceiverName string            // X-To
+	Cc           string            // Cc
+	Bcc          string            // Bcc
+	Subject      string            // Subject
+	MimeVersion  string            // Mime-Version
+	ContentType  string            // Content-Type
+	Encoding     string            // Content-Transfer-Encoding
+	Folder       string            // X-Folder
+	Body         string            // Contenido del email
+	Date         sql.NullTime      // Date normalizada a UTC
+	DateRaw      string            // Valor original de la cabecera Date
+	DateOffset   int               // Desplazamiento horario original en minutos
+	InReplyTo    string            // In-Reply-To
+	References   string            // References (identificadores separados por espacios)
+	ThreadID     *int              `json:"thread_id"` // Hilo al que pertenece el correo; nil si aún no se reconstruyeron los hilos
+	Headers      header.Header     // Todas las cabeceras del mensaje
+	Attachments  []Attachment      // Archivos adjuntos
+	Participants []Participant     // Remitente y destinatarios con su rol
+	ContentHash  string            // SHA-256 del cuerpo, junto al Message-ID identifica al mensaje
+	Locations    []MessageLocation // Carpetas y archivos en los que apareció el mensaje
 }
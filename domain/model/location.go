@@ -0,0 +1,11 @@
+package model
+
+// MessageLocation es una carpeta y archivo en los que apareció un correo.
+// Un mismo mensaje puede estar copiado en inbox, all_documents, sent, etc.
+type MessageLocation struct {
+	ID       int
+	EmailID  int
+	Folder   string // X-Folder del archivo (o el directorio si no tiene la cabecera)
+	FilePath string // Ruta del archivo procesado
+	Owner    string // Dueño del buzón: primer directorio de la ruta dentro de BASE_DIR
+}
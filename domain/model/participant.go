@@ -0,0 +1,33 @@
+package model
+
+// Roles de un participante en un correo
+const (
+	RoleFrom = "from"
+	RoleTo   = "to"
+	RoleCc   = "cc"
+	RoleBcc  = "bcc"
+)
+
+// ValidRole indica si el rol es uno de los admitidos.
+func ValidRole(role string) bool {
+	switch role {
+	case RoleFrom, RoleTo, RoleCc, RoleBcc:
+		return true
+	}
+	return false
+}
+
+// Contact es una dirección de correo única en todo el corpus.
+type Contact struct {
+	ID    int
+	Email string // Dirección en minúsculas
+	Name  string // Nombre visible más reciente
+}
+
+// Participant relaciona un contacto con un correo y su rol en él.
+type Participant struct {
+	ContactID int
+	Email     string
+	Name      string
+	Role      string // from, to, cc o bcc
+}
@@ -0,0 +1,23 @@
+package model
+
+// SearchHit es un correo encontrado por una búsqueda junto con el motivo de la coincidencia.
+type SearchHit struct {
+	Email
+	Score      float64             // Relevancia calculada por el motor de búsqueda
+	Highlights map[string][]string // Fragmentos con las coincidencias marcadas, por campo
+	Snippet    string              // Fragmento del cuerpo alrededor de la primera coincidencia
+}
+
+// Facets agrupan los resultados de una búsqueda para poder refinarla.
+type Facets struct {
+	Senders   []FacetBucket // Remitentes más frecuentes
+	Receivers []FacetBucket // Destinatarios (To y Cc) más frecuentes
+	Folders   []FacetBucket // Cantidad de correos por carpeta
+	Dates     []FacetBucket // Histograma de fechas; Value es el inicio del intervalo en RFC 3339
+}
+
+// FacetBucket es un valor de una faceta y la cantidad de correos que lo tienen.
+type FacetBucket struct {
+	Value string
+	Count int
+}
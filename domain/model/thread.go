@@ -0,0 +1,14 @@
+package model
+
+import "database/sql"
+
+// Thread es una conversación formada por correos relacionados.
+type Thread struct {
+	ID            int
+	RootMessageID string       // Message-ID del mensaje que inicia el hilo
+	Subject       string       // Asunto sin prefijos Re:/Fwd:
+	MessageCount  int          // Cantidad de correos del hilo
+	FirstDate     sql.NullTime // Fecha del primer correo
+	LastDate      sql.NullTime // Fecha del último correo
+	Emails        []Email      // Correos del hilo ordenados por fecha
+}
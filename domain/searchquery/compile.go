@@ -0,0 +1,80 @@
+package searchquery
+
+import (
+	"project/infrastructure/zincsearch/query"
+	"strings"
+)
+
+// Campos donde se busca el texto libre
+var textFields = []string{"subject", "body", "attachment_names", "sender_name", "receiver_name"}
+
+// Compile convierte el árbol de la consulta en una consulta bool de ZincSearch.
+func Compile(node Node) query.Query {
+	switch n := node.(type) {
+	case And:
+		var must, mustNot []query.Query
+		for _, child := range n.Nodes {
+			if not, ok := child.(Not); ok {
+				mustNot = append(mustNot, Compile(not.Node))
+			} else {
+				must = append(must, Compile(child))
+			}
+		}
+		return boolQuery(must, mustNot)
+	case Or:
+		should := make([]query.Query, len(n.Nodes))
+		for i, child := range n.Nodes {
+			should[i] = Compile(child)
+		}
+		return query.AnyOf(should...)
+	case Not:
+		return boolQuery(nil, []query.Query{Compile(n.Node)})
+	case Term:
+		return compileTerm(n)
+	}
+	return query.MatchAll()
+}
+
+// boolQuery arma una consulta bool. Si solo hay condiciones negadas se agrega
+// match_all, porque una consulta con solo must_not no devuelve resultados.
+func boolQuery(must, mustNot []query.Query) query.Query {
+	if len(must) == 0 {
+		must = []query.Query{query.MatchAll()}
+	}
+	return query.BoolQuery{Must: must, MustNot: mustNot}
+}
+
+func compileTerm(term Term) query.Query {
+	switch term.Field {
+	case "":
+		return query.MultiMatchQuery{Text: term.Value, Fields: textFields, Phrase: term.Phrase}
+	case "from":
+		return query.AnyOf(contains("sender_addresses", term.Value), match("sender_name", term))
+	case "to":
+		return query.AnyOf(contains("recipients", term.Value), match("receiver_name", term))
+	case "cc", "bcc":
+		return contains(term.Field+"_addresses", term.Value)
+	case "subject", "body":
+		return match(term.Field, term)
+	case "folder":
+		return query.Contains("folder", term.Value)
+	case "after":
+		return query.RangeQuery{Field: "date", Gte: term.Value + "T00:00:00Z"}
+	case "before":
+		return query.RangeQuery{Field: "date", Lt: term.Value + "T00:00:00Z"}
+	case "has":
+		return query.Term("has_attachment", true)
+	}
+	return query.MatchAll()
+}
+
+// match busca el valor en un campo de texto analizado; las frases entre comillas usan match_phrase.
+func match(field string, term Term) query.Query {
+	return query.MatchQuery{Field: field, Text: term.Value, Phrase: term.Phrase}
+}
+
+// contains busca una dirección como parte de un campo *_addresses o
+// recipients, que guardan cada dirección en minúsculas en un elemento.
+func contains(field, value string) query.Query {
+	return query.Contains(field, strings.ToLower(value))
+}
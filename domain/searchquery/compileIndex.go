@@ -0,0 +1,82 @@
+package searchquery
+
+import (
+	"project/infrastructure/searchindex"
+	"time"
+)
+
+// CompileIndex convierte el árbol de la consulta en una consulta del índice
+// local (searchindex), con los mismos campos que Compile.
+func CompileIndex(node Node) searchindex.Query {
+	switch n := node.(type) {
+	case And:
+		var must, mustNot []searchindex.Query
+		for _, child := range n.Nodes {
+			if not, ok := child.(Not); ok {
+				mustNot = append(mustNot, CompileIndex(not.Node))
+			} else {
+				must = append(must, CompileIndex(child))
+			}
+		}
+		return indexBool(must, mustNot)
+	case Or:
+		should := make([]searchindex.Query, len(n.Nodes))
+		for i, child := range n.Nodes {
+			should[i] = CompileIndex(child)
+		}
+		return searchindex.BoolQuery{Should: should}
+	case Not:
+		return indexBool(nil, []searchindex.Query{CompileIndex(n.Node)})
+	case Term:
+		return compileIndexTerm(n)
+	}
+	return searchindex.MatchAllQuery{}
+}
+
+// indexBool arma una consulta bool; si solo hay condiciones negadas, se aplican sobre todos los documentos.
+func indexBool(must, mustNot []searchindex.Query) searchindex.Query {
+	if len(must) == 0 {
+		must = []searchindex.Query{searchindex.MatchAllQuery{}}
+	}
+	return searchindex.BoolQuery{Must: must, MustNot: mustNot}
+}
+
+func compileIndexTerm(term Term) searchindex.Query {
+	match := func(fields ...string) searchindex.Query {
+		return searchindex.MatchQuery{Fields: fields, Text: term.Value, Phrase: term.Phrase}
+	}
+	contains := func(field string) searchindex.Query {
+		return searchindex.ContainsQuery{Field: field, Value: term.Value}
+	}
+	anyOf := func(queries ...searchindex.Query) searchindex.Query {
+		return searchindex.BoolQuery{Should: queries}
+	}
+
+	switch term.Field {
+	case "":
+		return match(textFields...)
+	case "from":
+		return anyOf(contains("sender_addresses"), match("sender_name"))
+	case "to":
+		return anyOf(contains("recipients"), match("receiver_name"))
+	case "cc", "bcc":
+		return contains(term.Field + "_addresses")
+	case "folder":
+		return contains("folder")
+	case "subject", "body":
+		return match(term.Field)
+	case "after", "before":
+		// El parser ya normalizó la fecha a AAAA-MM-DD
+		date, err := time.Parse("2006-01-02", term.Value)
+		if err != nil {
+			return searchindex.MatchAllQuery{}
+		}
+		if term.Field == "after" {
+			return searchindex.DateRangeQuery{From: date}
+		}
+		return searchindex.DateRangeQuery{To: date}
+	case "has":
+		return searchindex.TermQuery{Field: "has_attachment", Value: "true"}
+	}
+	return searchindex.MatchAllQuery{}
+}
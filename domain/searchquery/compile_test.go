@@ -0,0 +1,137 @@
+package searchquery
+
+import (
+	"encoding/json"
+	"project/infrastructure/searchindex"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const textFieldsJSON = `["subject","body","attachment_names","sender_name","receiver_name"]`
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string // JSON de la consulta de ZincSearch
+	}{
+		{
+			"contract",
+			`{"multi_match":{"query":"contract","fields":` + textFieldsJSON + `}}`,
+		},
+		{
+			`"budget review"`,
+			`{"multi_match":{"query":"budget review","fields":` + textFieldsJSON + `,"type":"phrase"}}`,
+		},
+		{
+			"from:Alice@Example.com",
+			`{"bool":{"minimum_should_match":1,"should":[
+				{"wildcard":{"sender_addresses":"*alice@example.com*"}},
+				{"match":{"sender_name":"Alice@Example.com"}}]}}`,
+		},
+		{
+			"to:bob",
+			`{"bool":{"minimum_should_match":1,"should":[
+				{"wildcard":{"recipients":"*bob*"}},
+				{"match":{"receiver_name":"bob"}}]}}`,
+		},
+		{"bcc:Carol", `{"wildcard":{"bcc_addresses":"*carol*"}}`},
+		{"folder:in*box", `{"wildcard":{"folder":"*in\\*box*"}}`},
+		{`subject:"q3 plan"`, `{"match_phrase":{"subject":"q3 plan"}}`},
+		{"after:2001-05-01", `{"range":{"date":{"gte":"2001-05-01T00:00:00Z"}}}`},
+		{"before:2001-05-01", `{"range":{"date":{"lt":"2001-05-01T00:00:00Z"}}}`},
+		{"has:attachment", `{"term":{"has_attachment":true}}`},
+		{
+			"body:merger -folder:spam",
+			`{"bool":{"must":[{"match":{"body":"merger"}}],"must_not":[{"wildcard":{"folder":"*spam*"}}]}}`,
+		},
+		{
+			"-spam",
+			`{"bool":{"must":[{"match_all":{}}],"must_not":[{"multi_match":{"query":"spam","fields":` + textFieldsJSON + `}}]}}`,
+		},
+		{
+			"subject:a OR subject:b",
+			`{"bool":{"minimum_should_match":1,"should":[{"match":{"subject":"a"}},{"match":{"subject":"b"}}]}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			node, err := Parse(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := json.Marshal(Compile(node))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got, want interface{}
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatalf("JSON esperado inválido: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Compile() = %s", data)
+			}
+		})
+	}
+}
+
+func TestCompileIndex(t *testing.T) {
+	match := func(text string, fields ...string) searchindex.Query {
+		return searchindex.MatchQuery{Fields: fields, Text: text}
+	}
+	contains := func(field, value string) searchindex.Query {
+		return searchindex.ContainsQuery{Field: field, Value: value}
+	}
+
+	tests := []struct {
+		query string
+		want  searchindex.Query
+	}{
+		{"contract", match("contract", textFields...)},
+		{`"q3 plan"`, searchindex.MatchQuery{Fields: textFields, Text: "q3 plan", Phrase: true}},
+		{
+			"from:Alice",
+			searchindex.BoolQuery{Should: []searchindex.Query{contains("sender_addresses", "Alice"), match("Alice", "sender_name")}},
+		},
+		{
+			"to:bob",
+			searchindex.BoolQuery{Should: []searchindex.Query{contains("recipients", "bob"), match("bob", "receiver_name")}},
+		},
+		{"cc:carol", contains("cc_addresses", "carol")},
+		{"folder:inbox", contains("folder", "inbox")},
+		{"after:2001-05-01", searchindex.DateRangeQuery{From: time.Date(2001, 5, 1, 0, 0, 0, 0, time.UTC)}},
+		{"before:2001-05-01", searchindex.DateRangeQuery{To: time.Date(2001, 5, 1, 0, 0, 0, 0, time.UTC)}},
+		{"has:attachment", searchindex.TermQuery{Field: "has_attachment", Value: "true"}},
+		{
+			"-spam",
+			searchindex.BoolQuery{
+				Must:    []searchindex.Query{searchindex.MatchAllQuery{}},
+				MustNot: []searchindex.Query{match("spam", textFields...)},
+			},
+		},
+		{
+			"a -subject:b",
+			searchindex.BoolQuery{
+				Must:    []searchindex.Query{match("a", textFields...)},
+				MustNot: []searchindex.Query{match("b", "subject")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			node, err := Parse(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := CompileIndex(node); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CompileIndex() = %#v, se esperaba %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,312 @@
+package searchquery
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Node es un nodo del árbol de una consulta.
+type Node interface {
+	node()
+}
+
+// Term es una condición simple: texto libre (Field vacío) o campo:valor.
+// Phrase indica que el valor estaba entre comillas.
+type Term struct {
+	Field  string
+	Value  string
+	Phrase bool
+}
+
+// Not niega la condición que contiene (prefijo "-").
+type Not struct {
+	Node Node
+}
+
+// And exige que se cumplan todas las condiciones (términos separados por espacios).
+type And struct {
+	Nodes []Node
+}
+
+// Or exige que se cumpla alguna de las condiciones (términos separados por OR).
+type Or struct {
+	Nodes []Node
+}
+
+func (Term) node() {}
+func (Not) node()  {}
+func (And) node()  {}
+func (Or) node()   {}
+
+// Campos aceptados antes de ":"
+var fields = map[string]bool{
+	"from":    true,
+	"to":      true,
+	"cc":      true,
+	"bcc":     true,
+	"subject": true,
+	"body":    true,
+	"folder":  true,
+	"after":   true,
+	"before":  true,
+	"has":     true,
+}
+
+// Formatos aceptados en after: y before:
+var dateLayouts = []string{"2006-01-02", "2006/01/02"}
+
+// ParseError describe un error de sintaxis en la consulta. Pos es la posición
+// (en bytes) donde se detectó.
+type ParseError struct {
+	Pos int
+	Msg string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s (posición %d)", e.Msg, e.Pos)
+}
+
+// Parse interpreta una consulta con la sintaxis de Gmail, por ejemplo:
+//
+//	from:alice to:bob subject:"budget review" after:2008-01-01 has:attachment -spam
+//
+// Los términos separados por espacios se combinan con AND, OR une alternativas,
+// "-" niega un término y los paréntesis agrupan.
+func Parse(query string) (Node, error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, &ParseError{Pos: 0, Msg: "la consulta está vacía"}
+	}
+
+	p := &parser{tokens: tokens, end: len(query)}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok != nil {
+		return nil, &ParseError{Pos: tok.pos, Msg: "paréntesis de cierre sin abrir"}
+	}
+	return node, nil
+}
+
+type tokenKind int
+
+const (
+	tokenTerm tokenKind = iota
+	tokenOr
+	tokenNot
+	tokenOpen
+	tokenClose
+)
+
+type token struct {
+	kind tokenKind
+	pos  int
+	term Term
+}
+
+func tokenize(query string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(query) {
+		c := query[i]
+		switch {
+		case isSpace(c):
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenOpen, pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenClose, pos: i})
+			i++
+		case c == '-' && i+1 < len(query) && !isSpace(query[i+1]):
+			tokens = append(tokens, token{kind: tokenNot, pos: i})
+			i++
+		case c == '"':
+			value, next, err := readQuoted(query, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenTerm, pos: i, term: Term{Value: value, Phrase: true}})
+			i = next
+		default:
+			start := i
+			for i < len(query) && !isSpace(query[i]) && !strings.ContainsRune(`()"`, rune(query[i])) {
+				i++
+			}
+			word := query[start:i]
+
+			if word == "OR" {
+				tokens = append(tokens, token{kind: tokenOr, pos: start})
+				continue
+			}
+
+			// Como en Gmail, un prefijo que no es un campo conocido ("Re:", "http:")
+			// es parte del texto buscado
+			colon := strings.IndexByte(word, ':')
+			if colon <= 0 || !fields[strings.ToLower(word[:colon])] {
+				tokens = append(tokens, token{kind: tokenTerm, pos: start, term: Term{Value: word}})
+				continue
+			}
+
+			field := strings.ToLower(word[:colon])
+
+			term := Term{Field: field, Value: word[colon+1:]}
+			if term.Value == "" && i < len(query) && query[i] == '"' {
+				value, next, err := readQuoted(query, i)
+				if err != nil {
+					return nil, err
+				}
+				term.Value, term.Phrase = value, true
+				i = next
+			}
+			if strings.TrimSpace(term.Value) == "" {
+				return nil, &ParseError{Pos: start, Msg: fmt.Sprintf("falta el valor del campo %s", field)}
+			}
+			if err := validateTerm(&term, start); err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenTerm, pos: start, term: term})
+		}
+	}
+	return tokens, nil
+}
+
+// readQuoted lee un valor entre comillas que empieza en start y devuelve la
+// posición siguiente a la comilla de cierre.
+func readQuoted(query string, start int) (string, int, error) {
+	end := strings.IndexByte(query[start+1:], '"')
+	if end < 0 {
+		return "", 0, &ParseError{Pos: start, Msg: "comillas sin cerrar"}
+	}
+	value := query[start+1 : start+1+end]
+	if strings.TrimSpace(value) == "" {
+		return "", 0, &ParseError{Pos: start, Msg: "frase vacía entre comillas"}
+	}
+	return value, start + end + 2, nil
+}
+
+// validateTerm comprueba los valores de los campos con formato fijo y los normaliza.
+func validateTerm(term *Term, pos int) error {
+	switch term.Field {
+	case "after", "before":
+		date, err := parseDate(term.Value)
+		if err != nil {
+			return &ParseError{Pos: pos, Msg: fmt.Sprintf("fecha inválida en %s: %q (use AAAA-MM-DD)", term.Field, term.Value)}
+		}
+		term.Value = date.Format("2006-01-02")
+	case "has":
+		value := strings.ToLower(term.Value)
+		if value != "attachment" && value != "attachments" {
+			return &ParseError{Pos: pos, Msg: fmt.Sprintf("valor no soportado en has: %q (solo attachment)", term.Value)}
+		}
+		term.Value = "attachment"
+	}
+	return nil
+}
+
+func parseDate(value string) (time.Time, error) {
+	var err error
+	for _, layout := range dateLayouts {
+		var date time.Time
+		if date, err = time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	end    int
+}
+
+func (p *parser) peek() *token {
+	if p.pos >= len(p.tokens) {
+		return nil
+	}
+	return &p.tokens[p.pos]
+}
+
+func (p *parser) parseOr() (Node, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := []Node{first}
+	for tok := p.peek(); tok != nil && tok.kind == tokenOr; tok = p.peek() {
+		p.pos++
+		next, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, next)
+	}
+	if len(nodes) == 1 {
+		return first, nil
+	}
+	return Or{Nodes: nodes}, nil
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	var nodes []Node
+	for tok := p.peek(); tok != nil && tok.kind != tokenOr && tok.kind != tokenClose; tok = p.peek() {
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+
+	if len(nodes) == 0 {
+		pos := p.end
+		if tok := p.peek(); tok != nil {
+			pos = tok.pos
+		}
+		return nil, &ParseError{Pos: pos, Msg: "se esperaba un término"}
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return And{Nodes: nodes}, nil
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	tok := p.peek()
+	switch tok.kind {
+	case tokenNot:
+		p.pos++
+		if next := p.peek(); next == nil || next.kind == tokenOr || next.kind == tokenClose {
+			return nil, &ParseError{Pos: tok.pos, Msg: "falta el término después de \"-\""}
+		}
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not{Node: node}, nil
+	case tokenOpen:
+		p.pos++
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if next := p.peek(); next == nil || next.kind != tokenClose {
+			return nil, &ParseError{Pos: tok.pos, Msg: "paréntesis sin cerrar"}
+		}
+		p.pos++
+		return node, nil
+	default:
+		p.pos++
+		return tok.term, nil
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
@@ -0,0 +1,97 @@
+package searchquery
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		query string
+		want  Node
+	}{
+		{"contract", Term{Value: "contract"}},
+		{`"budget review"`, Term{Value: "budget review", Phrase: true}},
+		{"from:alice", Term{Field: "from", Value: "alice"}},
+		{"FROM:Alice", Term{Field: "from", Value: "Alice"}},
+		{`subject:"budget review"`, Term{Field: "subject", Value: "budget review", Phrase: true}},
+		{"after:2001/05/01", Term{Field: "after", Value: "2001-05-01"}},
+		{"has:Attachments", Term{Field: "has", Value: "attachment"}},
+		{"12:30", Term{Value: "12:30"}},
+		{"color:red", Term{Value: "color:red"}},
+		{"Re:budget", Term{Value: "Re:budget"}},
+		{"http://example.com", Term{Value: "http://example.com"}},
+		{"note:", Term{Value: "note:"}},
+		{"a-b", Term{Value: "a-b"}},
+		{
+			"from:alice to:bob -spam",
+			And{Nodes: []Node{
+				Term{Field: "from", Value: "alice"},
+				Term{Field: "to", Value: "bob"},
+				Not{Node: Term{Value: "spam"}},
+			}},
+		},
+		{
+			"a b OR c",
+			Or{Nodes: []Node{
+				And{Nodes: []Node{Term{Value: "a"}, Term{Value: "b"}}},
+				Term{Value: "c"},
+			}},
+		},
+		{
+			"(from:alice OR from:bob) -folder:spam",
+			And{Nodes: []Node{
+				Or{Nodes: []Node{Term{Field: "from", Value: "alice"}, Term{Field: "from", Value: "bob"}}},
+				Not{Node: Term{Field: "folder", Value: "spam"}},
+			}},
+		},
+		{"-(a OR b)", Not{Node: Or{Nodes: []Node{Term{Value: "a"}, Term{Value: "b"}}}}},
+		{"a - b", And{Nodes: []Node{Term{Value: "a"}, Term{Value: "-"}, Term{Value: "b"}}}},
+		{"a or b", And{Nodes: []Node{Term{Value: "a"}, Term{Value: "or"}, Term{Value: "b"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got, err := Parse(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %#v, se esperaba %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		pos   int
+	}{
+		{"", 0},
+		{"   ", 0},
+		{"a from:", 2},
+		{`subject:"sin cerrar`, 8},
+		{`""`, 0},
+		{"after:ayer", 0},
+		{"has:pdf", 0},
+		{"(a OR b", 0},
+		{"a)", 1},
+		{"a OR", 4},
+		{"-)", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := Parse(tt.query)
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Parse() = %v, se esperaba un ParseError", err)
+			}
+			if parseErr.Pos != tt.pos {
+				t.Errorf("posición %d, se esperaba %d (%s)", parseErr.Pos, tt.pos, parseErr.Msg)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package searchquery
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// Etiquetas con las que ZincSearch marca las coincidencias en los fragmentos.
+// Los fragmentos son HTML: el texto del correo va escapado y solo estas
+// etiquetas quedan sin escapar.
+const (
+	HighlightPreTag  = "<mark>"
+	HighlightPostTag = "</mark>"
+)
+
+// Etiquetas tal como quedan en un fragmento escapado con html.EscapeString
+var (
+	escapedPreTag  = html.EscapeString(HighlightPreTag)
+	escapedPostTag = html.EscapeString(HighlightPostTag)
+)
+
+// EscapeHighlight escapa el HTML de un fragmento que el motor devolvió con las
+// coincidencias marcadas sobre el texto original, y restaura solo las
+// etiquetas de resaltado.
+func EscapeHighlight(fragment string) string {
+	escaped := html.EscapeString(fragment)
+	escaped = strings.ReplaceAll(escaped, escapedPreTag, HighlightPreTag)
+	return strings.ReplaceAll(escaped, escapedPostTag, HighlightPostTag)
+}
+
+// Snippet devuelve un fragmento de hasta size caracteres del cuerpo centrado en
+// la primera coincidencia marcada en fragments, buscada sin distinguir
+// mayúsculas porque el resaltado puede venir de un texto normalizado. Sin
+// coincidencias devuelve el comienzo del cuerpo. Los cortes se hacen entre
+// palabras y se indican con "…".
+func Snippet(body string, fragments []string, size int) string {
+	text := []rune(strings.Join(strings.Fields(body), " "))
+	if len(text) <= size {
+		return string(text)
+	}
+
+	start := 0
+	if term := firstMatch(fragments); term != "" {
+		if i := indexFold(text, term); i >= 0 {
+			// Dejar un tercio del fragmento antes de la coincidencia
+			start = i - size/3
+		}
+	}
+	if start < 0 {
+		start = 0
+	}
+	end := start + size
+	if end > len(text) {
+		end = len(text)
+		start = end - size
+	}
+
+	// Ajustar los extremos para no cortar palabras
+	if start > 0 {
+		for start < end && !unicode.IsSpace(text[start-1]) {
+			start++
+		}
+	}
+	if end < len(text) {
+		for end > start && !unicode.IsSpace(text[end]) {
+			end--
+		}
+	}
+
+	snippet := strings.TrimSpace(string(text[start:end]))
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(text) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+// indexFold devuelve la posición, en runas, de la primera aparición de term en
+// text sin distinguir mayúsculas, o -1 si no aparece. Se compara runa a runa
+// para que la posición corresponda a text aunque las minúsculas de algún
+// carácter ocupen otra cantidad de bytes.
+func indexFold(text []rune, term string) int {
+	pattern := []rune(term)
+	if len(pattern) == 0 {
+		return -1
+	}
+	for i := range pattern {
+		pattern[i] = unicode.ToLower(pattern[i])
+	}
+	for i := 0; i+len(pattern) <= len(text); i++ {
+		j := 0
+		for j < len(pattern) && unicode.ToLower(text[i+j]) == pattern[j] {
+			j++
+		}
+		if j == len(pattern) {
+			return i
+		}
+	}
+	return -1
+}
+
+// firstMatch devuelve el primer texto marcado en los fragmentos.
+func firstMatch(fragments []string) string {
+	for _, fragment := range fragments {
+		i := strings.Index(fragment, HighlightPreTag)
+		if i < 0 {
+			continue
+		}
+		rest := fragment[i+len(HighlightPreTag):]
+		if j := strings.Index(rest, HighlightPostTag); j > 0 {
+			return rest[:j]
+		}
+	}
+	return ""
+}
+
+// Mark marca con HighlightPreTag y HighlightPostTag las palabras del texto
+// que coinciden con alguna de words, sin distinguir mayúsculas, y escapa el
+// HTML del resto. Se usa con los motores que no devuelven las coincidencias
+// marcadas.
+func Mark(text string, words []string) string {
+	if len(words) == 0 {
+		return html.EscapeString(text)
+	}
+	wanted := make(map[string]bool, len(words))
+	for _, word := range words {
+		wanted[strings.ToLower(word)] = true
+	}
+
+	var b strings.Builder
+	start := -1
+	flush := func(end int) {
+		// Las palabras solo tienen letras y dígitos: no hace falta escaparlas
+		if word := text[start:end]; wanted[strings.ToLower(word)] {
+			b.WriteString(HighlightPreTag + word + HighlightPostTag)
+		} else {
+			b.WriteString(word)
+		}
+		start = -1
+	}
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			flush(i)
+		}
+		b.WriteString(html.EscapeString(string(r)))
+	}
+	if start >= 0 {
+		flush(len(text))
+	}
+	return b.String()
+}
+
+// Words devuelve las palabras de los términos de texto que no están negados,
+// para marcarlas con Mark.
+func Words(node Node) []string {
+	var words []string
+	var walk func(node Node)
+	walk = func(node Node) {
+		switch n := node.(type) {
+		case And:
+			for _, child := range n.Nodes {
+				walk(child)
+			}
+		case Or:
+			for _, child := range n.Nodes {
+				walk(child)
+			}
+		case Term:
+			switch n.Field {
+			case "", "subject", "body":
+				words = append(words, strings.FieldsFunc(n.Value, func(r rune) bool {
+					return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+				})...)
+			}
+		}
+	}
+	walk(node)
+	return words
+}
@@ -0,0 +1,153 @@
+package searchquery
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSnippet(t *testing.T) {
+	long := strings.Repeat("relleno ", 30) + "Acuerdo de fusión firmado " + strings.Repeat("final ", 30)
+
+	tests := []struct {
+		name      string
+		body      string
+		fragments []string
+		size      int
+		want      string
+	}{
+		{
+			name: "cuerpo corto con espacios normalizados",
+			body: "  Hola\n\n  mundo  ",
+			size: 50,
+			want: "Hola mundo",
+		},
+		{
+			name: "sin coincidencias devuelve el comienzo",
+			body: "uno dos tres cuatro cinco seis",
+			size: 12,
+			want: "uno dos tres…",
+		},
+		{
+			name:      "centrado en la coincidencia",
+			body:      long,
+			fragments: []string{"… de <mark>fusión</mark> firmado"},
+			size:      40,
+			want:      "…Acuerdo de fusión firmado final final…",
+		},
+		{
+			name:      "coincidencia con otra capitalización",
+			body:      long,
+			fragments: []string{"<mark>ACUERDO</mark>"},
+			size:      40,
+			want:      "…relleno Acuerdo de fusión firmado…",
+		},
+		{
+			name:      "coincidencia al final del cuerpo",
+			body:      strings.Repeat("palabra ", 20) + "Último",
+			fragments: []string{"<mark>último</mark>"},
+			size:      20,
+			want:      "…palabra Último",
+		},
+		{
+			name:      "coincidencia que no está en el cuerpo",
+			body:      "uno dos tres cuatro cinco seis",
+			fragments: []string{"<mark>siete</mark>"},
+			size:      12,
+			want:      "uno dos tres…",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Snippet(tt.body, tt.fragments, tt.size)
+			if got != tt.want {
+				t.Errorf("Snippet() = %q, se esperaba %q", got, tt.want)
+			}
+			if n := utf8.RuneCountInString(strings.Trim(got, "…")); n > tt.size {
+				t.Errorf("el fragmento tiene %d caracteres, más que %d", n, tt.size)
+			}
+		})
+	}
+}
+
+func TestIndexFold(t *testing.T) {
+	tests := []struct {
+		text string
+		term string
+		want int
+	}{
+		{"Acuerdo de Fusión", "fusión", 11},
+		{"ÁRBOL árbol", "árbol", 0},
+		{"straße STRASSE", "STRASSE", 7},
+		{"İstanbul y más", "más", 11},
+		{"abc", "", -1},
+		{"abc", "abcd", -1},
+	}
+	for _, tt := range tests {
+		if got := indexFold([]rune(tt.text), tt.term); got != tt.want {
+			t.Errorf("indexFold(%q, %q) = %d, se esperaba %d", tt.text, tt.term, got, tt.want)
+		}
+	}
+}
+
+func TestMark(t *testing.T) {
+	tests := []struct {
+		text  string
+		words []string
+		want  string
+	}{
+		{"Acuerdo de fusión", []string{"fusión"}, "Acuerdo de <mark>fusión</mark>"},
+		{"Fusión, FUSIÓN y fusiones", []string{"fusión"}, "<mark>Fusión</mark>, <mark>FUSIÓN</mark> y fusiones"},
+		{"q3-plan listo", []string{"plan", "q3"}, "<mark>q3</mark>-<mark>plan</mark> listo"},
+		{"sin palabras", nil, "sin palabras"},
+		{"", []string{"x"}, ""},
+		{`<script>alert("fusión")</script>`, []string{"fusión"}, "&lt;script&gt;alert(&#34;<mark>fusión</mark>&#34;)&lt;/script&gt;"},
+		{"<b>AT&T</b>", nil, "&lt;b&gt;AT&amp;T&lt;/b&gt;"},
+		{"<mark>falsa</mark> marca", []string{"marca"}, "&lt;mark&gt;falsa&lt;/mark&gt; <mark>marca</mark>"},
+	}
+	for _, tt := range tests {
+		if got := Mark(tt.text, tt.words); got != tt.want {
+			t.Errorf("Mark(%q, %q) = %q, se esperaba %q", tt.text, tt.words, got, tt.want)
+		}
+	}
+}
+
+func TestEscapeHighlight(t *testing.T) {
+	tests := []struct {
+		fragment string
+		want     string
+	}{
+		{"Acuerdo de <mark>fusión</mark>", "Acuerdo de <mark>fusión</mark>"},
+		{`<img src=x onerror="alert(1)"> <mark>fusión</mark>`, "&lt;img src=x onerror=&#34;alert(1)&#34;&gt; <mark>fusión</mark>"},
+		{"<b><mark>AT</mark>&T</b>", "&lt;b&gt;<mark>AT</mark>&amp;T&lt;/b&gt;"},
+	}
+	for _, tt := range tests {
+		if got := EscapeHighlight(tt.fragment); got != tt.want {
+			t.Errorf("EscapeHighlight(%q) = %q, se esperaba %q", tt.fragment, got, tt.want)
+		}
+	}
+}
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"budget", []string{"budget"}},
+		{`"q3 budget-review" from:alice`, []string{"q3", "budget", "review"}},
+		{"subject:plan OR body:merger", []string{"plan", "merger"}},
+		{"contract -spam folder:inbox", []string{"contract"}},
+		{"from:alice", nil},
+	}
+	for _, tt := range tests {
+		node, err := Parse(tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := Words(node); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Words(%q) = %q, se esperaba %q", tt.query, got, tt.want)
+		}
+	}
+}
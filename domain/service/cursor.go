@@ -0,0 +1,198 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"project/domain/model"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor indica que el cursor recibido no es válido o fue modificado.
+var ErrInvalidCursor = errors.New("cursor inválido")
+
+// CursorCodec firma los cursores de paginación con HMAC-SHA256 para que los
+// clientes no puedan fabricarlos ni modificarlos.
+type CursorCodec struct {
+	key []byte
+}
+
+// NewCursorCodec crea el codificador con la clave indicada. Si la clave está
+// vacía se genera una aleatoria, por lo que los cursores dejan de ser válidos
+// al reiniciar el proceso.
+func NewCursorCodec(secret string) *CursorCodec {
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			panic(fmt.Sprintf("no se pudo generar la clave de los cursores: %v", err))
+		}
+	}
+	return &CursorCodec{key: key}
+}
+
+// Encode serializa y firma el valor. El resultado es opaco para los clientes.
+func (c *CursorCodec) Encode(value interface{}) (string, error) {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("error serializando el cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(c.sign(payload)), nil
+}
+
+// Decode comprueba la firma del cursor y lo deserializa en value.
+func (c *CursorCodec) Decode(token string, value interface{}) error {
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, c.sign(payload)) {
+		return ErrInvalidCursor
+	}
+	if err := json.Unmarshal(payload, value); err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+func (c *CursorCodec) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// Cursor es una posición del listado de correos: el valor de la columna de
+// orden y el id del correo límite. Before indica que se piden los anteriores.
+type Cursor struct {
+	Field  string  `json:"f"`
+	Desc   bool    `json:"d,omitempty"`
+	Value  *string `json:"v,omitempty"` // nil si la columna es NULL o se ordena por id
+	ID     int     `json:"i"`
+	Before bool    `json:"b,omitempty"`
+}
+
+// Sort devuelve el orden del listado al que pertenece el cursor.
+func (c Cursor) Sort() EmailSort {
+	return EmailSort{Field: c.Field, Desc: c.Desc}
+}
+
+// CursorAfter devuelve el cursor de los correos que siguen a email en el orden s.
+func (s EmailSort) CursorAfter(email model.Email) Cursor {
+	return Cursor{Field: s.field(), Desc: s.Desc, Value: sortValue(s.field(), email), ID: email.ID}
+}
+
+// CursorBefore devuelve el cursor de los correos anteriores a email en el orden s.
+func (s EmailSort) CursorBefore(email model.Email) Cursor {
+	cursor := s.CursorAfter(email)
+	cursor.Before = true
+	return cursor
+}
+
+func (s EmailSort) field() string {
+	if ValidSortField(s.Field) {
+		return s.Field
+	}
+	return "id"
+}
+
+// sortValue devuelve el valor de la columna de orden de un correo.
+func sortValue(field string, email model.Email) *string {
+	var value string
+	switch field {
+	case "date":
+		if !email.Date.Valid {
+			return nil
+		}
+		value = email.Date.Time.UTC().Format(time.RFC3339Nano)
+	case "sender":
+		value = email.Sender
+	case "subject":
+		value = email.Subject
+	default:
+		return nil
+	}
+	return &value
+}
+
+// ReadOrder devuelve el orden en el que se leen los correos a partir del
+// cursor: el del listado, o el inverso si se piden los anteriores.
+func (c Cursor) ReadOrder() EmailSort {
+	order := c.Sort()
+	order.Field = order.field()
+	if c.Before {
+		order.Desc = !order.Desc
+	}
+	return order
+}
+
+// Date devuelve el valor del cursor como fecha cuando el listado se ordena por date.
+func (c Cursor) Date() (time.Time, error) {
+	if c.Value == nil {
+		return time.Time{}, nil
+	}
+	date, err := time.Parse(time.RFC3339Nano, *c.Value)
+	if err != nil {
+		return time.Time{}, ErrInvalidCursor
+	}
+	return date, nil
+}
+
+// SearchCursor es una posición en los resultados de una búsqueda, ordenados
+// por relevancia y luego por id. Query identifica la consulta y los filtros de
+// facetas a los que pertenece y Backend el motor que calculó la relevancia.
+type SearchCursor struct {
+	Score   float64 `json:"s"`
+	ID      int     `json:"i"`
+	Before  bool    `json:"b,omitempty"`
+	Query   string  `json:"q"`
+	Backend string  `json:"e,omitempty"`
+}
+
+// searchCursorQuery resume la consulta y los filtros de facetas para detectar
+// cursores usados con otra búsqueda. Los filtros se normalizan como los
+// aplican los motores: sin importar el orden, las mayúsculas de las
+// direcciones ni el intervalo si no se filtra por fecha.
+func searchCursorQuery(searchQuery string, options SearchOptions) string {
+	normalize := func(values []string) string {
+		sorted := append([]string(nil), values...)
+		sort.Strings(sorted)
+		return strings.Join(sorted, "\x1f")
+	}
+	filters := options.Filters
+	dates := make([]string, len(filters.Dates))
+	for i, date := range filters.Dates {
+		dates[i] = date.UTC().Format(time.RFC3339)
+	}
+	interval := ""
+	if len(dates) > 0 {
+		interval = options.interval()
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		searchQuery,
+		normalize(lowerAll(filters.Senders)),
+		normalize(lowerAll(filters.Receivers)),
+		normalize(filters.Folders),
+		normalize(dates),
+		interval,
+	}, "\x00")))
+	return base64.RawURLEncoding.EncodeToString(sum[:8])
+}
+
+// NewSearchCursor devuelve el cursor de los resultados que siguen (o preceden)
+// a hit, devueltos por el motor backend para la consulta y las opciones.
+func NewSearchCursor(searchQuery string, options SearchOptions, backend string, hit model.SearchHit, before bool) SearchCursor {
+	return SearchCursor{Score: hit.Score, ID: hit.ID, Before: before, Query: searchCursorQuery(searchQuery, options), Backend: backend}
+}
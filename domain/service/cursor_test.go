@@ -0,0 +1,80 @@
+package service_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"project/domain/service"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCursorCodecRoundTrip(t *testing.T) {
+	codec := service.NewCursorCodec("secreto")
+	value := "Alice"
+	cursor := service.Cursor{Field: "sender", Desc: true, Value: &value, ID: 42, Before: true}
+
+	token, err := codec.Encode(cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got service.Cursor
+	if err := codec.Decode(token, &got); err != nil {
+		t.Fatalf("Decode() = %v", err)
+	}
+	if !reflect.DeepEqual(got, cursor) {
+		t.Errorf("Decode() = %#v, se esperaba %#v", got, cursor)
+	}
+}
+
+func TestCursorCodecRejectsInvalidTokens(t *testing.T) {
+	codec := service.NewCursorCodec("secreto")
+	token, err := codec.Encode(service.Cursor{Field: "id", ID: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, signature, _ := strings.Cut(token, ".")
+
+	foreign, err := service.NewCursorCodec("otro secreto").Encode(service.Cursor{Field: "id", ID: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherType, err := codec.Encode("no es un cursor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"f":"id","i":8}`))
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"vacío", ""},
+		{"sin firma", payload},
+		{"contenido modificado", forged + "." + signature},
+		{"firma modificada", payload + "." + strings.Repeat("A", len(signature))},
+		{"firma truncada", payload + "." + signature[:len(signature)/2]},
+		{"base64 inválido", "%%%." + signature},
+		{"firmado con otra clave", foreign},
+		{"firma válida de otro tipo de valor", otherType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got service.Cursor
+			if err := codec.Decode(tt.token, &got); !errors.Is(err, service.ErrInvalidCursor) {
+				t.Errorf("Decode() = %v, se esperaba ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+func TestCursorCodecRandomKey(t *testing.T) {
+	token, err := service.NewCursorCodec("").Encode(service.Cursor{Field: "id", ID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got service.Cursor
+	if err := service.NewCursorCodec("").Decode(token, &got); !errors.Is(err, service.ErrInvalidCursor) {
+		t.Errorf("Decode() = %v, dos claves aleatorias no deberían coincidir", err)
+	}
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"errors"
+	"project/domain/model"
+)
+
+// ErrTransient indica que una escritura falló por un conflicto pasajero del
+// almacenamiento (por ejemplo un deadlock) y se puede reintentar.
+var ErrTransient = errors.New("conflicto transitorio al guardar")
+
+// InsertResult informa el resultado de guardar un lote de correos.
+type InsertResult struct {
+	Created    []model.Email // Correos nuevos, con su ID asignado
+	Duplicates int           // Copias de correos que ya estaban guardados
+}
+
+// EmailRepository guarda y consulta los correos junto con sus adjuntos,
+// participantes y ubicaciones. infrastructure/sqlstore lo implementa sobre MySQL
+// o SQLite e infrastructure/memory en memoria, para probar los servicios sin base de datos.
+type EmailRepository interface {
+	// List devuelve los correos que cumplen el filtro en el orden indicado.
+	// Con limit <= 0 devuelve todos, sin paginar.
+	List(filter EmailFilter, order EmailSort, offset, limit int) ([]model.Email, error)
+
+	// ListAfter devuelve hasta limit correos que cumplen el filtro y siguen al
+	// cursor en su orden. Si cursor.Before devuelve los anteriores, empezando
+	// por el más cercano al cursor.
+	ListAfter(filter EmailFilter, cursor Cursor, limit int) ([]model.Email, error)
+
+	// Count devuelve la cantidad de correos que cumplen el filtro.
+	Count(filter EmailFilter) (int, error)
+
+	// Get devuelve el correo con sus adjuntos, participantes y ubicaciones, o
+	// nil si no existe.
+	Get(id int) (*model.Email, error)
+
+	// Attachments devuelve los adjuntos de un correo.
+	Attachments(emailID int) ([]model.Attachment, error)
+
+	// Attachment devuelve un adjunto de un correo o nil si no existe.
+	Attachment(emailID, attachmentID int) (*model.Attachment, error)
+
+	// Insert guarda un lote de correos de forma atómica. Los correos que ya
+	// estaban guardados (mismo Message-ID y hash del cuerpo) solo suman sus
+	// ubicaciones. Asigna el ID a cada correo del lote. El contenido de los
+	// adjuntos se guarda aparte; aquí solo se registra su Hash.
+	Insert(emails []model.Email) (InsertResult, error)
+}
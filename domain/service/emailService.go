@@ -2,49 +2,79 @@ package service
 
 import (
 	"project/domain/model"
-	zincSearchClient "project/infrastructure/zincsearch"
+	"project/domain/searchquery"
 
-	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 )
 
-// EmailService es el servicio que maneja las operaciones sobre los correos electrónicos.
-type EmailService struct {
-	db *sql.DB
+// EmailFilter agrupa los filtros opcionales del listado de correos.
+type EmailFilter struct {
+	Participant string    // Dirección de un participante del correo
+	Role        string    // Rol del participante: from, to, cc o bcc
+	Sender      string    // Dirección del remitente
+	Receiver    string    // Dirección de un destinatario (to, cc o bcc)
+	Folder      string    // Carpeta (completa o su último componente) en la que apareció alguna copia del correo
+	Owner       string    // Dueño del buzón en el que apareció alguna copia del correo
+	DateFrom    time.Time // Fecha mínima (inclusive); cero si no se filtra
+	DateTo      time.Time // Fecha máxima (exclusive); cero si no se filtra
+	HasBody     *bool     // Correos con o sin cuerpo; nil si no se filtra
+	ContentType string    // Prefijo del Content-Type, por ejemplo text/plain
+	ThreadID    int       // Hilo al que pertenece el correo; 0 si no se filtra
 }
 
-// NewEmailService crea una nueva instancia de EmailService.
-func NewEmailService(db *sql.DB) *EmailService {
-	return &EmailService{db: db}
+// Campos por los que se puede ordenar el listado de correos
+var sortFields = []string{"date", "id", "sender", "subject"}
+
+// SortFields devuelve los campos por los que se puede ordenar el listado.
+func SortFields() []string {
+	return append([]string(nil), sortFields...)
 }
 
-func (es *EmailService) GetEmailsWithPagination(offset int, limit int) ([]model.Email, int, error) {
-	var emails []model.Email
+// ValidSortField indica si se puede ordenar el listado por el campo.
+func ValidSortField(field string) bool {
+	for _, f := range sortFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
 
-	query := `SELECT id, message_id, sender, receiver, subject, mime_version, content_type, encoding, folder, body, date 
-              FROM emails LIMIT ? OFFSET ?`
+// EmailSort es el orden del listado de correos. Los repositorios siempre
+// desempatan por id para que las páginas no repitan ni salteen correos.
+type EmailSort struct {
+	Field string // id, date, sender o subject; por defecto id
+	Desc  bool
+}
 
-	// Ejecutar la consulta con los parámetros limit y offset
-	rows, err := es.db.Query(query, limit, offset)
+// EmailService es el servicio que maneja las operaciones sobre los correos electrónicos.
+type EmailService struct {
+	emails EmailRepository
+	search SearchBackend
+}
+
+// ErrNoSearchBackend indica que el servicio se creó sin motor de búsqueda.
+var ErrNoSearchBackend = errors.New("no hay un motor de búsqueda configurado")
+
+// NewEmailService crea una nueva instancia de EmailService sobre el
+// repositorio y el motor de búsqueda indicados. search puede ser nil si el
+// servicio no se usa para buscar.
+func NewEmailService(emails EmailRepository, search SearchBackend) *EmailService {
+	return &EmailService{emails: emails, search: search}
+}
+
+// GetEmailsWithPagination devuelve una página de correos que cumplen el filtro,
+// en el orden indicado, y el total de coincidencias.
+func (es *EmailService) GetEmailsWithPagination(filter EmailFilter, order EmailSort, offset int, limit int) ([]model.Email, int, error) {
+	emails, err := es.emails.List(filter, order, offset, limit)
 	if err != nil {
 		return nil, 0, err
 	}
-	defer rows.Close()
-
-	// Leer los resultados de la consulta y mapearlos a la estructura Email
-	for rows.Next() {
-		var email model.Email
-		err := rows.Scan(&email.ID, &email.MessageID, &email.Sender, &email.Receiver, &email.Subject,
-			&email.MimeVersion, &email.ContentType, &email.Encoding, &email.Folder, &email.Body, &email.Date)
-		if err != nil {
-			return nil, 0, err
-		}
-		emails = append(emails, email)
-	}
 
 	// Obtener el total de correos
-	var total int
-	err = es.db.QueryRow("SELECT COUNT(*) FROM emails").Scan(&total)
+	total, err := es.emails.Count(filter)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -52,51 +82,140 @@ func (es *EmailService) GetEmailsWithPagination(offset int, limit int) ([]model.
 	return emails, total, nil
 }
 
-// GetEmailByID obtiene un correo electrónico por ID.
+// GetEmailsByCursor devuelve hasta limit correos que cumplen el filtro,
+// posteriores (o anteriores) al cursor en su orden, e indica si hay más en esa
+// dirección. No cuenta el total, por lo que su costo no depende de la posición.
+func (es *EmailService) GetEmailsByCursor(filter EmailFilter, cursor Cursor, limit int) ([]model.Email, bool, error) {
+	// Se lee un correo de más para saber si quedan otros después
+	emails, err := es.emails.ListAfter(filter, cursor, limit+1)
+	if err != nil {
+		return nil, false, err
+	}
+
+	hasMore := len(emails) > limit
+	if hasMore {
+		emails = emails[:limit]
+	}
+	if cursor.Before {
+		// Se leyeron en orden inverso
+		for i, j := 0, len(emails)-1; i < j; i, j = i+1, j-1 {
+			emails[i], emails[j] = emails[j], emails[i]
+		}
+	}
+	return emails, hasMore, nil
+}
+
+// GetEmailByID obtiene un correo electrónico por ID con sus adjuntos,
+// participantes y ubicaciones.
 func (es *EmailService) GetEmailByID(id int) (*model.Email, error) {
 	fmt.Printf("Iniciando consulta para obtener correo con ID: %d\n", id)
-	var email model.Email
-
-	err := es.db.QueryRow(`
-		SELECT id, message_id, sender, receiver, subject, mime_version, content_type, encoding, folder, body, date 
-		FROM emails 
-		WHERE id = ?`, id).
-		Scan(&email.ID, &email.MessageID, &email.Sender, &email.Receiver, &email.Subject, &email.MimeVersion,
-			&email.ContentType, &email.Encoding, &email.Folder, &email.Body, &email.Date)
 
+	email, err := es.emails.Get(id)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			fmt.Printf("Correo no encontrado para ID: %d\n", id)
-			return nil, nil
-		}
 		fmt.Printf("Error al ejecutar consulta para ID %d: %v\n", id, err)
 		return nil, fmt.Errorf("error al obtener el correo: %w", err)
 	}
+	if email == nil {
+		fmt.Printf("Correo no encontrado para ID: %d\n", id)
+		return nil, nil
+	}
 
 	fmt.Printf("Correo encontrado para ID: %d\n", id)
-	return &email, nil
+	return email, nil
+}
+
+// GetAttachment devuelve un adjunto concreto de un correo o nil si no existe.
+func (es *EmailService) GetAttachment(emailID int, attachmentID int) (*model.Attachment, error) {
+	return es.emails.Attachment(emailID, attachmentID)
 }
 
-// función que hace la búsqueda en ZincSearch
-func (es *EmailService) SearchEmailsWithPagination(query string, offset int, limit int) ([]model.Email, int, error) {
-	if query == "" {
-		return nil, 0, fmt.Errorf("el término de búsqueda no puede estar vacío")
+// Tamaño en caracteres de los fragmentos resaltados y del resumen del cuerpo
+const (
+	highlightFragmentSize = 150
+	snippetSize           = 200
+)
+
+// SearchEmailsWithPagination busca con el motor configurado usando la sintaxis
+// de searchquery (from:, to:, subject:, after:, -término, OR, ...). Cada
+// resultado incluye su relevancia, los fragmentos resaltados del asunto y el
+// cuerpo y un resumen del cuerpo. Las facetas elegidas en options filtran los
+// resultados y, si se piden, se devuelven las facetas de la búsqueda. Si la
+// consulta no es válida devuelve un *searchquery.ParseError.
+func (es *EmailService) SearchEmailsWithPagination(searchQuery string, options SearchOptions, offset int, limit int) (*SearchResult, error) {
+	node, err := searchquery.Parse(searchQuery)
+	if err != nil {
+		return nil, err
 	}
 
-	searchQuery := fmt.Sprintf(`{
-        "query": {
-            "match": {
-                "subject": "%s"
-            }
-        },
-        "from": %d,
-        "size": %d
-    }`, query, offset, limit)
+	return es.runSearch(SearchRequest{Query: node, Options: options, From: offset, Size: limit})
+}
+
+// SearchEmailsByCursor es como SearchEmailsWithPagination pero devuelve los
+// resultados posteriores (o anteriores) al cursor, e indica si hay más en esa
+// dirección. Devuelve ErrInvalidCursor si el cursor es de otra consulta o de
+// otros filtros de facetas, o si lo emitió otro motor de búsqueda, ya que la
+// relevancia de cada motor es distinta.
+func (es *EmailService) SearchEmailsByCursor(searchQuery string, options SearchOptions, cursor SearchCursor, limit int) (*SearchResult, bool, error) {
+	if cursor.Query != searchCursorQuery(searchQuery, options) {
+		return nil, false, ErrInvalidCursor
+	}
 
-	emails, total, err := zincSearchClient.NewZincSearchClient().SearchEmailsWithPagination(searchQuery)
+	node, err := searchquery.Parse(searchQuery)
 	if err != nil {
-		return nil, 0, fmt.Errorf("error al buscar en ZincSearch: %v", err)
+		return nil, false, err
+	}
+	// Se lee un resultado de más para saber si quedan otros después
+	result, err := es.runSearch(SearchRequest{Query: node, Options: options, Size: limit + 1, After: &cursor})
+	if err != nil {
+		return nil, false, err
+	}
+	if cursor.Backend != "" && cursor.Backend != result.Backend {
+		return nil, false, ErrInvalidCursor
 	}
 
-	return emails, total, nil
+	hasMore := len(result.Hits) > limit
+	if hasMore {
+		result.Hits = result.Hits[:limit]
+	}
+	if cursor.Before {
+		// Se leyeron en orden inverso
+		for i, j := 0, len(result.Hits)-1; i < j; i, j = i+1, j-1 {
+			result.Hits[i], result.Hits[j] = result.Hits[j], result.Hits[i]
+		}
+	}
+	return result, hasMore, nil
+}
+
+// runSearch ejecuta la búsqueda en el motor y completa los resúmenes del cuerpo.
+func (es *EmailService) runSearch(req SearchRequest) (*SearchResult, error) {
+	if es.search == nil {
+		return nil, ErrNoSearchBackend
+	}
+	result, err := es.search.Search(req)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range result.Hits {
+		result.Hits[i].Snippet = searchquery.Snippet(result.Hits[i].Body, result.Hits[i].Highlights["body"], snippetSize)
+	}
+	return result, nil
+}
+
+// GetEmailsAfterID devuelve hasta limit correos con ID mayor a afterID, ordenados
+// por ID y con los metadatos de sus adjuntos. Se usa para recorrer todo el corpus.
+func (es *EmailService) GetEmailsAfterID(afterID int, limit int) ([]model.Email, error) {
+	emails, err := es.emails.ListAfter(EmailFilter{}, Cursor{Field: "id", ID: afterID}, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range emails {
+		emails[i].Attachments, err = es.emails.Attachments(emails[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return emails, nil
 }
@@ -0,0 +1,216 @@
+package service_test
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"project/domain/model"
+	"project/domain/service"
+	"project/infrastructure/memory"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestService crea el servicio sobre un repositorio en memoria con correos
+// de remitentes en distinta capitalización, remitentes repetidos y fechas nulas.
+func newTestService(t *testing.T) *service.EmailService {
+	t.Helper()
+	senders := []string{"bob@example.com", "Alice@example.com", "carol@example.com", "alice@example.com", "BOB@example.com"}
+	var emails []model.Email
+	for i := 0; i < 23; i++ {
+		email := model.Email{
+			MessageID:   fmt.Sprintf("<%d@example.com>", i),
+			ContentHash: fmt.Sprint(i),
+			Sender:      senders[i%len(senders)],
+			Subject:     fmt.Sprintf("Asunto %d", i%4),
+		}
+		if i%6 != 0 {
+			email.Date = sql.NullTime{Time: time.Date(2001, 5, 1+i%3, 0, 0, 0, 0, time.UTC), Valid: true}
+		}
+		email.Folder = `\alice\inbox`
+		if i%3 == 0 {
+			email.Folder = `\alice\sent`
+		}
+		email.Locations = []model.MessageLocation{{Folder: email.Folder, FilePath: fmt.Sprintf("alice/%d.", i), Owner: "alice"}}
+		emails = append(emails, email)
+	}
+
+	repository := memory.NewEmailRepository()
+	if _, err := repository.Insert(emails); err != nil {
+		t.Fatal(err)
+	}
+	return service.NewEmailService(repository, nil)
+}
+
+func ids(emails []model.Email) []int {
+	result := make([]int, len(emails))
+	for i, email := range emails {
+		result[i] = email.ID
+	}
+	return result
+}
+
+var paginationCases = []struct {
+	name   string
+	filter service.EmailFilter
+	order  service.EmailSort
+}{
+	{"por id", service.EmailFilter{}, service.EmailSort{}},
+	{"por id descendente", service.EmailFilter{}, service.EmailSort{Field: "id", Desc: true}},
+	{"por fecha con nulos", service.EmailFilter{}, service.EmailSort{Field: "date"}},
+	{"por fecha descendente", service.EmailFilter{}, service.EmailSort{Field: "date", Desc: true}},
+	{"por remitente", service.EmailFilter{}, service.EmailSort{Field: "sender"}},
+	{"por asunto descendente", service.EmailFilter{}, service.EmailSort{Field: "subject", Desc: true}},
+	{"filtrado por carpeta", service.EmailFilter{Folder: "inbox"}, service.EmailSort{Field: "sender", Desc: true}},
+}
+
+func TestGetEmailsWithPagination(t *testing.T) {
+	es := newTestService(t)
+
+	for _, tt := range paginationCases {
+		t.Run(tt.name, func(t *testing.T) {
+			all, total, err := es.GetEmailsWithPagination(tt.filter, tt.order, 0, 100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != len(all) {
+				t.Fatalf("total %d, se listaron %d correos", total, len(all))
+			}
+
+			var paged []model.Email
+			for offset := 0; offset < total; offset += 5 {
+				page, pageTotal, err := es.GetEmailsWithPagination(tt.filter, tt.order, offset, 5)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if pageTotal != total {
+					t.Errorf("la página %d informa un total de %d, se esperaba %d", offset, pageTotal, total)
+				}
+				paged = append(paged, page...)
+			}
+			if !reflect.DeepEqual(ids(paged), ids(all)) {
+				t.Errorf("páginas %v, se esperaba %v", ids(paged), ids(all))
+			}
+		})
+	}
+}
+
+func TestGetEmailsByCursor(t *testing.T) {
+	es := newTestService(t)
+	const limit = 4
+
+	for _, tt := range paginationCases {
+		t.Run(tt.name, func(t *testing.T) {
+			all, _, err := es.GetEmailsWithPagination(tt.filter, tt.order, 0, 100)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(all) <= 2*limit {
+				t.Fatalf("el caso tiene %d correos, muy pocos para paginar", len(all))
+			}
+
+			// La primera página se pide sin cursor y las siguientes con el del último correo
+			forward, _, err := es.GetEmailsWithPagination(tt.filter, tt.order, 0, limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for hasMore := true; hasMore; {
+				var page []model.Email
+				page, hasMore, err = es.GetEmailsByCursor(tt.filter, tt.order.CursorAfter(forward[len(forward)-1]), limit)
+				if err != nil {
+					t.Fatal(err)
+				}
+				forward = append(forward, page...)
+				if wantMore := len(forward) < len(all); hasMore != wantMore {
+					t.Fatalf("hasMore = %v después de %d correos de %d", hasMore, len(forward), len(all))
+				}
+			}
+			if !reflect.DeepEqual(ids(forward), ids(all)) {
+				t.Fatalf("hacia adelante %v, se esperaba %v", ids(forward), ids(all))
+			}
+
+			// Hacia atrás desde el último correo
+			backward := all[len(all)-1:]
+			for hasMore := true; hasMore; {
+				var page []model.Email
+				page, hasMore, err = es.GetEmailsByCursor(tt.filter, tt.order.CursorBefore(backward[0]), limit)
+				if err != nil {
+					t.Fatal(err)
+				}
+				backward = append(page, backward...)
+				if wantMore := len(backward) < len(all); hasMore != wantMore {
+					t.Fatalf("hasMore = %v hacia atrás con %d correos de %d", hasMore, len(backward), len(all))
+				}
+			}
+			if !reflect.DeepEqual(ids(backward), ids(all)) {
+				t.Errorf("hacia atrás %v, se esperaba %v", ids(backward), ids(all))
+			}
+		})
+	}
+}
+
+func TestGetEmailsSortedBySenderIgnoresCase(t *testing.T) {
+	es := newTestService(t)
+
+	emails, _, err := es.GetEmailsWithPagination(service.EmailFilter{}, service.EmailSort{Field: "sender"}, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Las direcciones que solo difieren en mayúsculas se ordenan juntas, por id
+	var groups []string
+	for i, email := range emails {
+		sender := strings.ToLower(email.Sender)
+		if i == 0 || sender != groups[len(groups)-1] {
+			groups = append(groups, sender)
+		} else if email.ID < emails[i-1].ID {
+			t.Errorf("el correo %d aparece después del %d con el mismo remitente", email.ID, emails[i-1].ID)
+		}
+	}
+	want := []string{"alice@example.com", "bob@example.com", "carol@example.com"}
+	if !reflect.DeepEqual(groups, want) {
+		t.Errorf("remitentes %q, se esperaba %q", groups, want)
+	}
+}
+
+func TestSearchEmailsByCursorRejectsCursorOfAnotherQuery(t *testing.T) {
+	es := newTestService(t)
+	may := time.Date(2001, 5, 1, 0, 0, 0, 0, time.UTC)
+	june := time.Date(2001, 6, 1, 0, 0, 0, 0, time.UTC)
+	options := service.SearchOptions{Filters: service.FacetFilters{
+		Senders: []string{"Alice@example.com", "bob@example.com"},
+		Folders: []string{"inbox"},
+		Dates:   []time.Time{may, june},
+	}}
+	cursor := service.NewSearchCursor("contract", options, "embedded", model.SearchHit{Email: model.Email{ID: 1}, Score: 1}, false)
+
+	tests := []struct {
+		name    string
+		query   string
+		options service.SearchOptions
+		valid   bool
+	}{
+		{"otra consulta", "budget", options, false},
+		{"sin filtros", "contract", service.SearchOptions{}, false},
+		{"otro remitente", "contract", service.SearchOptions{Filters: service.FacetFilters{
+			Senders: []string{"alice@example.com"}, Folders: []string{"inbox"}, Dates: []time.Time{may, june}}}, false},
+		{"otro intervalo", "contract", service.SearchOptions{Interval: "year", Filters: options.Filters}, false},
+		{"mismos filtros en otro orden", "contract", service.SearchOptions{Facets: true, Interval: "month", Filters: service.FacetFilters{
+			Senders: []string{"BOB@example.com", "alice@example.com"}, Folders: []string{"inbox"}, Dates: []time.Time{june, may}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := es.SearchEmailsByCursor(tt.query, tt.options, cursor, 10)
+			// Con un cursor válido falla después, porque el servicio no tiene motor de búsqueda
+			want := service.ErrInvalidCursor
+			if tt.valid {
+				want = service.ErrNoSearchBackend
+			}
+			if !errors.Is(err, want) {
+				t.Errorf("SearchEmailsByCursor() = %v, se esperaba %v", err, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"errors"
+	"project/domain/model"
+	"project/infrastructure/blobstore"
+	"time"
+)
+
+// BatchConfig configura el tamaño y la frecuencia de los lotes.
+type BatchConfig struct {
+	Size          int           // Cantidad máxima de correos por lote
+	FlushInterval time.Duration // Tiempo máximo que un correo espera antes de escribirse
+	MaxRetries    int           // Reintentos ante conflictos transitorios (ErrTransient)
+}
+
+// DefaultBatchConfig devuelve la configuración por defecto.
+func DefaultBatchConfig() BatchConfig {
+	return BatchConfig{Size: 200, FlushInterval: 2 * time.Second, MaxRetries: 3}
+}
+
+// BatchResult informa el resultado de escribir un lote.
+type BatchResult struct {
+	Number     int             // Número de lote, empezando en 1
+	Created    []model.Email   // Correos nuevos, con su ID asignado
+	Duplicates int             // Copias de correos que ya estaban guardados
+	Emails     int             // Cantidad total de correos del lote
+	Attempts   int             // Intentos que hicieron falta
+	Rejected   []RejectedEmail // Correos que no se pudieron guardar cuando el resto del lote sí
+	Duration   time.Duration
+	Err        error // Error si no se pudo guardar ningún correo del lote
+}
+
+// RejectedEmail es un correo que el repositorio rechazó, por ejemplo por un
+// valor que no entra en su columna.
+type RejectedEmail struct {
+	Email model.Email
+	Err   error
+}
+
+// EmailWriter guarda correos en el repositorio agrupándolos en lotes. Cada
+// lote se guarda con una sola llamada a EmailRepository.Insert, salvo que el
+// repositorio rechace alguno de sus correos (ver WriteBatch).
+type EmailWriter struct {
+	emails EmailRepository
+	store  *blobstore.Store
+	cfg    BatchConfig
+}
+
+// NewEmailWriter crea un EmailWriter. Los valores no positivos de cfg se
+// reemplazan por los de DefaultBatchConfig.
+func NewEmailWriter(emails EmailRepository, store *blobstore.Store, cfg BatchConfig) *EmailWriter {
+	def := DefaultBatchConfig()
+	if cfg.Size <= 0 {
+		cfg.Size = def.Size
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = def.FlushInterval
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = def.MaxRetries
+	}
+	return &EmailWriter{emails: emails, store: store, cfg: cfg}
+}
+
+// Run consume los correos del canal hasta que se cierra, escribiéndolos en
+// lotes de cfg.Size o cada cfg.FlushInterval, y llama a onBatch con el
+// resultado de cada lote.
+func (w *EmailWriter) Run(emails <-chan model.Email, onBatch func(BatchResult)) {
+	ticker := time.NewTicker(w.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]model.Email, 0, w.cfg.Size)
+	number := 0
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		number++
+		result := w.WriteBatch(batch)
+		result.Number = number
+		onBatch(result)
+		batch = make([]model.Email, 0, w.cfg.Size)
+	}
+
+	for {
+		select {
+		case email, ok := <-emails:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, email)
+			if len(batch) >= w.cfg.Size {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// WriteBatch guarda un lote completo, reintentando ante conflictos
+// transitorios. Si el lote falla por otro motivo, los correos se guardan de a
+// uno para perder solo los que el repositorio rechaza, que se informan en
+// Rejected. Si no se guarda ninguno, el error queda en Err.
+func (w *EmailWriter) WriteBatch(emails []model.Email) BatchResult {
+	start := time.Now()
+	result := BatchResult{Emails: len(emails)}
+
+	// Los adjuntos se escriben en disco antes de guardar el lote; Put es idempotente
+	for i := range emails {
+		for j := range emails[i].Attachments {
+			attachment := &emails[i].Attachments[j]
+			hash, err := w.store.Put(attachment.Content)
+			if err != nil {
+				result.Err = err
+				result.Duration = time.Since(start)
+				return result
+			}
+			attachment.Hash = hash
+		}
+	}
+
+	inserted, attempts, err := w.insert(emails)
+	result.Attempts = attempts
+	switch {
+	case err == nil:
+		result.Created = inserted.Created
+		result.Duplicates = inserted.Duplicates
+	case errors.Is(err, ErrTransient) || len(emails) == 1:
+		result.Err = err
+	default:
+		// Un solo correo con un valor inválido hace fallar todo el lote
+		for _, email := range emails {
+			inserted, attempts, rowErr := w.insert([]model.Email{email})
+			result.Attempts += attempts
+			if rowErr != nil {
+				result.Rejected = append(result.Rejected, RejectedEmail{Email: email, Err: rowErr})
+				continue
+			}
+			result.Created = append(result.Created, inserted.Created...)
+			result.Duplicates += inserted.Duplicates
+		}
+		// Si fallan todos, el problema no son los correos sino el repositorio
+		if len(result.Rejected) == len(emails) {
+			result.Rejected = nil
+			result.Err = err
+		}
+	}
+
+	result.Duration = time.Since(start)
+	return result
+}
+
+// insert guarda los correos con una sola llamada a Insert, reintentando ante
+// conflictos transitorios. Devuelve también los intentos que hicieron falta.
+func (w *EmailWriter) insert(emails []model.Email) (InsertResult, int, error) {
+	for attempt := 1; ; attempt++ {
+		inserted, err := w.emails.Insert(emails)
+		if err == nil {
+			return inserted, attempt, nil
+		}
+		if !errors.Is(err, ErrTransient) || attempt > w.cfg.MaxRetries {
+			return InsertResult{}, attempt, err
+		}
+		time.Sleep(time.Duration(attempt*attempt) * 100 * time.Millisecond)
+	}
+}
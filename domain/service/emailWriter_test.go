@@ -0,0 +1,112 @@
+package service_test
+
+import (
+	"errors"
+	"fmt"
+	"project/domain/model"
+	"project/domain/service"
+	"project/infrastructure/blobstore"
+	"project/infrastructure/memory"
+	"testing"
+)
+
+// failingRepository rechaza los lotes que contienen un correo con asunto
+// "inválido" y falla con ErrTransient las primeras transient llamadas.
+type failingRepository struct {
+	*memory.EmailRepository
+	transient int
+	calls     int
+}
+
+var errTooLong = errors.New("valor demasiado largo para la columna subject")
+
+func (r *failingRepository) Insert(emails []model.Email) (service.InsertResult, error) {
+	r.calls++
+	if r.transient > 0 {
+		r.transient--
+		return service.InsertResult{}, fmt.Errorf("%w: deadlock", service.ErrTransient)
+	}
+	for _, email := range emails {
+		if email.Subject == "inválido" {
+			return service.InsertResult{}, errTooLong
+		}
+	}
+	return r.EmailRepository.Insert(emails)
+}
+
+func newTestWriter(t *testing.T, repository service.EmailRepository) *service.EmailWriter {
+	t.Helper()
+	t.Setenv("ATTACHMENTS_DIR", t.TempDir())
+	store, err := blobstore.NewStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return service.NewEmailWriter(repository, store, service.BatchConfig{Size: 10, MaxRetries: 2})
+}
+
+func batchEmails(subjects ...string) []model.Email {
+	emails := make([]model.Email, len(subjects))
+	for i, subject := range subjects {
+		emails[i] = model.Email{
+			MessageID:   fmt.Sprintf("<%d@example.com>", i),
+			ContentHash: fmt.Sprint(i),
+			Subject:     subject,
+			Locations:   []model.MessageLocation{{FilePath: fmt.Sprintf("alice/inbox/%d.", i)}},
+		}
+	}
+	return emails
+}
+
+func TestWriteBatchRetriesTransientErrors(t *testing.T) {
+	repository := &failingRepository{EmailRepository: memory.NewEmailRepository(), transient: 2}
+	emails := batchEmails("uno", "dos")
+	emails[0].Attachments = []model.Attachment{{Filename: "a.txt", Content: []byte("adjunto")}}
+
+	result := newTestWriter(t, repository).WriteBatch(emails)
+	if result.Err != nil || len(result.Created) != 2 || result.Attempts != 3 {
+		t.Fatalf("WriteBatch() = %d creados en %d intentos, error %v; se esperaban 2 en 3 intentos",
+			len(result.Created), result.Attempts, result.Err)
+	}
+	if hash := result.Created[0].Attachments[0].Hash; hash != blobstore.Hash([]byte("adjunto")) {
+		t.Errorf("hash del adjunto %q", hash)
+	}
+
+	// Sin más reintentos el lote falla
+	repository.transient = 3
+	result = newTestWriter(t, repository).WriteBatch(batchEmails("tres"))
+	if !errors.Is(result.Err, service.ErrTransient) || result.Attempts != 3 {
+		t.Errorf("WriteBatch() = error %v en %d intentos, se esperaba ErrTransient en 3", result.Err, result.Attempts)
+	}
+}
+
+func TestWriteBatchRejectsOnlyInvalidEmails(t *testing.T) {
+	repository := &failingRepository{EmailRepository: memory.NewEmailRepository()}
+
+	result := newTestWriter(t, repository).WriteBatch(batchEmails("uno", "inválido", "tres"))
+	if result.Err != nil {
+		t.Fatalf("WriteBatch() = %v, los correos válidos deberían guardarse", result.Err)
+	}
+	if len(result.Created) != 2 || len(result.Rejected) != 1 {
+		t.Fatalf("WriteBatch() = %d creados y %d rechazados, se esperaban 2 y 1", len(result.Created), len(result.Rejected))
+	}
+	if rejected := result.Rejected[0]; rejected.Email.Subject != "inválido" || !errors.Is(rejected.Err, errTooLong) {
+		t.Errorf("rechazado %q: %v", rejected.Email.Subject, rejected.Err)
+	}
+	if total, _ := repository.Count(service.EmailFilter{}); total != 2 {
+		t.Errorf("el repositorio tiene %d correos, se esperaban 2", total)
+	}
+}
+
+func TestWriteBatchFailsWhenEveryEmailIsRejected(t *testing.T) {
+	repository := &failingRepository{EmailRepository: memory.NewEmailRepository()}
+
+	result := newTestWriter(t, repository).WriteBatch(batchEmails("inválido", "inválido"))
+	if !errors.Is(result.Err, errTooLong) || len(result.Rejected) != 0 || len(result.Created) != 0 {
+		t.Errorf("WriteBatch() = error %v, %d rechazados, %d creados; se esperaba el error del lote",
+			result.Err, len(result.Rejected), len(result.Created))
+	}
+	// El lote completo y luego cada correo
+	if repository.calls != 3 {
+		t.Errorf("se llamó %d veces a Insert, se esperaban 3", repository.calls)
+	}
+}
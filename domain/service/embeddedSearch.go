@@ -0,0 +1,244 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"project/domain/address"
+	"project/domain/model"
+	"project/domain/searchquery"
+	"project/infrastructure/searchindex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EmbeddedSearch busca en el índice invertido local (searchindex), sin
+// depender de un servidor externo.
+type EmbeddedSearch struct {
+	index *searchindex.Index
+}
+
+// NewEmbeddedSearch crea el motor de búsqueda sobre el índice indicado.
+func NewEmbeddedSearch(index *searchindex.Index) *EmbeddedSearch {
+	return &EmbeddedSearch{index: index}
+}
+
+// Name devuelve el nombre del motor.
+func (s *EmbeddedSearch) Name() string {
+	return "embedded"
+}
+
+// AddEmails agrega los correos al índice o los reemplaza si ya estaban. Los
+// cambios quedan visibles para las búsquedas al llamar a Commit.
+func (s *EmbeddedSearch) AddEmails(emails ...model.Email) error {
+	docs := make([]searchindex.Document, 0, len(emails))
+	for _, email := range emails {
+		doc, err := emailIndexDocument(email)
+		if err != nil {
+			return err
+		}
+		docs = append(docs, doc)
+	}
+	if err := s.index.Add(docs...); err != nil {
+		return fmt.Errorf("error al indexar en el índice local: %w", err)
+	}
+	return nil
+}
+
+// Commit escribe en disco los correos agregados y los publica.
+func (s *EmbeddedSearch) Commit() error {
+	if err := s.index.Commit(); err != nil {
+		return fmt.Errorf("error al guardar el índice local: %w", err)
+	}
+	return nil
+}
+
+// Close libera el índice para que otro proceso pueda escribir en él.
+func (s *EmbeddedSearch) Close() error {
+	return s.index.Close()
+}
+
+// Count devuelve la cantidad de correos indexados.
+func (s *EmbeddedSearch) Count() int {
+	return s.index.Count()
+}
+
+// Search ejecuta la búsqueda en el índice local.
+func (s *EmbeddedSearch) Search(req SearchRequest) (*SearchResult, error) {
+	search := searchindex.SearchRequest{
+		Query: searchquery.CompileIndex(req.Query),
+		From:  req.From,
+		Size:  req.Size,
+		Highlight: map[string]searchindex.HighlightField{
+			"subject": {Fragments: 1},
+			"body":    {FragmentSize: highlightFragmentSize, Fragments: 3},
+		},
+		PreTag:  searchquery.HighlightPreTag,
+		PostTag: searchquery.HighlightPostTag,
+	}
+	if cursor := req.After; cursor != nil {
+		search.From = 0
+		search.After = &searchindex.SearchAfter{Score: cursor.Score, ID: cursor.ID}
+		search.Reverse = cursor.Before
+	}
+	if filters := req.Options.indexFilters(); len(filters) > 0 {
+		search.Query = searchindex.BoolQuery{Must: []searchindex.Query{search.Query}, Filter: filters}
+	}
+	if req.Options.Facets {
+		interval := req.Options.interval()
+		search.Terms = map[string]searchindex.TermsAggregation{
+			"senders":   {Field: "sender_addresses", Size: req.Options.FacetSize},
+			"receivers": {Field: "recipients", Size: req.Options.FacetSize},
+			"folders":   {Field: "folder", Size: req.Options.FacetSize},
+		}
+		search.Histograms = map[string]searchindex.DateHistogram{
+			"dates": {Interval: func(t time.Time) time.Time { return intervalStart(interval, t) }},
+		}
+	}
+
+	results, err := s.index.Search(search)
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar en el índice local: %v", err)
+	}
+
+	result := &SearchResult{Hits: make([]model.SearchHit, len(results.Hits)), Total: results.Total, Backend: s.Name()}
+	for i, hit := range results.Hits {
+		email, err := emailFromIndexDocument(hit.Document)
+		if err != nil {
+			return nil, err
+		}
+		result.Hits[i] = model.SearchHit{Email: email, Score: hit.Score, Highlights: hit.Highlights}
+	}
+	if req.Options.Facets {
+		result.Facets = &model.Facets{
+			Senders:   termFacets(results.Terms["senders"]),
+			Receivers: termFacets(results.Terms["receivers"]),
+			Folders:   termFacets(results.Terms["folders"]),
+			Dates:     dateFacets(results.Histograms["dates"]),
+		}
+	}
+	return result, nil
+}
+
+// emailIndexDocument convierte el correo en un documento del índice local, con
+// los mismos campos que el documento de ZincSearch.
+func emailIndexDocument(email model.Email) (searchindex.Document, error) {
+	names := make([]string, len(email.Attachments))
+	for i, attachment := range email.Attachments {
+		names[i] = attachment.Filename
+	}
+
+	doc := searchindex.Document{
+		ID: email.ID,
+		Text: map[string]string{
+			"subject":          email.Subject,
+			"body":             email.Body,
+			"attachment_names": strings.Join(names, " "),
+			"sender_name":      email.SenderName,
+			"receiver_name":    email.ReceiverName,
+		},
+		// Las direcciones se guardan en minúsculas y una por elemento, como las buscan los filtros de facetas
+		Keywords: map[string][]string{
+			"folder":             {email.Folder},
+			"sender_addresses":   address.Emails(email.Sender),
+			"receiver_addresses": address.Emails(email.Receiver),
+			"cc_addresses":       address.Emails(email.Cc),
+			"bcc_addresses":      address.Emails(email.Bcc),
+			"recipients":         address.Emails(email.Receiver, email.Cc),
+			"has_attachment":     {strconv.FormatBool(len(email.Attachments) > 0)},
+		},
+	}
+	if email.Date.Valid {
+		doc.Date = email.Date.Time.UTC()
+	}
+
+	// El cuerpo ya está en Text; los participantes y ubicaciones no se devuelven en la búsqueda
+	stored := email
+	stored.Body = ""
+	stored.Participants = nil
+	stored.Locations = nil
+	var err error
+	if doc.Stored, err = json.Marshal(stored); err != nil {
+		return doc, fmt.Errorf("error serializando el correo %d para el índice local: %w", email.ID, err)
+	}
+	return doc, nil
+}
+
+// emailFromIndexDocument reconstruye el correo guardado en el documento.
+func emailFromIndexDocument(doc searchindex.Document) (model.Email, error) {
+	var email model.Email
+	if err := json.Unmarshal(doc.Stored, &email); err != nil {
+		return email, fmt.Errorf("correo %d inválido en el índice local: %w", doc.ID, err)
+	}
+	email.Body = doc.Text["body"]
+	return email, nil
+}
+
+// indexFilters convierte las facetas elegidas en filtros del índice local.
+func (o SearchOptions) indexFilters() []searchindex.Query {
+	var filters []searchindex.Query
+	addFilter := func(queries []searchindex.Query) {
+		switch len(queries) {
+		case 0:
+		case 1:
+			filters = append(filters, queries[0])
+		default:
+			filters = append(filters, searchindex.BoolQuery{Should: queries})
+		}
+	}
+
+	terms := func(field string, values []string) []searchindex.Query {
+		queries := make([]searchindex.Query, len(values))
+		for i, value := range values {
+			queries[i] = searchindex.TermQuery{Field: field, Value: value}
+		}
+		return queries
+	}
+
+	addFilter(terms("sender_addresses", lowerAll(o.Filters.Senders)))
+	addFilter(terms("recipients", lowerAll(o.Filters.Receivers)))
+	addFilter(terms("folder", o.Filters.Folders))
+
+	dates := make([]searchindex.Query, len(o.Filters.Dates))
+	for i, start := range o.Filters.Dates {
+		from, to := o.FacetDateRange(start)
+		dates[i] = searchindex.DateRangeQuery{From: from, To: to}
+	}
+	addFilter(dates)
+
+	return filters
+}
+
+// intervalStart devuelve el inicio del intervalo del histograma que contiene
+// la fecha, en UTC. Las semanas empiezan el lunes, como en ZincSearch.
+func intervalStart(interval string, t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch interval {
+	case "day":
+		return day
+	case "week":
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case "quarter":
+		return time.Date(t.Year(), (t.Month()-1)/3*3+1, 1, 0, 0, 0, 0, time.UTC)
+	case "year":
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func termFacets(buckets []searchindex.TermBucket) []model.FacetBucket {
+	facets := make([]model.FacetBucket, 0, len(buckets))
+	for _, bucket := range buckets {
+		facets = append(facets, model.FacetBucket{Value: bucket.Value, Count: bucket.Count})
+	}
+	return facets
+}
+
+func dateFacets(buckets []searchindex.DateBucket) []model.FacetBucket {
+	facets := make([]model.FacetBucket, 0, len(buckets))
+	for _, bucket := range buckets {
+		facets = append(facets, model.FacetBucket{Value: bucket.Start.Format(time.RFC3339), Count: bucket.Count})
+	}
+	return facets
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"fmt"
+	"project/domain/model"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Intervalos aceptados en el histograma de fechas y cómo se calcula el fin de cada uno
+var facetIntervals = map[string]func(time.Time) time.Time{
+	"day":     func(t time.Time) time.Time { return t.AddDate(0, 0, 1) },
+	"week":    func(t time.Time) time.Time { return t.AddDate(0, 0, 7) },
+	"month":   func(t time.Time) time.Time { return t.AddDate(0, 1, 0) },
+	"quarter": func(t time.Time) time.Time { return t.AddDate(0, 3, 0) },
+	"year":    func(t time.Time) time.Time { return t.AddDate(1, 0, 0) },
+}
+
+// DefaultFacetInterval es el intervalo del histograma de fechas si no se indica otro.
+const DefaultFacetInterval = "month"
+
+// FacetIntervals devuelve los intervalos aceptados en el histograma de fechas.
+func FacetIntervals() []string {
+	intervals := make([]string, 0, len(facetIntervals))
+	for interval := range facetIntervals {
+		intervals = append(intervals, interval)
+	}
+	sort.Strings(intervals)
+	return intervals
+}
+
+// ValidFacetInterval indica si el intervalo del histograma de fechas es válido.
+func ValidFacetInterval(interval string) bool {
+	_, ok := facetIntervals[interval]
+	return ok
+}
+
+// ParseFacetDate interpreta el inicio de un intervalo del histograma, tal como
+// lo devuelve la faceta (RFC 3339) o como fecha AAAA-MM-DD.
+func ParseFacetDate(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("fecha de faceta inválida: %q", value)
+	}
+	return t, nil
+}
+
+// FacetFilters son los valores de facetas elegidos para refinar la búsqueda.
+// Los valores de una misma faceta se combinan con OR y las facetas entre sí con AND.
+type FacetFilters struct {
+	Senders   []string
+	Receivers []string
+	Folders   []string
+	Dates     []time.Time // Inicio de intervalos del histograma, con el intervalo de SearchOptions
+}
+
+// SearchOptions configura las facetas de una búsqueda.
+type SearchOptions struct {
+	Facets    bool   // Calcular las facetas de los resultados
+	FacetSize int    // Cantidad de valores por faceta
+	Interval  string // Intervalo del histograma de fechas
+	Filters   FacetFilters
+}
+
+// SearchResult es el resultado de una búsqueda con sus facetas, si se pidieron.
+type SearchResult struct {
+	Hits    []model.SearchHit
+	Total   int
+	Facets  *model.Facets
+	Backend string // Nombre del motor que respondió la búsqueda
+}
+
+// FacetDateRange devuelve el inicio y el fin (exclusive) del intervalo del
+// histograma que empieza en start, en UTC.
+func (o SearchOptions) FacetDateRange(start time.Time) (time.Time, time.Time) {
+	start = start.UTC()
+	return start, facetIntervals[o.interval()](start)
+}
+
+func (o SearchOptions) interval() string {
+	if ValidFacetInterval(o.Interval) {
+		return o.Interval
+	}
+	return DefaultFacetInterval
+}
+
+func lowerAll(values []string) []string {
+	lowered := make([]string, len(values))
+	for i, value := range values {
+		lowered[i] = strings.ToLower(value)
+	}
+	return lowered
+}
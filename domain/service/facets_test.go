@@ -0,0 +1,150 @@
+package service_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"project/domain/model"
+	"project/domain/searchquery"
+	"project/domain/service"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseFacetDate(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Time
+		err   bool
+	}{
+		{"2001-05-01T00:00:00Z", time.Date(2001, 5, 1, 0, 0, 0, 0, time.UTC), false},
+		{"2001-05-01T02:00:00+02:00", time.Date(2001, 5, 1, 0, 0, 0, 0, time.UTC), false},
+		{"2001-05-01", time.Date(2001, 5, 1, 0, 0, 0, 0, time.UTC), false},
+		{"2001/05/01", time.Time{}, true},
+		{"", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		got, err := service.ParseFacetDate(tt.value)
+		if (err != nil) != tt.err || !got.Equal(tt.want) || got.Location() != time.UTC {
+			t.Errorf("ParseFacetDate(%q) = %v, %v", tt.value, got, err)
+		}
+	}
+}
+
+func TestFacetDateRange(t *testing.T) {
+	start := time.Date(2001, 1, 31, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		interval string
+		end      time.Time
+	}{
+		{"day", time.Date(2001, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"week", time.Date(2001, 2, 7, 0, 0, 0, 0, time.UTC)},
+		{"month", time.Date(2001, 3, 3, 0, 0, 0, 0, time.UTC)},
+		{"quarter", time.Date(2001, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{"year", time.Date(2002, 1, 31, 0, 0, 0, 0, time.UTC)},
+		// Un intervalo desconocido usa el de por defecto
+		{"", time.Date(2001, 3, 3, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		from, to := service.SearchOptions{Interval: tt.interval}.FacetDateRange(start.In(time.FixedZone("", -7*3600)))
+		if !from.Equal(start) || from.Location() != time.UTC || !to.Equal(tt.end) {
+			t.Errorf("FacetDateRange(%q) = %v, %v; se esperaba %v, %v", tt.interval, from, to, start, tt.end)
+		}
+	}
+
+	if !reflect.DeepEqual(service.FacetIntervals(), []string{"day", "month", "quarter", "week", "year"}) {
+		t.Errorf("FacetIntervals() = %v", service.FacetIntervals())
+	}
+	if service.ValidFacetInterval("hour") {
+		t.Error("ValidFacetInterval(\"hour\") = true")
+	}
+}
+
+// Las facetas elegidas se envían a ZincSearch como filtros y las
+// agregaciones de la respuesta se devuelven como facetas.
+func TestZincSearchFacets(t *testing.T) {
+	var request map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/emails/_search" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		w.Write([]byte(`{
+			"hits": {"total": {"value": 0}, "hits": []},
+			"aggregations": {
+				"senders": {"buckets": [{"key": "alice@enron.com", "doc_count": 3}]},
+				"receivers": {"buckets": []},
+				"folders": {"buckets": [{"key": "inbox", "doc_count": 2}, {"key": "sent", "doc_count": 1}]},
+				"dates": {"buckets": [
+					{"key": 986083200000, "key_as_string": "2001-04-01", "doc_count": 2},
+					{"key_as_string": "2001-07-01T00:00:00Z", "doc_count": 1}
+				]}
+			}
+		}`))
+	}))
+	defer server.Close()
+	t.Setenv("ZINC_URL", server.URL+"/api")
+	t.Setenv("ZINC_USERNAME", "admin")
+	t.Setenv("ZINC_PASSWORD", "secreto")
+	t.Setenv("ZINC_INDEX", "emails")
+
+	query, err := searchquery.Parse("contract")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := service.NewZincSearchBackend().Search(service.SearchRequest{
+		Query: query,
+		Size:  10,
+		Options: service.SearchOptions{
+			Facets:    true,
+			FacetSize: 5,
+			Interval:  "quarter",
+			Filters: service.FacetFilters{
+				Senders: []string{"Alice@Enron.com"},
+				Folders: []string{"inbox", "sent"},
+				Dates:   []time.Time{time.Date(2001, 4, 1, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Cada faceta es un filtro; los valores de una misma faceta se combinan con OR
+	var wantFilters interface{}
+	json.Unmarshal([]byte(`[
+		{"term": {"sender_addresses": "alice@enron.com"}},
+		{"bool": {"should": [{"term": {"folder": "inbox"}}, {"term": {"folder": "sent"}}], "minimum_should_match": 1}},
+		{"range": {"date": {"gte": "2001-04-01T00:00:00Z", "lt": "2001-07-01T00:00:00Z"}}}
+	]`), &wantFilters)
+	boolQuery, _ := request["query"].(map[string]interface{})["bool"].(map[string]interface{})
+	if !reflect.DeepEqual(boolQuery["filter"], wantFilters) {
+		t.Errorf("filtros %v, se esperaba %v", boolQuery["filter"], wantFilters)
+	}
+	var wantAggs interface{}
+	json.Unmarshal([]byte(`{
+		"senders": {"terms": {"field": "sender_addresses", "size": 5}},
+		"receivers": {"terms": {"field": "recipients", "size": 5}},
+		"folders": {"terms": {"field": "folder", "size": 5}},
+		"dates": {"date_histogram": {"field": "date", "calendar_interval": "quarter"}}
+	}`), &wantAggs)
+	if !reflect.DeepEqual(request["aggs"], wantAggs) {
+		t.Errorf("agregaciones %v, se esperaba %v", request["aggs"], wantAggs)
+	}
+
+	// Los intervalos de fechas se devuelven en RFC 3339 para usarlos como filtro
+	want := &model.Facets{
+		Senders:   []model.FacetBucket{{Value: "alice@enron.com", Count: 3}},
+		Receivers: []model.FacetBucket{},
+		Folders:   []model.FacetBucket{{Value: "inbox", Count: 2}, {Value: "sent", Count: 1}},
+		Dates: []model.FacetBucket{
+			{Value: "2001-04-01T00:00:00Z", Count: 2},
+			{Value: "2001-07-01T00:00:00Z", Count: 1},
+		},
+	}
+	if !reflect.DeepEqual(result.Facets, want) {
+		t.Errorf("facetas %+v, se esperaba %+v", result.Facets, want)
+	}
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CircuitBreaker deja de usar un servicio después de varios errores seguidos.
+// Pasado el tiempo de espera permite un único intento: si funciona el
+// circuito se cierra, y si falla vuelve a esperar.
+type CircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// NewCircuitBreaker crea un circuito que se abre después de threshold errores
+// seguidos y permanece abierto durante cooldown.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &CircuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// Allow indica si se puede usar el servicio.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return true
+	}
+	if b.probing || time.Now().Before(b.openUntil) {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// Success registra una llamada exitosa y cierra el circuito.
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.probing = false
+}
+
+// Failure registra un error. Devuelve true si el circuito quedó abierto.
+func (b *CircuitBreaker) Failure() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.probing = false
+	if b.failures < b.threshold {
+		return false
+	}
+	b.openUntil = time.Now().Add(b.cooldown)
+	return true
+}
+
+// FailoverSearch busca con el motor principal y, si no está disponible o su
+// circuito está abierto, con el de respaldo.
+type FailoverSearch struct {
+	primary  SearchBackend
+	fallback SearchBackend
+	breaker  *CircuitBreaker
+}
+
+// NewFailoverSearch crea el motor que usa fallback cuando primary no responde.
+func NewFailoverSearch(primary, fallback SearchBackend, breaker *CircuitBreaker) *FailoverSearch {
+	return &FailoverSearch{primary: primary, fallback: fallback, breaker: breaker}
+}
+
+// Name devuelve el nombre del motor principal.
+func (f *FailoverSearch) Name() string {
+	return f.primary.Name()
+}
+
+// Search busca con el motor principal o con el de respaldo. Los cursores se
+// resuelven con el motor que los emitió, para no cambiar de orden a mitad de
+// la paginación: si el principal no está disponible, sus cursores son
+// inválidos y se devuelve ErrInvalidCursor sin consultar el respaldo.
+func (f *FailoverSearch) Search(req SearchRequest) (*SearchResult, error) {
+	if req.After != nil {
+		switch req.After.Backend {
+		case f.fallback.Name():
+			return f.fallback.Search(req)
+		case f.primary.Name():
+			if !f.breaker.Allow() {
+				return nil, ErrInvalidCursor
+			}
+			result, err := f.searchPrimary(req)
+			if errors.Is(err, ErrSearchUnavailable) {
+				return nil, ErrInvalidCursor
+			}
+			return result, err
+		}
+	}
+
+	if f.breaker.Allow() {
+		result, err := f.searchPrimary(req)
+		if !errors.Is(err, ErrSearchUnavailable) {
+			return result, err
+		}
+	}
+	return f.fallback.Search(req)
+}
+
+// searchPrimary busca con el motor principal y registra el resultado en el
+// circuito. Los errores de la consulta (por ejemplo un 4xx de ZincSearch)
+// muestran que el motor responde, por lo que no cuentan como fallas.
+func (f *FailoverSearch) searchPrimary(req SearchRequest) (*SearchResult, error) {
+	result, err := f.primary.Search(req)
+	if !errors.Is(err, ErrSearchUnavailable) {
+		f.breaker.Success()
+		return result, err
+	}
+	fmt.Printf("Error en el motor de búsqueda %s: %v\n", f.primary.Name(), err)
+	if f.breaker.Failure() {
+		fmt.Printf("Motor de búsqueda %s deshabilitado durante %v, se usa %s\n", f.primary.Name(), f.breaker.cooldown, f.fallback.Name())
+	}
+	return nil, err
+}
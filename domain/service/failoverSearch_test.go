@@ -0,0 +1,209 @@
+package service_test
+
+import (
+	"errors"
+	"fmt"
+	"project/domain/service"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerOpensAfterThreshold(t *testing.T) {
+	breaker := service.NewCircuitBreaker(3, time.Hour)
+
+	for i := 1; i <= 2; i++ {
+		if breaker.Failure() {
+			t.Fatalf("el circuito se abrió con %d errores", i)
+		}
+		if !breaker.Allow() {
+			t.Fatalf("Allow() = false con %d errores", i)
+		}
+	}
+	if !breaker.Failure() {
+		t.Fatal("el circuito debería abrirse con 3 errores")
+	}
+	if breaker.Allow() {
+		t.Error("Allow() = true con el circuito abierto")
+	}
+}
+
+func TestCircuitBreakerSuccessResetsFailures(t *testing.T) {
+	breaker := service.NewCircuitBreaker(2, time.Hour)
+
+	breaker.Failure()
+	breaker.Success()
+	if breaker.Failure() {
+		t.Error("los errores anteriores a un éxito no deberían contar")
+	}
+	if !breaker.Allow() {
+		t.Error("Allow() = false con el circuito cerrado")
+	}
+}
+
+func TestCircuitBreakerProbe(t *testing.T) {
+	breaker := service.NewCircuitBreaker(1, 0)
+	breaker.Failure()
+
+	// Pasado el tiempo de espera se permite un único intento
+	if !breaker.Allow() {
+		t.Fatal("Allow() = false pasado el tiempo de espera")
+	}
+	if breaker.Allow() {
+		t.Fatal("se permitió un segundo intento mientras se prueba el servicio")
+	}
+
+	// Si el intento falla el circuito vuelve a abrirse
+	if !breaker.Failure() {
+		t.Fatal("el intento fallido debería reabrir el circuito")
+	}
+	if !breaker.Allow() {
+		t.Fatal("Allow() = false al reabrir con tiempo de espera 0")
+	}
+
+	// Si funciona el circuito se cierra
+	breaker.Success()
+	for i := 0; i < 3; i++ {
+		if !breaker.Allow() {
+			t.Fatal("Allow() = false con el circuito cerrado")
+		}
+	}
+}
+
+// fakeBackend es un motor de búsqueda que devuelve err o un resultado con su nombre.
+type fakeBackend struct {
+	name  string
+	err   error
+	calls int
+}
+
+func (b *fakeBackend) Name() string {
+	return b.name
+}
+
+func (b *fakeBackend) Search(req service.SearchRequest) (*service.SearchResult, error) {
+	b.calls++
+	if b.err != nil {
+		return nil, b.err
+	}
+	return &service.SearchResult{Backend: b.name}, nil
+}
+
+var (
+	errUnavailable = fmt.Errorf("%w: connection refused", service.ErrSearchUnavailable)
+	errBadQuery    = errors.New("consulta rechazada por el motor")
+)
+
+func TestFailoverSearch(t *testing.T) {
+	tests := []struct {
+		name         string
+		primaryErr   error
+		open         bool // El circuito del principal está abierto
+		after        *service.SearchCursor
+		wantBackend  string
+		wantErr      error
+		wantPrimary  int // Llamadas esperadas a cada motor
+		wantFallback int
+	}{
+		{
+			name:        "usa el principal",
+			wantBackend: "zinc",
+			wantPrimary: 1,
+		},
+		{
+			name:         "el principal no está disponible",
+			primaryErr:   errUnavailable,
+			wantBackend:  "embedded",
+			wantPrimary:  1,
+			wantFallback: 1,
+		},
+		{
+			name:        "un error de la consulta no usa el respaldo",
+			primaryErr:  errBadQuery,
+			wantErr:     errBadQuery,
+			wantPrimary: 1,
+		},
+		{
+			name:         "circuito abierto",
+			open:         true,
+			wantBackend:  "embedded",
+			wantFallback: 1,
+		},
+		{
+			name:         "cursor del respaldo",
+			after:        &service.SearchCursor{Backend: "embedded"},
+			wantBackend:  "embedded",
+			wantFallback: 1,
+		},
+		{
+			name:        "cursor del principal",
+			after:       &service.SearchCursor{Backend: "zinc"},
+			wantBackend: "zinc",
+			wantPrimary: 1,
+		},
+		{
+			name:    "cursor del principal con el circuito abierto",
+			open:    true,
+			after:   &service.SearchCursor{Backend: "zinc"},
+			wantErr: service.ErrInvalidCursor,
+		},
+		{
+			name:        "cursor del principal que no está disponible",
+			primaryErr:  errUnavailable,
+			after:       &service.SearchCursor{Backend: "zinc"},
+			wantErr:     service.ErrInvalidCursor,
+			wantPrimary: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary := &fakeBackend{name: "zinc"}
+			fallback := &fakeBackend{name: "embedded"}
+			breaker := service.NewCircuitBreaker(1, time.Hour)
+			if tt.open {
+				breaker.Failure()
+			}
+			primary.err = tt.primaryErr
+
+			result, err := service.NewFailoverSearch(primary, fallback, breaker).Search(service.SearchRequest{After: tt.after})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Search() = %v, se esperaba %v", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("Search() = %v", err)
+			} else if result.Backend != tt.wantBackend {
+				t.Errorf("respondió %s, se esperaba %s", result.Backend, tt.wantBackend)
+			}
+			if primary.calls != tt.wantPrimary || fallback.calls != tt.wantFallback {
+				t.Errorf("llamadas: principal %d, respaldo %d; se esperaba %d y %d",
+					primary.calls, fallback.calls, tt.wantPrimary, tt.wantFallback)
+			}
+		})
+	}
+}
+
+func TestFailoverSearchQueryErrorsDoNotOpenBreaker(t *testing.T) {
+	primary := &fakeBackend{name: "zinc", err: errBadQuery}
+	fallback := &fakeBackend{name: "embedded"}
+	breaker := service.NewCircuitBreaker(2, time.Hour)
+	search := service.NewFailoverSearch(primary, fallback, breaker)
+
+	for i := 0; i < 3; i++ {
+		search.Search(service.SearchRequest{})
+	}
+	if !breaker.Allow() {
+		t.Error("los errores de la consulta abrieron el circuito")
+	}
+
+	primary.err = errUnavailable
+	for i := 0; i < 2; i++ {
+		search.Search(service.SearchRequest{})
+	}
+	if breaker.Allow() {
+		t.Error("el circuito debería abrirse cuando el motor no está disponible")
+	}
+	if fallback.calls != 2 {
+		t.Errorf("el respaldo respondió %d búsquedas, se esperaban 2", fallback.calls)
+	}
+}
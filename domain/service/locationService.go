@@ -0,0 +1,21 @@
+package service
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// MailboxOwner devuelve el dueño del buzón de un archivo: el primer directorio
+// de su ruta dentro de baseDir (por ejemplo usuario1 en usuario1/inbox/1.).
+// Devuelve "" si el archivo no está dentro de un subdirectorio de baseDir.
+func MailboxOwner(baseDir, filePath string) string {
+	rel, err := filepath.Rel(baseDir, filePath)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[0]
+}
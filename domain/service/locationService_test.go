@@ -0,0 +1,25 @@
+package service_test
+
+import (
+	"path/filepath"
+	"project/domain/service"
+	"testing"
+)
+
+func TestMailboxOwner(t *testing.T) {
+	base := filepath.Join("data", "maildir")
+	tests := []struct {
+		filePath string
+		want     string
+	}{
+		{filepath.Join(base, "allen-p", "inbox", "1."), "allen-p"},
+		{filepath.Join(base, "allen-p", "1."), "allen-p"},
+		{filepath.Join(base, "1."), ""},
+		{filepath.Join("data", "otro", "allen-p", "1."), ""},
+	}
+	for _, tt := range tests {
+		if got := service.MailboxOwner(base, tt.filePath); got != tt.want {
+			t.Errorf("MailboxOwner(%q) = %q, se esperaba %q", tt.filePath, got, tt.want)
+		}
+	}
+}
@@ -2,9 +2,17 @@ package service
 
 import (
 	"bufio"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"project/domain/address"
+	"project/domain/header"
+	"project/domain/maildate"
+	"project/domain/mimeparse"
 	"project/domain/model"
 	"strings"
 	"sync"
@@ -20,45 +28,133 @@ func ProcessFile(filePath string, results chan<- model.Email, wg *sync.WaitGroup
 	}
 	defer file.Close()
 
-	// Crear un scanner para leer línea por línea
+	email, err := ParseEmail(file)
+	if err != nil {
+		fmt.Printf("error leyendo archivo %s: %v", filePath, err)
+		return err
+	}
+
+	// Registrar dónde se encontró esta copia del mensaje
+	folder := email.Folder
+	if folder == "" {
+		folder = filepath.Base(filepath.Dir(filePath))
+	}
+	email.Locations = []model.MessageLocation{{Folder: folder, FilePath: filePath}}
+
+	results <- email
+	return nil
+}
+
+// ParseEmail interpreta un mensaje completo: primero el bloque de cabeceras
+// (hasta la primera línea vacía) y luego el cuerpo MIME, del que se guarda
+// el texto decodificado en UTF-8.
+func ParseEmail(r io.Reader) (model.Email, error) {
 	var email model.Email
-	scanner := bufio.NewScanner(file)
+	reader := bufio.NewReader(r)
 
-	// Recorrer las líneas del archivo
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.HasPrefix(line, "Message-ID:") {
-			email.MessageID = strings.TrimSpace(strings.TrimPrefix(line, "Message-ID:"))
-		} else if strings.HasPrefix(line, "Date:") {
-			email.Date = sql.NullString{
-				String: strings.TrimSpace(strings.TrimPrefix(line, "Date:")),
-				Valid:  true,
-			}
-		} else if strings.HasPrefix(line, "From:") {
-			email.Sender = strings.TrimSpace(strings.TrimPrefix(line, "From:"))
-		} else if strings.HasPrefix(line, "To:") {
-			email.Receiver = strings.TrimSpace(strings.TrimPrefix(line, "To:"))
-		} else if strings.HasPrefix(line, "Subject:") {
-			email.Subject = strings.TrimSpace(strings.TrimPrefix(line, "Subject:"))
-		} else if strings.HasPrefix(line, "Mime-Version:") {
-			email.MimeVersion = strings.TrimSpace(strings.TrimPrefix(line, "Mime-Version:"))
-		} else if strings.HasPrefix(line, "Content-Type:") {
-			email.ContentType = strings.TrimSpace(strings.TrimPrefix(line, "Content-Type:"))
-		} else if strings.HasPrefix(line, "Content-Transfer-Encoding:") {
-			email.Encoding = strings.TrimSpace(strings.TrimPrefix(line, "Content-Transfer-Encoding:"))
-		} else if strings.HasPrefix(line, "X-Folder:") {
-			email.Folder = strings.TrimSpace(strings.TrimPrefix(line, "X-Folder:"))
-		} else if len(line) == 0 {
-			// Cuando encontramos una línea vacía, significa que el cuerpo del correo comienza aquí
-			email.Body = scanner.Text()
+	headers, err := header.Parse(reader)
+	if err != nil {
+		return email, err
+	}
+
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return email, fmt.Errorf("error leyendo el cuerpo: %w", err)
+	}
+
+	email.Headers = headers
+	email.MessageID = headers.Get("Message-ID")
+	email.Sender = headers.Get("From")
+	email.SenderName = headers.Get("X-From")
+	email.Receiver = headers.Get("To")
+	email.ReceiverName = headers.Get("X-To")
+	email.Cc = headers.Get("Cc")
+	email.Bcc = headers.Get("Bcc")
+	email.Subject = mimeparse.DecodeHeader(headers.Get("Subject"))
+	email.MimeVersion = headers.Get("Mime-Version")
+	email.ContentType = headers.Get("Content-Type")
+	email.Encoding = headers.Get("Content-Transfer-Encoding")
+	email.Folder = headers.Get("X-Folder")
+	email.Participants = parseParticipants(headers)
+	if ids := header.MessageIDs(headers.Get("In-Reply-To")); len(ids) > 0 {
+		email.InReplyTo = ids[0]
+	}
+	email.References = strings.Join(header.MessageIDs(headers.Get("References")), " ")
+	email.DateRaw = headers.Get("Date")
+	if email.DateRaw != "" {
+		date, err := maildate.Parse(email.DateRaw)
+		if err != nil {
+			// La fecha queda como NULL pero se conserva el valor original
+			fmt.Printf("Fecha inválida en el correo %s: %v\n", email.MessageID, err)
+		} else {
+			email.Date = sql.NullTime{Time: date.UTC(), Valid: true}
+			email.DateOffset = maildate.OffsetMinutes(date)
 		}
 	}
+	email.Body = string(body)
+	email.ContentHash = contentHash(email)
 
-	if err := scanner.Err(); err != nil {
-		fmt.Printf("error leyendo archivo %s: %v", filePath, err)
-		return err
+	// Decodificar el cuerpo MIME; si el mensaje está mal formado se conserva el cuerpo original
+	msg, err := mimeparse.Parse(email.ContentType, email.Encoding, body)
+	if err != nil {
+		fmt.Printf("Error decodificando MIME del correo %s: %v\n", email.MessageID, err)
+		return email, nil
 	}
+	email.Body = msg.Text
+	email.ContentHash = contentHash(email)
 
-	results <- email
-	return nil
+	for _, part := range msg.Attachments() {
+		filename := part.Filename
+		if filename == "" {
+			filename = fmt.Sprintf("adjunto-%d", len(email.Attachments)+1)
+		}
+		email.Attachments = append(email.Attachments, model.Attachment{
+			Filename:    filename,
+			ContentType: part.MediaType,
+			Size:        int64(len(part.Content)),
+			ContentID:   part.ContentID,
+			Content:     part.Content,
+		})
+	}
+
+	return email, nil
+}
+
+// parseParticipants obtiene las direcciones de From, To, Cc y Bcc con su rol.
+func parseParticipants(headers header.Header) []model.Participant {
+	fields := []struct {
+		header string
+		role   string
+	}{
+		{"From", model.RoleFrom},
+		{"To", model.RoleTo},
+		{"Cc", model.RoleCc},
+		{"Bcc", model.RoleBcc},
+	}
+
+	var participants []model.Participant
+	for _, field := range fields {
+		for _, value := range headers.Values(field.header) {
+			for _, addr := range address.ParseList(value) {
+				participants = append(participants, model.Participant{
+					Email: addr.Email,
+					Name:  addr.Name,
+					Role:  field.role,
+				})
+			}
+		}
+	}
+	return participants
+}
+
+// contentHash calcula el hash del cuerpo usado para detectar copias del mismo
+// mensaje. Si el mensaje no tiene Message-ID se incluyen también el remitente y
+// la fecha, para no unir mensajes distintos que solo comparten el cuerpo.
+func contentHash(email model.Email) string {
+	content := email.Body
+	if email.MessageID == "" {
+		content = email.Sender + "\n" + email.DateRaw + "\n" + email.Body
+	}
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
 }
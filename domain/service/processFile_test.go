@@ -0,0 +1,49 @@
+package service_test
+
+import (
+	"project/domain/service"
+	"strings"
+	"testing"
+)
+
+func parse(t *testing.T, message string) string {
+	t.Helper()
+	email, err := service.ParseEmail(strings.NewReader(strings.ReplaceAll(message, "\n", "\r\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return email.ContentHash
+}
+
+// Las copias de un mensaje en distintas carpetas tienen el mismo hash aunque
+// cambien sus cabeceras; sin Message-ID, el remitente y la fecha también cuentan.
+func TestContentHash(t *testing.T) {
+	const inbox = `Message-ID: <1@enron.com>
+From: alice@enron.com
+Date: Mon, 14 May 2001 16:39:00 -0700
+X-Folder: \alice\inbox
+
+Texto del mensaje
+`
+	anonymous := strings.Replace(inbox, "Message-ID: <1@enron.com>\n", "", 1)
+	tests := []struct {
+		name    string
+		base    string
+		message string
+		same    bool
+	}{
+		{"copia en otra carpeta", inbox, strings.Replace(inbox, `\alice\inbox`, `\bob\sent`, 1), true},
+		{"otro cuerpo", inbox, strings.Replace(inbox, "Texto", "Otro texto", 1), false},
+		{"mismo cuerpo con otro Message-ID", inbox, strings.Replace(inbox, "<1@", "<2@", 1), true},
+		{"sin Message-ID en otra carpeta", anonymous, strings.Replace(anonymous, `\alice\inbox`, `\bob\sent`, 1), true},
+		{"sin Message-ID de otro remitente", anonymous, strings.Replace(anonymous, "alice@", "bob@", 1), false},
+		{"sin Message-ID con otra fecha", anonymous, strings.Replace(anonymous, "16:39", "16:40", 1), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if same := parse(t, tt.base) == parse(t, tt.message); same != tt.same {
+				t.Errorf("hash igual = %v, se esperaba %v", same, tt.same)
+			}
+		})
+	}
+}
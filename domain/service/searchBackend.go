@@ -0,0 +1,28 @@
+package service
+
+import (
+	"errors"
+	"project/domain/searchquery"
+)
+
+// ErrSearchUnavailable indica que el motor de búsqueda no respondió o falló
+// por un problema propio, no de la consulta. Solo estos errores cuentan para
+// el CircuitBreaker y hacen que FailoverSearch use el respaldo.
+var ErrSearchUnavailable = errors.New("el motor de búsqueda no está disponible")
+
+// SearchRequest es una búsqueda ya interpretada que se envía al motor de búsqueda.
+type SearchRequest struct {
+	Query   searchquery.Node
+	Options SearchOptions
+	From    int
+	Size    int
+	After   *SearchCursor // Si no es nil se usa en lugar de From; con Before se lee en orden inverso
+}
+
+// SearchBackend es un motor de búsqueda de correos. Los resultados se ordenan
+// por relevancia y luego por id (al revés si el cursor es Before), con los
+// fragmentos resaltados del asunto y el cuerpo y, si se pidieron, las facetas.
+type SearchBackend interface {
+	Name() string
+	Search(req SearchRequest) (*SearchResult, error)
+}
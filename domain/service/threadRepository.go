@@ -0,0 +1,37 @@
+package service
+
+import (
+	"database/sql"
+	"project/domain/model"
+	"project/domain/threading"
+)
+
+// ThreadRecord es un hilo reconstruido listo para guardar.
+type ThreadRecord struct {
+	RootMessageID string       // Message-ID que identifica al hilo entre reconstrucciones
+	Subject       string       // Asunto sin prefijos Re:/Fwd:
+	FirstDate     sql.NullTime // Fecha del primer correo con fecha
+	LastDate      sql.NullTime // Fecha del último correo con fecha
+	EmailIDs      []int        // Correos del hilo
+}
+
+// ThreadRepository guarda y consulta los hilos de conversación.
+// infrastructure/sqlstore lo implementa sobre MySQL o SQLite e
+// infrastructure/memory en memoria, junto a su repositorio de correos.
+type ThreadRepository interface {
+	// Messages devuelve los datos de todos los correos que hacen falta para
+	// agruparlos en hilos, ordenados por ID.
+	Messages() ([]threading.Message, error)
+
+	// Replace guarda los hilos de forma atómica y asigna a cada correo el suyo.
+	// Un hilo que ya existía con el mismo RootMessageID conserva su ID; los que
+	// no están en threads se eliminan.
+	Replace(threads []ThreadRecord) error
+
+	// List devuelve los hilos ordenados por su último correo, del más reciente
+	// al más antiguo, sin sus correos, junto con el total de hilos.
+	List(offset, limit int) ([]model.Thread, int, error)
+
+	// Get devuelve un hilo sin sus correos o nil si no existe.
+	Get(id int) (*model.Thread, error)
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"project/domain/model"
+	"project/domain/threading"
+)
+
+// ThreadService reconstruye y consulta los hilos de conversación.
+type ThreadService struct {
+	threads ThreadRepository
+	emails  EmailRepository
+}
+
+// NewThreadService crea una nueva instancia de ThreadService. Los hilos se
+// guardan en threads y sus correos se leen de emails.
+func NewThreadService(threads ThreadRepository, emails EmailRepository) *ThreadService {
+	return &ThreadService{threads: threads, emails: emails}
+}
+
+// RebuildThreads recorre todos los correos, los agrupa en hilos y guarda el
+// resultado. Los hilos se identifican por el Message-ID de su raíz, por lo que
+// conservan su ID entre ejecuciones. Devuelve la cantidad de hilos.
+func (ts *ThreadService) RebuildThreads() (int, error) {
+	messages, err := ts.threads.Messages()
+	if err != nil {
+		return 0, fmt.Errorf("error al leer los correos: %w", err)
+	}
+
+	threads := threading.Build(messages)
+
+	records := make([]ThreadRecord, len(threads))
+	for i, thread := range threads {
+		rootID := thread.RootMessageID
+		if rootID == "" {
+			rootID = fmt.Sprintf("<email-%d>", thread.Messages[0].ID)
+		}
+
+		ids := make([]int, len(thread.Messages))
+		for j, msg := range thread.Messages {
+			ids[j] = msg.ID
+		}
+
+		first, last := threadDates(thread.Messages)
+		records[i] = ThreadRecord{RootMessageID: rootID, Subject: thread.Subject, FirstDate: first, LastDate: last, EmailIDs: ids}
+	}
+
+	if err := ts.threads.Replace(records); err != nil {
+		return 0, fmt.Errorf("error al guardar los hilos: %w", err)
+	}
+
+	return len(threads), nil
+}
+
+// GetThreadsWithPagination devuelve los hilos ordenados por su último correo.
+func (ts *ThreadService) GetThreadsWithPagination(offset int, limit int) ([]model.Thread, int, error) {
+	threads, total, err := ts.threads.List(offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// El listado no carga los correos de cada hilo
+	for i := range threads {
+		threads[i].Emails = []model.Email{}
+	}
+	if threads == nil {
+		threads = []model.Thread{}
+	}
+
+	return threads, total, nil
+}
+
+// GetThreadByID devuelve un hilo con todos sus correos o nil si no existe.
+func (ts *ThreadService) GetThreadByID(id int) (*model.Thread, error) {
+	thread, err := ts.threads.Get(id)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener el hilo: %w", err)
+	}
+	if thread == nil {
+		return nil, nil
+	}
+
+	thread.Emails, err = ts.emails.List(EmailFilter{ThreadID: id}, EmailSort{Field: "date"}, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los correos del hilo: %w", err)
+	}
+	if thread.Emails == nil {
+		thread.Emails = []model.Email{}
+	}
+
+	return thread, nil
+}
+
+// threadDates devuelve la fecha del primer y del último correo del hilo. Los
+// correos sin fecha no cuentan: Build los ordena primero, pero no son el
+// comienzo de la conversación.
+func threadDates(messages []threading.Message) (first, last sql.NullTime) {
+	for _, msg := range messages {
+		if msg.Date.IsZero() {
+			continue
+		}
+		if !first.Valid || msg.Date.Before(first.Time) {
+			first = sql.NullTime{Time: msg.Date, Valid: true}
+		}
+		if !last.Valid || msg.Date.After(last.Time) {
+			last = sql.NullTime{Time: msg.Date, Valid: true}
+		}
+	}
+	return first, last
+}
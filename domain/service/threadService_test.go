@@ -0,0 +1,130 @@
+package service_test
+
+import (
+	"database/sql"
+	"project/domain/model"
+	"project/domain/service"
+	"project/infrastructure/memory"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func day(d int) sql.NullTime {
+	return sql.NullTime{Time: time.Date(2001, 5, d, 0, 0, 0, 0, time.UTC), Valid: true}
+}
+
+// threadEmail crea un correo de prueba; date inválida es un correo sin fecha.
+func threadEmail(messageID, subject string, date sql.NullTime, inReplyTo, references string) model.Email {
+	return model.Email{
+		MessageID:   messageID,
+		ContentHash: messageID,
+		Subject:     subject,
+		Date:        date,
+		InReplyTo:   inReplyTo,
+		References:  references,
+	}
+}
+
+// threadSummary resume un hilo como raíz, cantidad de correos y fechas.
+type threadSummary struct {
+	Root        string
+	Count       int
+	First, Last sql.NullTime
+}
+
+func summarize(threads []model.Thread) []threadSummary {
+	result := make([]threadSummary, len(threads))
+	for i, thread := range threads {
+		result[i] = threadSummary{thread.RootMessageID, thread.MessageCount, thread.FirstDate, thread.LastDate}
+	}
+	return result
+}
+
+func TestRebuildThreads(t *testing.T) {
+	emails := memory.NewEmailRepository()
+	ts := service.NewThreadService(memory.NewThreadRepository(emails), emails)
+
+	insert := func(batch ...model.Email) {
+		t.Helper()
+		if _, err := emails.Insert(batch); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rebuild := func(want int) {
+		t.Helper()
+		count, err := ts.RebuildThreads()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != want {
+			t.Fatalf("se reconstruyeron %d hilos, se esperaban %d", count, want)
+		}
+	}
+	list := func() []model.Thread {
+		t.Helper()
+		threads, total, err := ts.GetThreadsWithPagination(0, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total != len(threads) {
+			t.Fatalf("total %d, se listaron %d hilos", total, len(threads))
+		}
+		return threads
+	}
+
+	// La respuesta sin fecha no cambia las fechas del hilo
+	insert(
+		threadEmail("<a>", "Presupuesto", day(1), "", ""),
+		threadEmail("<b>", "Re: Presupuesto", day(3), "<a>", ""),
+		threadEmail("<c>", "Re: Presupuesto", sql.NullTime{}, "", "<a> <b>"),
+		threadEmail("<d>", "Reunión", day(2), "", ""),
+	)
+	rebuild(2)
+	first := list()
+	want := []threadSummary{{"<a>", 3, day(1), day(3)}, {"<d>", 1, day(2), day(2)}}
+	if got := summarize(first); !reflect.DeepEqual(got, want) {
+		t.Fatalf("hilos %+v, se esperaba %+v", got, want)
+	}
+
+	thread, err := ts.GetThreadByID(first[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if thread == nil || len(thread.Emails) != 3 {
+		t.Fatalf("GetThreadByID() = %+v, se esperaban 3 correos", thread)
+	}
+	for _, email := range thread.Emails {
+		if email.ThreadID == nil || *email.ThreadID != thread.ID {
+			t.Errorf("el correo %s tiene thread_id %v, se esperaba %d", email.MessageID, email.ThreadID, thread.ID)
+		}
+	}
+
+	// Al reconstruir, los hilos conservan su ID y los que no tienen fecha van al final
+	insert(
+		threadEmail("<e>", "Re: Reunión", day(5), "<d>", ""),
+		threadEmail("<f>", "Suelto", sql.NullTime{}, "", ""),
+	)
+	rebuild(3)
+	second := list()
+	want = []threadSummary{{"<d>", 2, day(2), day(5)}, {"<a>", 3, day(1), day(3)}, {"<f>", 1, sql.NullTime{}, sql.NullTime{}}}
+	if got := summarize(second); !reflect.DeepEqual(got, want) {
+		t.Fatalf("hilos %+v, se esperaba %+v", got, want)
+	}
+	if second[0].ID != first[1].ID || second[1].ID != first[0].ID {
+		t.Errorf("los hilos cambiaron de ID: %d y %d, antes %d y %d", second[0].ID, second[1].ID, first[1].ID, first[0].ID)
+	}
+
+	page, total, err := ts.GetThreadsWithPagination(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 3 || len(page) != 1 || page[0].ID != second[1].ID {
+		t.Errorf("página 2 = %+v (total %d), se esperaba el hilo %d", page, total, second[1].ID)
+	}
+
+	missing, err := ts.GetThreadByID(999)
+	if err != nil || missing != nil {
+		t.Errorf("GetThreadByID(999) = %+v, %v; se esperaba nil", missing, err)
+	}
+}
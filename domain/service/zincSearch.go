@@ -0,0 +1,173 @@
+package service
+
+import (
+	"fmt"
+	"project/domain/model"
+	"project/domain/searchquery"
+	zincSearchClient "project/infrastructure/zincsearch"
+	"project/infrastructure/zincsearch/query"
+	"time"
+)
+
+// Nombres de las agregaciones en la consulta a ZincSearch
+const (
+	aggSenders   = "senders"
+	aggReceivers = "receivers"
+	aggFolders   = "folders"
+	aggDates     = "dates"
+)
+
+// ZincSearchBackend busca en el índice activo de ZincSearch.
+type ZincSearchBackend struct{}
+
+// NewZincSearchBackend crea el motor de búsqueda sobre ZincSearch, configurado con ZINC_URL, ZINC_USERNAME y ZINC_PASSWORD.
+func NewZincSearchBackend() *ZincSearchBackend {
+	return &ZincSearchBackend{}
+}
+
+// Name devuelve el nombre del motor.
+func (b *ZincSearchBackend) Name() string {
+	return "zinc"
+}
+
+// Search ejecuta la búsqueda en ZincSearch.
+func (b *ZincSearchBackend) Search(req SearchRequest) (*SearchResult, error) {
+	search := buildSearch(req)
+
+	client := zincSearchClient.NewZincSearchClient()
+	if client == nil {
+		return nil, fmt.Errorf("%w: ZINC_URL, ZINC_USERNAME o ZINC_PASSWORD no están configurados", ErrSearchUnavailable)
+	}
+	results, err := client.Search(search)
+	if err != nil {
+		if zincSearchClient.IsUnavailable(err) {
+			return nil, fmt.Errorf("%w: %w", ErrSearchUnavailable, err)
+		}
+		return nil, fmt.Errorf("error al buscar en ZincSearch: %w", err)
+	}
+
+	// ZincSearch marca el texto original del correo, que puede traer HTML
+	for _, hit := range results.Hits {
+		for field, fragments := range hit.Highlights {
+			for i, fragment := range fragments {
+				hit.Highlights[field][i] = searchquery.EscapeHighlight(fragment)
+			}
+		}
+	}
+
+	result := &SearchResult{Hits: results.Hits, Total: results.Total, Backend: b.Name()}
+	if req.Options.Facets {
+		result.Facets = facetsFromAggregations(results.Aggregations)
+	}
+	return result, nil
+}
+
+// buildSearch arma la consulta de ZincSearch con resaltado, orden, filtros de facetas y agregaciones.
+func buildSearch(req SearchRequest) query.Search {
+	search := query.Search{
+		Query: searchquery.Compile(req.Query),
+		From:  req.From,
+		Size:  req.Size,
+		Sort:  []query.Sort{query.Desc("_score"), query.Asc("id")},
+		Highlight: &query.Highlight{
+			PreTags:  []string{searchquery.HighlightPreTag},
+			PostTags: []string{searchquery.HighlightPostTag},
+			Fields: map[string]query.HighlightField{
+				"subject": {NumberOfFragments: 1},
+				"body":    {FragmentSize: highlightFragmentSize, NumberOfFragments: 3},
+			},
+		},
+	}
+	if cursor := req.After; cursor != nil {
+		search.From = 0
+		if cursor.Before {
+			search.Sort = []query.Sort{query.Asc("_score"), query.Desc("id")}
+		}
+		search.SearchAfter = []interface{}{cursor.Score, cursor.ID}
+	}
+	if filters := req.Options.filterQueries(); len(filters) > 0 {
+		search.Query = query.BoolQuery{Must: []query.Query{search.Query}, Filter: filters}
+	}
+	if req.Options.Facets {
+		search.Aggs = req.Options.aggregations()
+	}
+	return search
+}
+
+// filterQueries convierte las facetas elegidas en filtros de la consulta.
+func (o SearchOptions) filterQueries() []query.Query {
+	var filters []query.Query
+	addFilter := func(queries []query.Query) {
+		switch len(queries) {
+		case 0:
+		case 1:
+			filters = append(filters, queries[0])
+		default:
+			filters = append(filters, query.AnyOf(queries...))
+		}
+	}
+
+	terms := func(field string, values []string) []query.Query {
+		queries := make([]query.Query, len(values))
+		for i, value := range values {
+			queries[i] = query.Term(field, value)
+		}
+		return queries
+	}
+
+	addFilter(terms("sender_addresses", lowerAll(o.Filters.Senders)))
+	addFilter(terms("recipients", lowerAll(o.Filters.Receivers)))
+	addFilter(terms("folder", o.Filters.Folders))
+
+	dates := make([]query.Query, len(o.Filters.Dates))
+	for i, start := range o.Filters.Dates {
+		from, to := o.FacetDateRange(start)
+		dates[i] = query.RangeQuery{
+			Field: "date",
+			Gte:   from.Format(time.RFC3339),
+			Lt:    to.Format(time.RFC3339),
+		}
+	}
+	addFilter(dates)
+
+	return filters
+}
+
+// aggregations devuelve las agregaciones que calculan las facetas.
+func (o SearchOptions) aggregations() map[string]query.Aggregation {
+	return map[string]query.Aggregation{
+		aggSenders:   query.Terms("sender_addresses", o.FacetSize),
+		aggReceivers: query.Terms("recipients", o.FacetSize),
+		aggFolders:   query.Terms("folder", o.FacetSize),
+		aggDates:     query.DateHistogram("date", o.interval()),
+	}
+}
+
+// facetsFromAggregations convierte las agregaciones de ZincSearch en facetas.
+func facetsFromAggregations(aggregations map[string][]zincSearchClient.AggregationBucket) *model.Facets {
+	return &model.Facets{
+		Senders:   facetBuckets(aggregations[aggSenders], false),
+		Receivers: facetBuckets(aggregations[aggReceivers], false),
+		Folders:   facetBuckets(aggregations[aggFolders], false),
+		Dates:     facetBuckets(aggregations[aggDates], true),
+	}
+}
+
+func facetBuckets(buckets []zincSearchClient.AggregationBucket, dates bool) []model.FacetBucket {
+	facets := make([]model.FacetBucket, 0, len(buckets))
+	for _, bucket := range buckets {
+		value := bucket.KeyAsString
+		if dates {
+			// El inicio del intervalo se devuelve siempre en RFC 3339 para poder usarlo como filtro
+			if ms, ok := bucket.Key.(float64); ok {
+				value = time.UnixMilli(int64(ms)).UTC().Format(time.RFC3339)
+			} else if t, err := ParseFacetDate(value); err == nil {
+				value = t.Format(time.RFC3339)
+			}
+		} else if value == "" {
+			value = fmt.Sprint(bucket.Key)
+		}
+		facets = append(facets, model.FacetBucket{Value: value, Count: bucket.DocCount})
+	}
+	return facets
+}
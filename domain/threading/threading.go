@@ -0,0 +1,296 @@
+package threading
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Message es la información mínima de un correo necesaria para agruparlo en hilos.
+type Message struct {
+	ID         int       // ID del correo en la base de datos
+	MessageID  string    // Message-ID
+	References []string  // Identificadores de References seguidos de In-Reply-To
+	Subject    string    // Asunto original
+	Date       time.Time // Fecha del correo (cero si se desconoce)
+}
+
+// Thread es una conversación reconstruida.
+type Thread struct {
+	RootMessageID string    // Message-ID que identifica de forma estable al hilo
+	Subject       string    // Asunto normalizado, sin prefijos Re:/Fwd:
+	Messages      []Message // Mensajes ordenados por fecha
+}
+
+var replyPrefixRe = regexp.MustCompile(`(?i)^\s*((re|fw|fwd|rv|aw|tr)(\[\d+\])?\s*:\s*)+`)
+
+// NormalizeSubject elimina los prefijos de respuesta y reenvío de un asunto.
+func NormalizeSubject(subject string) string {
+	return strings.TrimSpace(replyPrefixRe.ReplaceAllString(subject, ""))
+}
+
+// IsReply indica si el asunto tiene un prefijo de respuesta o reenvío.
+func IsReply(subject string) bool {
+	return replyPrefixRe.MatchString(subject)
+}
+
+// ReferenceChain devuelve los identificadores de la cabecera References
+// seguidos de In-Reply-To, si no es ya el último, como espera Message.References.
+func ReferenceChain(references, inReplyTo string) []string {
+	chain := strings.Fields(references)
+	if inReplyTo != "" && (len(chain) == 0 || chain[len(chain)-1] != inReplyTo) {
+		chain = append(chain, inReplyTo)
+	}
+	return chain
+}
+
+// container es un nodo del árbol de hilos del algoritmo de JWZ. Un contenedor
+// sin mensaje representa un correo referenciado que no está en el corpus.
+type container struct {
+	id       string
+	message  *Message
+	parent   *container
+	children []*container
+}
+
+func (c *container) hasDescendant(other *container) bool {
+	for _, child := range c.children {
+		if child == other || child.hasDescendant(other) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *container) removeChild(child *container) {
+	for i, current := range c.children {
+		if current == child {
+			c.children = append(c.children[:i], c.children[i+1:]...)
+			break
+		}
+	}
+	child.parent = nil
+}
+
+func (c *container) addChild(child *container) {
+	if child.parent != nil {
+		child.parent.removeChild(child)
+	}
+	child.parent = c
+	c.children = append(c.children, child)
+}
+
+// subject devuelve el asunto del contenedor o, si está vacío, el de su primer hijo.
+func (c *container) subject() (string, bool) {
+	if c.message != nil {
+		return c.message.Subject, true
+	}
+	if len(c.children) > 0 && c.children[0].message != nil {
+		return c.children[0].message.Subject, true
+	}
+	return "", false
+}
+
+// Build agrupa los mensajes en hilos con el algoritmo de Jamie Zawinski
+// (https://www.jwz.org/doc/threading.html). Los mensajes que no se pueden
+// enlazar por References/In-Reply-To se agrupan por asunto solo cuando uno
+// de ellos es una respuesta o reenvío ("Re:", "Fwd:").
+func Build(messages []Message) []Thread {
+	table := make(map[string]*container)
+	var order []string
+
+	get := func(id string) *container {
+		c, ok := table[id]
+		if !ok {
+			c = &container{id: id}
+			table[id] = c
+			order = append(order, id)
+		}
+		return c
+	}
+
+	for i := range messages {
+		msg := &messages[i]
+
+		id := msg.MessageID
+		if original := table[id]; id != "" && original != nil && original.message != nil {
+			// Las copias de un mismo mensaje (por ejemplo en otra carpeta) quedan en su hilo
+			dup := get(fmt.Sprintf("\x00%d", i))
+			dup.message = msg
+			original.addChild(dup)
+			continue
+		}
+		if id == "" {
+			// Los mensajes sin Message-ID reciben un identificador propio
+			id = fmt.Sprintf("\x00%d", i)
+		}
+		c := get(id)
+		c.message = msg
+
+		// Enlazar la cadena de referencias: cada una es padre de la siguiente
+		var prev *container
+		for _, ref := range msg.References {
+			if ref == msg.MessageID {
+				continue
+			}
+			refContainer := get(ref)
+			if prev != nil && refContainer.parent == nil && prev != refContainer &&
+				!refContainer.hasDescendant(prev) {
+				prev.addChild(refContainer)
+			}
+			prev = refContainer
+		}
+
+		// El último elemento de References es el padre del mensaje
+		if c.parent != nil {
+			c.parent.removeChild(c)
+		}
+		if prev != nil && prev != c && !c.hasDescendant(prev) {
+			prev.addChild(c)
+		}
+	}
+
+	// Conjunto raíz: contenedores sin padre, podando los vacíos
+	var roots []*container
+	for _, id := range order {
+		c := table[id]
+		if c.parent == nil {
+			roots = append(roots, c)
+		}
+	}
+	roots = pruneRoots(roots)
+
+	// Agrupar por asunto los hilos de respuestas cuyo original no se encontró
+	roots = groupBySubject(roots)
+
+	threads := make([]Thread, 0, len(roots))
+	for _, root := range roots {
+		thread := Thread{}
+		collect(root, &thread.Messages)
+		if len(thread.Messages) == 0 {
+			continue
+		}
+		sort.SliceStable(thread.Messages, func(i, j int) bool {
+			return thread.Messages[i].Date.Before(thread.Messages[j].Date)
+		})
+
+		if rootSubject, ok := root.subject(); ok {
+			thread.Subject = NormalizeSubject(rootSubject)
+		}
+		// La raíz se identifica por su Message-ID, aunque sea un mensaje
+		// referenciado que no está en el corpus
+		if !strings.HasPrefix(root.id, "\x00") {
+			thread.RootMessageID = root.id
+		} else if root.message != nil {
+			thread.RootMessageID = root.message.MessageID
+		}
+		if thread.RootMessageID == "" {
+			thread.RootMessageID = firstMessageID(thread.Messages)
+		}
+		threads = append(threads, thread)
+	}
+
+	return threads
+}
+
+// pruneRoots elimina los contenedores vacíos: si no tienen hijos se descartan
+// y si tienen uno solo se reemplazan por él.
+func pruneRoots(roots []*container) []*container {
+	var result []*container
+	for _, root := range roots {
+		pruneChildren(root)
+		if root.message == nil {
+			switch len(root.children) {
+			case 0:
+				continue
+			case 1:
+				child := root.children[0]
+				root.removeChild(child)
+				result = append(result, child)
+				continue
+			}
+		}
+		result = append(result, root)
+	}
+	return result
+}
+
+func pruneChildren(c *container) {
+	var children []*container
+	for _, child := range c.children {
+		pruneChildren(child)
+		if child.message == nil {
+			// Los hijos de un contenedor vacío pasan a depender de su padre
+			for _, grandchild := range child.children {
+				grandchild.parent = c
+			}
+			children = append(children, child.children...)
+			continue
+		}
+		children = append(children, child)
+	}
+	c.children = children
+}
+
+func groupBySubject(roots []*container) []*container {
+	bySubject := make(map[string]*container)
+	var result []*container
+
+	for _, root := range roots {
+		subject, ok := root.subject()
+		normalized := strings.ToLower(NormalizeSubject(subject))
+		if !ok || normalized == "" {
+			result = append(result, root)
+			continue
+		}
+
+		existing, found := bySubject[normalized]
+		if !found {
+			bySubject[normalized] = root
+			result = append(result, root)
+			continue
+		}
+
+		existingSubject, _ := existing.subject()
+		switch {
+		case IsReply(subject):
+			// Este hilo responde a otro con el mismo asunto
+			existing.addChild(root)
+		case IsReply(existingSubject):
+			// El hilo anterior era la respuesta: el actual pasa a ser la raíz
+			root.addChild(existing)
+			bySubject[normalized] = root
+			for i, r := range result {
+				if r == existing {
+					result[i] = root
+					break
+				}
+			}
+		default:
+			// Dos mensajes originales con el mismo asunto son hilos distintos
+			result = append(result, root)
+		}
+	}
+
+	return result
+}
+
+func collect(c *container, messages *[]Message) {
+	if c.message != nil {
+		*messages = append(*messages, *c.message)
+	}
+	for _, child := range c.children {
+		collect(child, messages)
+	}
+}
+
+func firstMessageID(messages []Message) string {
+	for _, msg := range messages {
+		if msg.MessageID != "" {
+			return msg.MessageID
+		}
+	}
+	return ""
+}
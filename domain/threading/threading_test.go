@@ -0,0 +1,168 @@
+package threading
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// msg crea un mensaje de prueba. day ordena los mensajes por fecha.
+func msg(id int, messageID, subject string, day int, refs ...string) Message {
+	return Message{
+		ID:         id,
+		MessageID:  messageID,
+		References: refs,
+		Subject:    subject,
+		Date:       time.Date(2001, 5, day, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+// summary resume un hilo como "raíz|asunto|ids", para comparar sin depender
+// del orden de los hilos.
+func summary(threads []Thread) []string {
+	var result []string
+	for _, thread := range threads {
+		ids := make([]string, len(thread.Messages))
+		for i, m := range thread.Messages {
+			ids[i] = strings.TrimPrefix(strings.TrimSuffix(m.MessageID, ">"), "<")
+			if ids[i] == "" {
+				ids[i] = "?"
+			}
+		}
+		result = append(result, thread.RootMessageID+"|"+thread.Subject+"|"+strings.Join(ids, ","))
+	}
+	sort.Strings(result)
+	return result
+}
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []Message
+		want     []string
+	}{
+		{
+			name: "respuestas encadenadas por References",
+			messages: []Message{
+				msg(3, "<c>", "Re: Re: Presupuesto", 3, "<a>", "<b>"),
+				msg(1, "<a>", "Presupuesto", 1),
+				msg(2, "<b>", "Re: Presupuesto", 2, "<a>"),
+			},
+			want: []string{"<a>|Presupuesto|a,b,c"},
+		},
+		{
+			name: "raíz que no está en el corpus",
+			messages: []Message{
+				msg(1, "<b>", "Re: Viaje", 2, "<a>"),
+				msg(2, "<c>", "Re: Viaje", 3, "<a>"),
+			},
+			want: []string{"<a>|Viaje|b,c"},
+		},
+		{
+			name: "un único hijo de una raíz ausente la reemplaza",
+			messages: []Message{
+				msg(1, "<b>", "Re: Viaje", 2, "<a>"),
+			},
+			want: []string{"<b>|Viaje|b"},
+		},
+		{
+			name: "respuesta sin References agrupada por asunto",
+			messages: []Message{
+				msg(1, "<a>", "Reunión", 1),
+				msg(2, "<b>", "RE: reunión", 2),
+				msg(3, "<c>", "Fwd: Re: Reunión", 3),
+			},
+			want: []string{"<a>|Reunión|a,b,c"},
+		},
+		{
+			name: "la respuesta llega antes que el original",
+			messages: []Message{
+				msg(2, "<b>", "Re: Informe", 2),
+				msg(1, "<a>", "Informe", 1),
+			},
+			want: []string{"<a>|Informe|a,b"},
+		},
+		{
+			name: "originales con el mismo asunto son hilos distintos",
+			messages: []Message{
+				msg(1, "<a>", "Hola", 1),
+				msg(2, "<b>", "Hola", 2),
+			},
+			want: []string{"<a>|Hola|a", "<b>|Hola|b"},
+		},
+		{
+			name: "copias del mismo Message-ID quedan en el mismo hilo",
+			messages: []Message{
+				msg(1, "<a>", "Aviso", 1),
+				msg(2, "<a>", "Aviso", 1),
+			},
+			want: []string{"<a>|Aviso|a,a"},
+		},
+		{
+			name: "mensaje sin Message-ID",
+			messages: []Message{
+				msg(1, "", "Sin identificador", 1),
+			},
+			want: []string{"|Sin identificador|?"},
+		},
+		{
+			name: "referencias circulares",
+			messages: []Message{
+				msg(1, "<a>", "Ciclo", 1, "<b>"),
+				msg(2, "<b>", "Re: Ciclo", 2, "<a>"),
+			},
+			want: []string{"<b>|Ciclo|a,b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := summary(Build(tt.messages))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Build() = %q, se esperaba %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeSubject(t *testing.T) {
+	tests := []struct {
+		subject string
+		want    string
+		reply   bool
+	}{
+		{"Presupuesto", "Presupuesto", false},
+		{"Re: Presupuesto", "Presupuesto", true},
+		{"RE: Fw: re[2]: Presupuesto", "Presupuesto", true},
+		{"  fwd :Presupuesto ", "Presupuesto", true},
+		{"Reunión", "Reunión", false},
+	}
+	for _, tt := range tests {
+		if got := NormalizeSubject(tt.subject); got != tt.want {
+			t.Errorf("NormalizeSubject(%q) = %q, se esperaba %q", tt.subject, got, tt.want)
+		}
+		if got := IsReply(tt.subject); got != tt.reply {
+			t.Errorf("IsReply(%q) = %v", tt.subject, got)
+		}
+	}
+}
+
+func TestReferenceChain(t *testing.T) {
+	tests := []struct {
+		references string
+		inReplyTo  string
+		want       []string
+	}{
+		{"", "", []string{}},
+		{"", "<a>", []string{"<a>"}},
+		{"<a> <b>", "<b>", []string{"<a>", "<b>"}},
+		{"<a>\n <b>", "<c>", []string{"<a>", "<b>", "<c>"}},
+	}
+	for _, tt := range tests {
+		if got := ReferenceChain(tt.references, tt.inReplyTo); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ReferenceChain(%q, %q) = %q, se esperaba %q", tt.references, tt.inReplyTo, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package blobstore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Directorio usado cuando ATTACHMENTS_DIR no está definida
+const defaultDir = "./data/attachments"
+
+// Store guarda contenidos en disco direccionados por su hash SHA-256.
+// Dos adjuntos idénticos comparten el mismo archivo.
+type Store struct {
+	dir string
+}
+
+// NewStore crea el almacén en el directorio indicado por ATTACHMENTS_DIR.
+func NewStore() (*Store, error) {
+	dir := os.Getenv("ATTACHMENTS_DIR")
+	if dir == "" {
+		dir = defaultDir
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creando el directorio de adjuntos %s: %w", dir, err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Hash devuelve el hash hexadecimal con el que se guardaría el contenido.
+func Hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// Put guarda el contenido y devuelve su hash. Si ya existe no se vuelve a escribir.
+func (s *Store) Put(content []byte) (string, error) {
+	hash := Hash(content)
+	path := s.Path(hash)
+
+	if _, err := os.Stat(path); err == nil {
+		return hash, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("error creando directorio para %s: %w", hash, err)
+	}
+
+	// Se escribe en un archivo temporal y se renombra para no dejar blobs a medias
+	tmp, err := os.CreateTemp(filepath.Dir(path), hash+".tmp-*")
+	if err != nil {
+		return "", fmt.Errorf("error creando archivo temporal: %w", err)
+	}
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("error escribiendo adjunto %s: %w", hash, err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("error cerrando adjunto %s: %w", hash, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("error guardando adjunto %s: %w", hash, err)
+	}
+
+	return hash, nil
+}
+
+// Path devuelve la ruta del archivo correspondiente a un hash. Los archivos se
+// reparten en subdirectorios con los dos primeros caracteres del hash.
+func (s *Store) Path(hash string) string {
+	if len(hash) < 2 {
+		return filepath.Join(s.dir, hash)
+	}
+	return filepath.Join(s.dir, hash[:2], hash)
+}
+
+// Open abre el contenido guardado con el hash indicado.
+func (s *Store) Open(hash string) (*os.File, error) {
+	file, err := os.Open(s.Path(hash))
+	if err != nil {
+		return nil, fmt.Errorf("error abriendo adjunto %s: %w", hash, err)
+	}
+	return file, nil
+}
@@ -0,0 +1,103 @@
+package blobstore
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "adjuntos")
+	t.Setenv("ATTACHMENTS_DIR", dir)
+	store, err := NewStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		t.Fatalf("NewStore() no creó el directorio %s: %v", dir, err)
+	}
+	return store
+}
+
+func TestPutAndOpen(t *testing.T) {
+	store := newTestStore(t)
+	content := []byte("contenido del adjunto")
+
+	hash, err := store.Put(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// SHA-256 de "contenido del adjunto"
+	const want = "6def1c2b14eecdce6934d234da1cdd66d7fa6be74f733e59b16a9b7590d7f781"
+	if hash != want || Hash(content) != want {
+		t.Fatalf("Put() = %q, se esperaba %q", hash, want)
+	}
+	if want := filepath.Join(store.dir, hash[:2], hash); store.Path(hash) != want {
+		t.Errorf("Path() = %q, se esperaba %q", store.Path(hash), want)
+	}
+
+	file, err := store.Open(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	got, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(content) {
+		t.Errorf("Open() leyó %q, se esperaba %q", got, content)
+	}
+
+	// No quedan archivos temporales junto al blob
+	entries, err := os.ReadDir(filepath.Dir(store.Path(hash)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("el directorio tiene %d archivos, se esperaba solo el blob", len(entries))
+	}
+}
+
+// Put no vuelve a escribir un contenido que ya está guardado.
+func TestPutIsIdempotent(t *testing.T) {
+	store := newTestStore(t)
+	content := []byte("mismo adjunto en dos correos")
+
+	hash, err := store.Put(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Si Put reescribiera el archivo, la marca desaparecería
+	if err := os.WriteFile(store.Path(hash), []byte("marca"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	again, err := store.Put(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != hash {
+		t.Errorf("Put() = %q la segunda vez, se esperaba %q", again, hash)
+	}
+	if got, _ := os.ReadFile(store.Path(hash)); string(got) != "marca" {
+		t.Errorf("Put() reescribió el blob: %q", got)
+	}
+}
+
+func TestOpenMissing(t *testing.T) {
+	store := newTestStore(t)
+	_, err := store.Open(Hash([]byte("nunca guardado")))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Open() = %v, se esperaba os.ErrNotExist", err)
+	}
+}
+
+func TestPathShortHash(t *testing.T) {
+	store := &Store{dir: "adjuntos"}
+	if got := store.Path("a"); got != filepath.Join("adjuntos", "a") {
+		t.Errorf("Path(%q) = %q", "a", got)
+	}
+}
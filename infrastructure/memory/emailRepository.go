@@ -0,0 +1,340 @@
+// Package memory implementa los repositorios en memoria, para probar los
+// servicios, los controladores y la ingesta sin una base de datos.
+package memory
+
+import (
+	"project/domain/model"
+	"project/domain/service"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type messageKey struct {
+	messageID   string
+	contentHash string
+}
+
+// EmailRepository implementa service.EmailRepository guardando los correos en
+// memoria. Filtra y ordena igual que la implementación de MySQL: los textos
+// se ordenan sin distinguir mayúsculas, como con la intercalación de la base.
+type EmailRepository struct {
+	mu             sync.RWMutex
+	emails         map[int]model.Email
+	byKey          map[messageKey]int
+	contacts       map[string]int
+	filePaths      map[string]bool
+	nextID         int
+	nextAttachment int
+	nextLocation   int
+}
+
+// NewEmailRepository crea un repositorio vacío.
+func NewEmailRepository() *EmailRepository {
+	return &EmailRepository{
+		emails:    make(map[int]model.Email),
+		byKey:     make(map[messageKey]int),
+		contacts:  make(map[string]int),
+		filePaths: make(map[string]bool),
+	}
+}
+
+// List devuelve los correos que cumplen el filtro en el orden indicado.
+func (r *EmailRepository) List(filter service.EmailFilter, order service.EmailSort, offset, limit int) ([]model.Email, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	emails := r.matching(filter, nil)
+	sortEmails(emails, order)
+	if limit <= 0 {
+		return emails, nil
+	}
+	if offset >= len(emails) {
+		return nil, nil
+	}
+	emails = emails[offset:]
+	if len(emails) > limit {
+		emails = emails[:limit]
+	}
+	return emails, nil
+}
+
+// ListAfter devuelve los correos que siguen al cursor en su orden.
+func (r *EmailRepository) ListAfter(filter service.EmailFilter, cursor service.Cursor, limit int) ([]model.Email, error) {
+	order := cursor.ReadOrder()
+
+	// El correo del cursor con los valores que tenía al crearlo
+	position := model.Email{ID: cursor.ID}
+	if cursor.Value != nil {
+		switch order.Field {
+		case "date":
+			date, err := cursor.Date()
+			if err != nil {
+				return nil, err
+			}
+			position.Date.Time, position.Date.Valid = date, true
+		case "sender":
+			position.Sender = *cursor.Value
+		case "subject":
+			position.Subject = *cursor.Value
+		}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	emails := r.matching(filter, func(email model.Email) bool {
+		return compareEmails(email, position, order) > 0
+	})
+	sortEmails(emails, order)
+	if len(emails) > limit {
+		emails = emails[:limit]
+	}
+	return emails, nil
+}
+
+// Count devuelve la cantidad de correos que cumplen el filtro.
+func (r *EmailRepository) Count(filter service.EmailFilter) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.matching(filter, nil)), nil
+}
+
+// Get devuelve una copia del correo o nil si no existe.
+func (r *EmailRepository) Get(id int) (*model.Email, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	email, ok := r.emails[id]
+	if !ok {
+		return nil, nil
+	}
+	email = copyEmail(email)
+	return &email, nil
+}
+
+// Attachments devuelve los adjuntos de un correo.
+func (r *EmailRepository) Attachments(emailID int) ([]model.Attachment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]model.Attachment{}, r.emails[emailID].Attachments...), nil
+}
+
+// Attachment devuelve un adjunto concreto de un correo o nil si no existe.
+func (r *EmailRepository) Attachment(emailID int, attachmentID int) (*model.Attachment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, attachment := range r.emails[emailID].Attachments {
+		if attachment.ID == attachmentID {
+			return &attachment, nil
+		}
+	}
+	return nil, nil
+}
+
+// Insert guarda los correos nuevos del lote y suma las ubicaciones de los que ya estaban.
+func (r *EmailRepository) Insert(emails []model.Email) (service.InsertResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result service.InsertResult
+	for i := range emails {
+		key := messageKey{emails[i].MessageID, emails[i].ContentHash}
+		if id, ok := r.byKey[key]; ok {
+			emails[i].ID = id
+			r.addLocations(id, emails[i].Locations)
+			result.Duplicates++
+			continue
+		}
+		emails[i].ID = r.create(emails[i])
+		result.Created = append(result.Created, emails[i])
+	}
+	return result, nil
+}
+
+// create guarda un correo nuevo y devuelve su ID.
+func (r *EmailRepository) create(email model.Email) int {
+	r.nextID++
+	email.ID = r.nextID
+	r.byKey[messageKey{email.MessageID, email.ContentHash}] = email.ID
+	locations := email.Locations
+	email.Locations = nil
+	r.store(email)
+	r.addLocations(email.ID, locations)
+	return email.ID
+}
+
+// store guarda una copia del correo asignando IDs a sus adjuntos y contactos.
+// No guarda el contenido de los adjuntos, igual que la base de datos.
+func (r *EmailRepository) store(email model.Email) {
+	stored := copyEmail(email)
+	for i := range stored.Attachments {
+		r.nextAttachment++
+		stored.Attachments[i].ID = r.nextAttachment
+		stored.Attachments[i].EmailID = email.ID
+		stored.Attachments[i].Content = nil
+	}
+	for i := range stored.Participants {
+		address := strings.ToLower(stored.Participants[i].Email)
+		contactID, ok := r.contacts[address]
+		if !ok {
+			contactID = len(r.contacts) + 1
+			r.contacts[address] = contactID
+		}
+		stored.Participants[i].ContactID = contactID
+		stored.Participants[i].Email = address
+	}
+	r.emails[email.ID] = stored
+}
+
+// addLocations suma las ubicaciones cuyo archivo no estaba registrado.
+func (r *EmailRepository) addLocations(id int, locations []model.MessageLocation) {
+	email := r.emails[id]
+	for _, location := range locations {
+		if r.filePaths[location.FilePath] {
+			continue
+		}
+		r.filePaths[location.FilePath] = true
+		r.nextLocation++
+		location.ID = r.nextLocation
+		location.EmailID = id
+		email.Locations = append(email.Locations, location)
+	}
+	r.emails[id] = email
+}
+
+// matching devuelve copias de los correos que cumplen el filtro y la condición extra, si la hay.
+func (r *EmailRepository) matching(filter service.EmailFilter, extra func(model.Email) bool) []model.Email {
+	var emails []model.Email
+	for _, email := range r.emails {
+		if matches(email, filter) && (extra == nil || extra(email)) {
+			emails = append(emails, copyEmail(email))
+		}
+	}
+	return emails
+}
+
+// matches indica si el correo cumple el filtro.
+func matches(email model.Email, f service.EmailFilter) bool {
+	if f.Participant != "" {
+		var roles []string
+		if f.Role != "" {
+			roles = []string{f.Role}
+		}
+		if !hasParticipant(email, f.Participant, roles...) {
+			return false
+		}
+	}
+	if f.Sender != "" && !hasParticipant(email, f.Sender, model.RoleFrom) {
+		return false
+	}
+	if f.Receiver != "" && !hasParticipant(email, f.Receiver, model.RoleTo, model.RoleCc, model.RoleBcc) {
+		return false
+	}
+	if f.Folder != "" && !hasLocation(email, func(l model.MessageLocation) bool {
+		return l.Folder == f.Folder || strings.HasSuffix(l.Folder, `\`+f.Folder) || strings.HasSuffix(l.Folder, "/"+f.Folder)
+	}) {
+		return false
+	}
+	if f.Owner != "" && !hasLocation(email, func(l model.MessageLocation) bool { return l.Owner == f.Owner }) {
+		return false
+	}
+	if !f.DateFrom.IsZero() && (!email.Date.Valid || email.Date.Time.Before(f.DateFrom)) {
+		return false
+	}
+	if !f.DateTo.IsZero() && (!email.Date.Valid || !email.Date.Time.Before(f.DateTo)) {
+		return false
+	}
+	if f.HasBody != nil && (email.Body != "") != *f.HasBody {
+		return false
+	}
+	if f.ContentType != "" && !strings.HasPrefix(strings.ToLower(email.ContentType), strings.ToLower(f.ContentType)) {
+		return false
+	}
+	if f.ThreadID != 0 && (email.ThreadID == nil || *email.ThreadID != f.ThreadID) {
+		return false
+	}
+	return true
+}
+
+func hasParticipant(email model.Email, address string, roles ...string) bool {
+	for _, participant := range email.Participants {
+		if !strings.EqualFold(participant.Email, address) {
+			continue
+		}
+		if len(roles) == 0 {
+			return true
+		}
+		for _, role := range roles {
+			if participant.Role == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasLocation(email model.Email, match func(model.MessageLocation) bool) bool {
+	for _, location := range email.Locations {
+		if match(location) {
+			return true
+		}
+	}
+	return false
+}
+
+// sortEmails ordena los correos desempatando por id, como la implementación de MySQL.
+func sortEmails(emails []model.Email, order service.EmailSort) {
+	sort.Slice(emails, func(i, j int) bool {
+		return compareEmails(emails[i], emails[j], order) < 0
+	})
+}
+
+// compareEmails compara dos correos en el orden indicado. Los NULL van antes
+// que cualquier valor en orden ascendente y los textos se comparan sin
+// distinguir mayúsculas, como con la intercalación de MySQL.
+func compareEmails(a, b model.Email, order service.EmailSort) int {
+	result := 0
+	switch order.Field {
+	case "date":
+		result = compareDates(a.Date.Time, a.Date.Valid, b.Date.Time, b.Date.Valid)
+	case "sender":
+		result = compareFold(a.Sender, b.Sender)
+	case "subject":
+		result = compareFold(a.Subject, b.Subject)
+	}
+	if result == 0 {
+		result = a.ID - b.ID
+	}
+	if order.Desc {
+		return -result
+	}
+	return result
+}
+
+// compareFold compara dos textos sin distinguir mayúsculas.
+func compareFold(a, b string) int {
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+func compareDates(a time.Time, aValid bool, b time.Time, bValid bool) int {
+	switch {
+	case !aValid && !bValid:
+		return 0
+	case !aValid:
+		return -1
+	case !bValid:
+		return 1
+	}
+	return a.Compare(b)
+}
+
+// copyEmail copia las listas del correo para que quien lo recibe no modifique el repositorio.
+func copyEmail(email model.Email) model.Email {
+	email.Attachments = append([]model.Attachment(nil), email.Attachments...)
+	email.Participants = append([]model.Participant(nil), email.Participants...)
+	email.Locations = append([]model.MessageLocation(nil), email.Locations...)
+	return email
+}
@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"project/domain/model"
+	"project/domain/service"
+	"project/domain/threading"
+	"sort"
+	"sync"
+)
+
+// ThreadRepository implementa service.ThreadRepository en memoria sobre los
+// correos de un EmailRepository, a los que asigna su hilo.
+type ThreadRepository struct {
+	mu     sync.RWMutex
+	emails *EmailRepository
+	byID   map[int]model.Thread
+	byRoot map[string]int
+	nextID int
+}
+
+// NewThreadRepository crea un repositorio de hilos vacío sobre los correos indicados.
+func NewThreadRepository(emails *EmailRepository) *ThreadRepository {
+	return &ThreadRepository{
+		emails: emails,
+		byID:   make(map[int]model.Thread),
+		byRoot: make(map[string]int),
+	}
+}
+
+// Messages devuelve los datos de todos los correos que hacen falta para agruparlos en hilos.
+func (r *ThreadRepository) Messages() ([]threading.Message, error) {
+	r.emails.mu.RLock()
+	defer r.emails.mu.RUnlock()
+
+	messages := make([]threading.Message, 0, len(r.emails.emails))
+	for _, email := range r.emails.emails {
+		messages = append(messages, threading.Message{
+			ID:         email.ID,
+			MessageID:  email.MessageID,
+			References: threading.ReferenceChain(email.References, email.InReplyTo),
+			Subject:    email.Subject,
+			Date:       email.Date.Time,
+		})
+	}
+	sort.Slice(messages, func(i, j int) bool { return messages[i].ID < messages[j].ID })
+	return messages, nil
+}
+
+// Replace guarda los hilos y asigna a cada correo el suyo.
+func (r *ThreadRepository) Replace(threads []service.ThreadRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.emails.mu.Lock()
+	defer r.emails.mu.Unlock()
+
+	byID := make(map[int]model.Thread, len(threads))
+	byRoot := make(map[string]int, len(threads))
+	for _, record := range threads {
+		id, ok := r.byRoot[record.RootMessageID]
+		if !ok {
+			r.nextID++
+			id = r.nextID
+		}
+		byID[id] = model.Thread{
+			ID:            id,
+			RootMessageID: record.RootMessageID,
+			Subject:       record.Subject,
+			MessageCount:  len(record.EmailIDs),
+			FirstDate:     record.FirstDate,
+			LastDate:      record.LastDate,
+		}
+		byRoot[record.RootMessageID] = id
+
+		for _, emailID := range record.EmailIDs {
+			email, ok := r.emails.emails[emailID]
+			if !ok {
+				continue
+			}
+			threadID := id
+			email.ThreadID = &threadID
+			r.emails.emails[emailID] = email
+		}
+	}
+
+	// Los hilos que no se volvieron a guardar ya no existen
+	r.byID, r.byRoot = byID, byRoot
+	return nil
+}
+
+// List devuelve los hilos ordenados por su último correo junto con el total.
+// Los hilos sin fecha van al final, como los NULL en el orden descendente de la base.
+func (r *ThreadRepository) List(offset, limit int) ([]model.Thread, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	threads := make([]model.Thread, 0, len(r.byID))
+	for _, thread := range r.byID {
+		threads = append(threads, thread)
+	}
+	sort.Slice(threads, func(i, j int) bool {
+		a, b := threads[i], threads[j]
+		if result := compareDates(a.LastDate.Time, a.LastDate.Valid, b.LastDate.Time, b.LastDate.Valid); result != 0 {
+			return result > 0
+		}
+		return a.ID > b.ID
+	})
+
+	total := len(threads)
+	if offset >= total {
+		return nil, total, nil
+	}
+	threads = threads[offset:]
+	if len(threads) > limit {
+		threads = threads[:limit]
+	}
+	return threads, total, nil
+}
+
+// Get devuelve una copia del hilo sin sus correos o nil si no existe.
+func (r *ThreadRepository) Get(id int) (*model.Thread, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	thread, ok := r.byID[id]
+	if !ok {
+		return nil, nil
+	}
+	return &thread, nil
+}
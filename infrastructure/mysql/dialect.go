@@ -0,0 +1,77 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"project/infrastructure/sqlstore"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Nombre del lock de MySQL que evita que dos instancias migren a la vez
+const (
+	migrationLockName    = "labora_schema_migrations"
+	migrationLockTimeout = 60 // segundos
+)
+
+// Errores de MySQL
+const (
+	errNoSuchTable     = 1146
+	errLockWaitTimeout = 1205
+	errLockDeadlock    = 1213
+)
+
+// Dialect describe la sintaxis de MySQL para sqlstore.
+var Dialect = sqlstore.Dialect{
+	Name:            "mysql",
+	MaxPlaceholders: 65535, // Límite de parámetros por sentencia del protocolo de MySQL
+	InsertIgnore:    "INSERT IGNORE",
+	IgnoreConflict:  " ON DUPLICATE KEY UPDATE id = id",
+	UpsertContact:   " ON DUPLICATE KEY UPDATE name = IF(VALUES(name) = '', name, VALUES(name))",
+	ForUpdate:       " FOR UPDATE",
+	IsTransient: func(err error) bool {
+		return isErrorNumber(err, errLockDeadlock, errLockWaitTimeout)
+	},
+	IsMissingTable: func(err error) bool {
+		return isErrorNumber(err, errNoSuchTable)
+	},
+	SchemaTypes: map[string]string{
+		"id":               "INT AUTO_INCREMENT PRIMARY KEY",
+		"collate_binary":   " CHARACTER SET utf8mb4 COLLATE utf8mb4_bin",
+		"collate_nocase":   "", // La intercalación por defecto ya no distingue mayúsculas
+		"json":             "JSON",
+		"participant_role": "ENUM('from', 'to', 'cc', 'bcc')",
+	},
+	Lock: migrationLock,
+	// Cada sentencia DDL confirma la transacción: las migraciones deben ser idempotentes
+	TransactionalDDL: false,
+	TextIndex:        textIndex{},
+}
+
+func isErrorNumber(err error, numbers ...uint16) bool {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return false
+	}
+	for _, number := range numbers {
+		if mysqlErr.Number == number {
+			return true
+		}
+	}
+	return false
+}
+
+// migrationLock toma un lock con nombre de MySQL, compartido por todas las
+// conexiones del servidor.
+func migrationLock(ctx context.Context, conn *sql.Conn) (func(), error) {
+	var acquired sql.NullInt64
+	if err := conn.QueryRowContext(ctx, `SELECT GET_LOCK(?, ?)`, migrationLockName, migrationLockTimeout).Scan(&acquired); err != nil {
+		return nil, fmt.Errorf("error obteniendo el lock de migraciones: %w", err)
+	}
+	if acquired.Int64 != 1 {
+		return nil, fmt.Errorf("otra instancia está ejecutando migraciones (lock %s)", migrationLockName)
+	}
+	return func() { conn.ExecContext(ctx, `SELECT RELEASE_LOCK(?)`, migrationLockName) }, nil
+}
@@ -4,18 +4,36 @@ import (
 	"database/sql"
 	"fmt"
 	"os"
+	"project/infrastructure/sqlstore"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 )
 
+// Open abre la conexión configurada en MYSQL_DSN con el dialecto de MySQL.
+func Open() (*sqlstore.DB, error) {
+	db, err := InitMySQL()
+	if err != nil {
+		return nil, err
+	}
+	return &sqlstore.DB{DB: db, Dialect: Dialect}, nil
+}
+
 func InitMySQL() (*sql.DB, error) {
 	dsn := os.Getenv("MYSQL_DSN")
 	if dsn == "" {
 		return nil, fmt.Errorf("la variable de entorno MYSQL_DSN no está configurada")
 	}
 
-	db, err := sql.Open("mysql", dsn)
+	// Las columnas DATETIME se leen como time.Time y se interpretan en UTC
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("MYSQL_DSN inválido: %w", err)
+	}
+	cfg.ParseTime = true
+	cfg.Loc = time.UTC
+
+	db, err := sql.Open("mysql", cfg.FormatDSN())
 	if err != nil {
 		return nil, fmt.Errorf("error al abrir la conexión a MySQL: %w", err)
 	}
@@ -0,0 +1,74 @@
+package mysql
+
+import (
+	"project/domain/searchquery"
+	"project/infrastructure/sqlstore"
+	"strings"
+	"unicode"
+)
+
+// Expresión del índice FULLTEXT sobre el asunto y el cuerpo (migración 0013)
+const fullTextMatch = "MATCH(emails.subject, emails.body) AGAINST (? IN BOOLEAN MODE)"
+
+// textIndex busca con el índice FULLTEXT de emails en modo booleano. Los
+// nombres de remitente y destinatario no están en el índice y se buscan con
+// LIKE; las coincidencias se marcan después de leer los correos.
+type textIndex struct{}
+
+// Match busca el término en el asunto y el cuerpo o en los nombres del campo.
+func (textIndex) Match(c *sqlstore.SearchCompiler, field string, term searchquery.Term) string {
+	switch field {
+	case "sender_name", "receiver_name":
+		return c.Contains(field, term.Value)
+	}
+
+	against := booleanQuery(term)
+	if against == "" {
+		return "1 = 0"
+	}
+	c.Args = append(c.Args, against)
+	switch field {
+	case "subject", "body":
+		// El índice cubre las dos columnas: se comprueba además que aparezca en la pedida
+		return "(" + fullTextMatch + " AND " + c.Contains(field, term.Value) + ")"
+	}
+	return "(" + fullTextMatch + " OR " + c.Contains("sender_name", term.Value) + " OR " +
+		c.Contains("receiver_name", term.Value) + ")"
+}
+
+// Rank calcula la relevancia con un solo MATCH que reúne los términos de texto
+// no negados del asunto y el cuerpo. Se redondea para que el valor del cursor
+// de paginación se compare igual en la siguiente consulta.
+func (textIndex) Rank(terms []sqlstore.RankedTerm) (string, []interface{}) {
+	var groups []string
+	for _, ranked := range terms {
+		if ranked.Field == "sender_name" || ranked.Field == "receiver_name" {
+			continue
+		}
+		if against := booleanQuery(ranked.Term); against != "" {
+			groups = append(groups, "("+against+")")
+		}
+	}
+	if len(groups) == 0 {
+		return `0 AS score, '' AS subject_hl, '' AS body_hl FROM emails`, nil
+	}
+	return `ROUND(` + fullTextMatch + `, 6) AS score, '' AS subject_hl, '' AS body_hl FROM emails`,
+		[]interface{}{strings.Join(groups, " ")}
+}
+
+// booleanQuery convierte el término en una expresión del modo booleano de
+// MySQL. Solo se conservan las letras y los dígitos para que los operadores
+// del usuario no se interpreten; si tiene varias palabras se busca como frase,
+// igual que match_phrase en ZincSearch.
+func booleanQuery(term searchquery.Term) string {
+	words := strings.FieldsFunc(term.Value, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	switch len(words) {
+	case 0:
+		return ""
+	case 1:
+		return words[0]
+	}
+	return `"` + strings.Join(words, " ") + `"`
+}
@@ -0,0 +1,119 @@
+package searchindex
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Largo máximo de una palabra indexada, en bytes. Las más largas suelen ser
+// adjuntos codificados en el cuerpo y se omiten.
+const maxTokenLength = 64
+
+// Idiomas de los que el analizador sabe extraer raíces
+var stemmers = map[string]func(string) string{
+	"english": stemEnglish,
+	"spanish": stemSpanish,
+	"none":    func(word string) string { return word },
+}
+
+// Token es una palabra del texto ya normalizada, con su posición.
+type Token struct {
+	Term     string // Raíz de la palabra en minúsculas y sin tildes
+	Position int    // Número de palabra dentro del texto
+	Start    int    // Byte donde empieza la palabra en el texto original
+	End      int    // Byte siguiente al final de la palabra
+}
+
+// Analyzer separa los textos en palabras y las reduce a su raíz, de forma que
+// "reuniones" encuentre "reunión" y "meetings" encuentre "meeting".
+type Analyzer struct {
+	language string
+	stem     func(string) string
+}
+
+// NewAnalyzer crea el analizador del idioma indicado: english, spanish o none
+// (sin extraer raíces).
+func NewAnalyzer(language string) (*Analyzer, error) {
+	stem, ok := stemmers[language]
+	if !ok {
+		return nil, fmt.Errorf("idioma de búsqueda no soportado: %q (use english, spanish o none)", language)
+	}
+	return &Analyzer{language: language, stem: stem}, nil
+}
+
+// Language devuelve el idioma del analizador.
+func (a *Analyzer) Language() string {
+	return a.language
+}
+
+// Tokens devuelve las palabras del texto en orden. Una palabra es una
+// secuencia de letras y dígitos; los apóstrofos entre letras forman parte de
+// ella ("don't").
+func (a *Analyzer) Tokens(text string) []Token {
+	var tokens []Token
+	position := 0
+	start := -1
+	for i := 0; i <= len(text); {
+		r, size := utf8.RuneError, 1
+		if i < len(text) {
+			r, size = utf8.DecodeRuneInString(text[i:])
+		}
+
+		inWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if !inWord && start >= 0 && (r == '\'' || r == '’') && i+size < len(text) {
+			// Apóstrofo dentro de una palabra
+			next, _ := utf8.DecodeRuneInString(text[i+size:])
+			inWord = unicode.IsLetter(next)
+		}
+
+		switch {
+		case inWord && start < 0:
+			start = i
+		case !inWord && start >= 0:
+			if i-start <= maxTokenLength {
+				tokens = append(tokens, Token{Term: a.normalize(text[start:i]), Position: position, Start: start, End: i})
+			}
+			position++
+			start = -1
+		}
+		i += size
+	}
+	return tokens
+}
+
+// Terms devuelve solo las raíces de las palabras del texto.
+func (a *Analyzer) Terms(text string) []string {
+	tokens := a.Tokens(text)
+	terms := make([]string, len(tokens))
+	for i, token := range tokens {
+		terms[i] = token.Term
+	}
+	return terms
+}
+
+// normalize pasa la palabra a minúsculas, extrae su raíz y quita las tildes.
+// Las tildes se quitan al final porque el algoritmo para español las usa.
+func (a *Analyzer) normalize(word string) string {
+	word = strings.ReplaceAll(strings.ToLower(word), "’", "'")
+	return foldAccents(a.stem(word))
+}
+
+// foldAccents quita las marcas diacríticas: "camión" pasa a "camion".
+func foldAccents(word string) string {
+	if isASCII(word) {
+		return word
+	}
+	// El transformador guarda estado, por lo que se crea uno por palabra
+	folder := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(folder, word)
+	if err != nil {
+		return word
+	}
+	return folded
+}
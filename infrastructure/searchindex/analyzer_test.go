@@ -0,0 +1,54 @@
+package searchindex
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAnalyzerTerms(t *testing.T) {
+	tests := []struct {
+		language string
+		text     string
+		want     []string
+	}{
+		{"english", "Meetings about the Q3 budgets", []string{"meet", "about", "the", "q3", "budget"}},
+		{"english", "Don't forward; it’s private!", []string{"don't", "forward", "it", "privat"}},
+		{"spanish", "Reuniones de organización", []string{"reunion", "de", "organiz"}},
+		{"spanish", "CAMIÓN y camión", []string{"camion", "y", "camion"}},
+		{"none", "Reuniones, Meetings", []string{"reuniones", "meetings"}},
+		{"none", "a-b_c 12:30", []string{"a", "b", "c", "12", "30"}},
+		{"none", "", []string{}},
+	}
+	for _, tt := range tests {
+		analyzer, err := NewAnalyzer(tt.language)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := analyzer.Terms(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Terms(%q) en %s = %q, se esperaba %q", tt.text, tt.language, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzerTokens(t *testing.T) {
+	analyzer, err := NewAnalyzer("none")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Las palabras demasiado largas se omiten pero ocupan su posición
+	text := "inicio " + strings.Repeat("x", maxTokenLength+1) + " Fin"
+	want := []Token{
+		{Term: "inicio", Position: 0, Start: 0, End: 6},
+		{Term: "fin", Position: 2, Start: len(text) - 3, End: len(text)},
+	}
+	if got := analyzer.Tokens(text); !reflect.DeepEqual(got, want) {
+		t.Errorf("Tokens() = %+v, se esperaba %+v", got, want)
+	}
+}
+
+func TestNewAnalyzerUnknownLanguage(t *testing.T) {
+	if _, err := NewAnalyzer("klingon"); err == nil {
+		t.Error("se esperaba un error con un idioma no soportado")
+	}
+}
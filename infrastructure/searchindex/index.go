@@ -0,0 +1,474 @@
+package searchindex
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Archivo con la lista de segmentos vigentes del índice
+const manifestFile = "segments.json"
+
+// Archivo que bloquea el proceso que escribe en el índice
+const lockFileName = "write.lock"
+
+var (
+	// ErrLocked indica que otro proceso ya tiene abierto el índice para escribir.
+	ErrLocked = errors.New("otro proceso está escribiendo en el índice (ingest o reindex)")
+	// ErrReadOnly indica que el índice se abrió con Open, solo para buscar.
+	ErrReadOnly = errors.New("el índice está abierto solo para lectura")
+)
+
+const (
+	// Documentos que se acumulan en memoria antes de escribir un segmento
+	flushDocs = 5000
+	// Cantidad de segmentos a partir de la cual se combinan los más chicos
+	maxSegments = 10
+	// Cada cuánto se comprueba si otro proceso cambió el índice
+	refreshInterval = 2 * time.Second
+)
+
+// manifest es el contenido de segments.json. Reemplazarlo es lo que publica
+// los cambios: los segmentos que no figuran en él no se usan.
+type manifest struct {
+	Generation  int64             `json:"generation"`
+	Language    string            `json:"language"`
+	NextSegment int               `json:"next_segment"`
+	Segments    []manifestSegment `json:"segments"`
+}
+
+type manifestSegment struct {
+	Name    string `json:"name"`
+	Deleted []int  `json:"deleted,omitempty"` // IDs de documentos eliminados o reemplazados
+}
+
+// Index es un índice invertido guardado en un directorio como una lista de
+// segmentos inmutables. Los documentos nuevos se acumulan en memoria y se
+// escriben en un segmento nuevo; los reemplazados se marcan como eliminados.
+//
+// Solo un proceso puede escribir a la vez: OpenWriter y Create toman un lock
+// sobre el directorio hasta Close. Los demás (por ejemplo el servidor mientras
+// corre ingest) abren el índice con Open y ven los cambios unos segundos
+// después de cada Commit.
+type Index struct {
+	dir      string
+	analyzer *Analyzer
+	lock     *os.File // Lock de escritura; nil si el índice es solo de lectura
+
+	mu          sync.RWMutex
+	segments    []*segment
+	generation  int64
+	nextSegment int
+	checked     time.Time
+
+	pending []Document // Documentos agregados que todavía no se escribieron
+	rebuild bool       // Commit reemplaza todo el contenido anterior por staged
+	staged  []*segment // Segmentos escritos durante la reconstrucción
+}
+
+// Open abre el índice del directorio para buscar, creándolo si no existe. El
+// índice debe haberse creado con el mismo idioma que el analizador.
+func Open(dir string, analyzer *Analyzer) (*Index, error) {
+	ix, m, err := newIndex(dir, analyzer, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := ix.loadChecked(m); err != nil {
+		return nil, err
+	}
+	return ix, nil
+}
+
+// OpenWriter abre el índice como Open, y además para agregar y eliminar
+// documentos. Devuelve ErrLocked si otro proceso ya lo abrió para escribir.
+func OpenWriter(dir string, analyzer *Analyzer) (*Index, error) {
+	ix, m, err := newIndex(dir, analyzer, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := ix.loadChecked(m); err != nil {
+		ix.Close()
+		return nil, err
+	}
+	return ix, nil
+}
+
+// Create abre el índice del directorio para reconstruirlo desde cero: al
+// llamar a Commit, los documentos agregados reemplazan a todos los anteriores.
+// Hasta entonces los demás procesos siguen viendo el contenido anterior. Como
+// OpenWriter, devuelve ErrLocked si otro proceso está escribiendo.
+func Create(dir string, analyzer *Analyzer) (*Index, error) {
+	ix, _, err := newIndex(dir, analyzer, true)
+	if err != nil {
+		return nil, err
+	}
+	ix.rebuild = true
+	return ix, nil
+}
+
+// Close libera el lock de escritura. El índice se puede seguir usando para buscar.
+func (ix *Index) Close() error {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	if ix.lock == nil {
+		return nil
+	}
+	err := unlockFile(ix.lock)
+	ix.lock = nil
+	return err
+}
+
+// loadChecked carga los segmentos del manifiesto si el índice usa el idioma
+// del analizador.
+func (ix *Index) loadChecked(m manifest) error {
+	if len(m.Segments) > 0 && m.Language != ix.analyzer.Language() {
+		return fmt.Errorf("el índice de %s usa el idioma %s y no %s: ejecute reindex para reconstruirlo", ix.dir, m.Language, ix.analyzer.Language())
+	}
+	return ix.load(m)
+}
+
+func newIndex(dir string, analyzer *Analyzer, writer bool) (*Index, manifest, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, manifest{}, fmt.Errorf("error al crear el directorio del índice %s: %w", dir, err)
+	}
+	ix := &Index{dir: dir, analyzer: analyzer}
+	if writer {
+		lock, err := lockFile(filepath.Join(dir, lockFileName))
+		if err != nil {
+			return nil, manifest{}, fmt.Errorf("%s: %w", dir, err)
+		}
+		ix.lock = lock
+	}
+	// El manifiesto se lee después de tomar el lock, para partir del último publicado
+	m, err := ix.readManifest()
+	if err != nil {
+		ix.Close()
+		return nil, manifest{}, err
+	}
+	ix.generation = m.Generation
+	ix.nextSegment = m.NextSegment
+	ix.checked = time.Now()
+	return ix, m, nil
+}
+
+// Add agrega documentos al índice; los que tienen el ID de uno existente lo
+// reemplazan. Se escriben en disco cada cierta cantidad y al llamar a Commit.
+func (ix *Index) Add(docs ...Document) error {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	if ix.lock == nil {
+		return ErrReadOnly
+	}
+	ix.pending = append(ix.pending, docs...)
+	if len(ix.pending) < flushDocs {
+		return nil
+	}
+	if err := ix.flush(); err != nil {
+		return err
+	}
+	if ix.rebuild {
+		return nil
+	}
+	return ix.publish()
+}
+
+// Delete elimina los documentos con los IDs indicados. Los cambios se
+// publican con Commit.
+func (ix *Index) Delete(ids ...int) error {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	if ix.lock == nil {
+		return ErrReadOnly
+	}
+	removed := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		removed[id] = true
+	}
+	pending := ix.pending[:0]
+	for _, doc := range ix.pending {
+		if !removed[doc.ID] {
+			pending = append(pending, doc)
+		}
+	}
+	ix.pending = pending
+	ix.segments = withoutDocs(ix.segments, ids)
+	ix.staged = withoutDocs(ix.staged, ids)
+	return nil
+}
+
+// Commit escribe los documentos pendientes y publica los cambios.
+func (ix *Index) Commit() error {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	if ix.lock == nil {
+		return ErrReadOnly
+	}
+	if err := ix.flush(); err != nil {
+		return err
+	}
+	if ix.rebuild {
+		ix.segments = ix.staged
+		ix.staged = nil
+		ix.rebuild = false
+	}
+	if err := ix.merge(); err != nil {
+		return err
+	}
+	return ix.publish()
+}
+
+// Count devuelve la cantidad de documentos del índice.
+func (ix *Index) Count() int {
+	ix.refresh()
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	n := 0
+	for _, seg := range ix.segments {
+		n += seg.live()
+	}
+	return n
+}
+
+// flush escribe los documentos pendientes en un segmento nuevo.
+func (ix *Index) flush() error {
+	if len(ix.pending) == 0 {
+		return nil
+	}
+
+	// Si un documento se agregó más de una vez, vale la última
+	seen := make(map[int]bool, len(ix.pending))
+	docs := make([]Document, 0, len(ix.pending))
+	for i := len(ix.pending) - 1; i >= 0; i-- {
+		if doc := ix.pending[i]; !seen[doc.ID] {
+			seen[doc.ID] = true
+			docs = append(docs, doc)
+		}
+	}
+	for i, j := 0, len(docs)-1; i < j; i, j = i+1, j-1 {
+		docs[i], docs[j] = docs[j], docs[i]
+	}
+	ids := make([]int, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.ID
+	}
+
+	seg := buildSegment(ix.newSegmentName(), docs, ix.analyzer)
+	if err := writeSegment(ix.dir, seg); err != nil {
+		return err
+	}
+	ix.pending = nil
+
+	if ix.rebuild {
+		ix.staged = append(withoutDocs(ix.staged, ids), seg)
+	} else {
+		ix.segments = append(withoutDocs(ix.segments, ids), seg)
+	}
+	return nil
+}
+
+// merge descarta los segmentos vacíos y, si quedan demasiados, combina los más
+// chicos en uno solo para que las búsquedas no recorran tantos.
+func (ix *Index) merge() error {
+	segments := make([]*segment, 0, len(ix.segments))
+	for _, seg := range ix.segments {
+		if seg.live() > 0 {
+			segments = append(segments, seg)
+		}
+	}
+	ix.segments = segments
+	if len(segments) <= maxSegments {
+		return nil
+	}
+
+	bySize := append([]*segment(nil), segments...)
+	sort.SliceStable(bySize, func(i, j int) bool { return bySize[i].live() < bySize[j].live() })
+	selected := make(map[*segment]bool)
+	var docs []Document
+	for _, seg := range bySize[:len(bySize)-maxSegments/2+1] {
+		selected[seg] = true
+		for i, doc := range seg.Docs {
+			if !seg.deleted[i] {
+				docs = append(docs, doc)
+			}
+		}
+	}
+
+	merged := buildSegment(ix.newSegmentName(), docs, ix.analyzer)
+	if err := writeSegment(ix.dir, merged); err != nil {
+		return err
+	}
+	kept := make([]*segment, 0, len(segments))
+	for _, seg := range segments {
+		if !selected[seg] {
+			kept = append(kept, seg)
+		}
+	}
+	ix.segments = append(kept, merged)
+	return nil
+}
+
+// publish reemplaza el manifiesto por uno con los segmentos actuales y borra
+// los archivos de segmento que ya no se usan. Falla si el manifiesto cambió
+// desde que se leyó: los segmentos en memoria ya no serían los vigentes y se
+// borrarían los de otro proceso.
+func (ix *Index) publish() error {
+	current, err := ix.readManifest()
+	if err != nil {
+		return err
+	}
+	if current.Generation != ix.generation {
+		return fmt.Errorf("el índice de %s cambió mientras se escribía (generación %d, se esperaba %d)", ix.dir, current.Generation, ix.generation)
+	}
+
+	m := manifest{
+		Generation:  ix.generation + 1,
+		Language:    ix.analyzer.Language(),
+		NextSegment: ix.nextSegment,
+		Segments:    make([]manifestSegment, len(ix.segments)),
+	}
+	for i, seg := range ix.segments {
+		m.Segments[i] = manifestSegment{Name: seg.name, Deleted: seg.deletedIDs()}
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error al serializar el manifiesto del índice: %w", err)
+	}
+
+	path := filepath.Join(ix.dir, manifestFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("error al guardar el manifiesto del índice: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("error al guardar el manifiesto del índice: %w", err)
+	}
+	ix.generation = m.Generation
+	ix.checked = time.Now()
+
+	ix.removeUnused()
+	return nil
+}
+
+// removeUnused borra los archivos de segmento que no figuran en el manifiesto.
+// Los procesos que todavía los tienen cargados los conservan en memoria.
+func (ix *Index) removeUnused() {
+	used := make(map[string]bool)
+	for _, seg := range append(append([]*segment(nil), ix.segments...), ix.staged...) {
+		used[seg.name+segmentExt] = true
+	}
+	files, err := filepath.Glob(filepath.Join(ix.dir, "*"+segmentExt))
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		if !used[filepath.Base(file)] {
+			os.Remove(file)
+		}
+	}
+}
+
+// refresh vuelve a cargar el índice si otro proceso publicó cambios. Se
+// comprueba como mucho una vez cada refreshInterval.
+func (ix *Index) refresh() {
+	ix.mu.RLock()
+	fresh := time.Since(ix.checked) < refreshInterval
+	ix.mu.RUnlock()
+	if fresh {
+		return
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if time.Since(ix.checked) < refreshInterval || ix.rebuild {
+		return
+	}
+	ix.checked = time.Now()
+
+	m, err := ix.readManifest()
+	if err != nil || m.Generation == ix.generation {
+		return
+	}
+	if len(m.Segments) > 0 && m.Language != ix.analyzer.Language() {
+		fmt.Printf("El índice de %s se reconstruyó con el idioma %s; reinicie con SEARCH_LANGUAGE=%s\n", ix.dir, m.Language, m.Language)
+		return
+	}
+	if err := ix.load(m); err != nil {
+		// Se reintenta en la próxima comprobación; mientras tanto se usa lo que ya estaba cargado
+		fmt.Printf("Error al recargar el índice de búsqueda: %v\n", err)
+	}
+}
+
+// load carga los segmentos del manifiesto, reutilizando los que ya estaban en memoria.
+func (ix *Index) load(m manifest) error {
+	loaded := make(map[string]*segment, len(ix.segments))
+	for _, seg := range ix.segments {
+		loaded[seg.name] = seg
+	}
+
+	segments := make([]*segment, 0, len(m.Segments))
+	for _, entry := range m.Segments {
+		seg, ok := loaded[entry.Name]
+		if !ok {
+			var err error
+			if seg, err = readSegment(ix.dir, entry.Name); err != nil {
+				return err
+			}
+		}
+		segments = append(segments, seg.withDeleted(entry.Deleted))
+	}
+
+	ix.segments = segments
+	ix.generation = m.Generation
+	ix.nextSegment = m.NextSegment
+	return nil
+}
+
+func (ix *Index) readManifest() (manifest, error) {
+	var m manifest
+	data, err := os.ReadFile(filepath.Join(ix.dir, manifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return m, fmt.Errorf("error al leer el manifiesto del índice: %w", err)
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("manifiesto del índice inválido en %s: %w", ix.dir, err)
+	}
+	return m, nil
+}
+
+func (ix *Index) newSegmentName() string {
+	ix.nextSegment++
+	return fmt.Sprintf("seg_%06d", ix.nextSegment)
+}
+
+// withoutDocs devuelve los segmentos con los IDs indicados marcados como
+// eliminados. Solo se copian los segmentos que contienen alguno.
+func withoutDocs(segments []*segment, ids []int) []*segment {
+	if len(segments) == 0 {
+		return segments
+	}
+	result := make([]*segment, len(segments))
+	for i, seg := range segments {
+		result[i] = seg
+		for _, id := range ids {
+			if doc, ok := seg.byID[id]; ok && !seg.deleted[doc] {
+				result[i] = seg.withDeleted(ids)
+				break
+			}
+		}
+	}
+	return result
+}
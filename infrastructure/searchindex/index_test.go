@@ -0,0 +1,341 @@
+package searchindex
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func newTestIndex(t *testing.T, language string, docs ...Document) (*Index, string) {
+	t.Helper()
+	analyzer, err := NewAnalyzer(language)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	ix, err := OpenWriter(dir, analyzer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ix.Add(docs...); err != nil {
+		t.Fatal(err)
+	}
+	if err := ix.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ix.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return ix, dir
+}
+
+func textDoc(id int, subject, body string) Document {
+	return Document{ID: id, Text: map[string]string{"subject": subject, "body": body}}
+}
+
+// searchIDs devuelve los IDs de los resultados en orden.
+func searchIDs(t *testing.T, ix *Index, q Query) []int {
+	t.Helper()
+	result, err := ix.Search(SearchRequest{Query: q, Size: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []int{}
+	for _, hit := range result.Hits {
+		ids = append(ids, hit.Document.ID)
+	}
+	return ids
+}
+
+func TestSearchBM25Order(t *testing.T) {
+	ix, _ := newTestIndex(t, "english",
+		textDoc(1, "Weekly report", "The merger was discussed among many other topics in a long meeting"),
+		textDoc(2, "Merger", "Merger merger merger"),
+		textDoc(3, "Lunch", "Nothing to see here"),
+		textDoc(4, "Merger update", "The merger closes on Friday"),
+		textDoc(5, "Merger update", "The merger closes on Friday"),
+	)
+
+	tests := []struct {
+		name  string
+		query Query
+		want  []int
+	}{
+		// Más apariciones en un campo más corto dan más relevancia; a igual relevancia se ordena por ID
+		{"frecuencia y largo del campo", MatchQuery{Fields: []string{"body"}, Text: "merger"}, []int{2, 4, 5, 1}},
+		{"mejor campo", MatchQuery{Fields: []string{"subject", "body"}, Text: "merger"}, []int{2, 4, 5, 1}},
+		{"todas las palabras en un mismo campo", MatchQuery{Fields: []string{"subject", "body"}, Text: "merger friday"}, []int{4, 5}},
+		{"raíces", MatchQuery{Fields: []string{"body"}, Text: "meetings discussing"}, []int{1}},
+		{"sin coincidencias", MatchQuery{Fields: []string{"body"}, Text: "acquisition"}, []int{}},
+		{
+			"exclusión",
+			BoolQuery{Must: []Query{MatchQuery{Fields: []string{"body"}, Text: "merger"}}, MustNot: []Query{MatchQuery{Fields: []string{"body"}, Text: "friday"}}},
+			[]int{2, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := searchIDs(t, ix, tt.query); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search() = %v, se esperaba %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchRareTermsScoreHigher(t *testing.T) {
+	ix, _ := newTestIndex(t, "none",
+		textDoc(1, "", "contract common"),
+		textDoc(2, "", "enron common"),
+		textDoc(3, "", "enron common"),
+		textDoc(4, "", "enron common"),
+	)
+	result, err := ix.Search(SearchRequest{Query: BoolQuery{Should: []Query{
+		MatchQuery{Fields: []string{"body"}, Text: "contract"},
+		MatchQuery{Fields: []string{"body"}, Text: "enron"},
+	}}, Size: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Hits) != 4 || result.Hits[0].Document.ID != 1 {
+		t.Fatalf("se esperaba primero el documento con la palabra menos frecuente: %+v", result.Hits)
+	}
+	if result.Hits[0].Score <= result.Hits[1].Score {
+		t.Errorf("relevancia %v, no mayor que %v", result.Hits[0].Score, result.Hits[1].Score)
+	}
+}
+
+func TestSearchPhrase(t *testing.T) {
+	ix, _ := newTestIndex(t, "english",
+		textDoc(1, "", "Please send the budget review today"),
+		textDoc(2, "", "Review the budget before Monday"),
+		textDoc(3, "", "The budget for the review"),
+		textDoc(4, "", "Both budgets reviewed"),
+		textDoc(5, "budget", "review"),
+	)
+
+	tests := []struct {
+		text string
+		want []int
+	}{
+		{"budget review", []int{1, 4}},
+		{"review the budget", []int{2}},
+		{"the budget", []int{1, 2, 3}},
+		{"send budget", []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got := searchIDs(t, ix, MatchQuery{Fields: []string{"subject", "body"}, Text: tt.text, Phrase: true})
+			if !reflect.DeepEqual(sortedIDs(got), tt.want) {
+				t.Errorf("frase %q = %v, se esperaba %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchKeywordsAndDates(t *testing.T) {
+	ix, _ := newTestIndex(t, "none",
+		Document{ID: 1, Keywords: map[string][]string{"folder": {`\Alice\Inbox`}}, Date: time.Date(2001, 5, 1, 0, 0, 0, 0, time.UTC)},
+		Document{ID: 2, Keywords: map[string][]string{"folder": {`\Bob\Sent`}}, Date: time.Date(2001, 6, 1, 0, 0, 0, 0, time.UTC)},
+		Document{ID: 3},
+	)
+	if got := searchIDs(t, ix, ContainsQuery{Field: "folder", Value: "inbox"}); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("ContainsQuery = %v, se esperaba [1]", got)
+	}
+	if got := searchIDs(t, ix, TermQuery{Field: "folder", Value: `\bob\sent`}); !reflect.DeepEqual(got, []int{}) {
+		t.Errorf("TermQuery debería distinguir mayúsculas: %v", got)
+	}
+	if got := searchIDs(t, ix, TermQuery{Field: "folder", Value: `\Bob\Sent`}); !reflect.DeepEqual(got, []int{2}) {
+		t.Errorf("TermQuery = %v, se esperaba [2]", got)
+	}
+	dates := DateRangeQuery{From: time.Date(2001, 5, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2001, 6, 1, 0, 0, 0, 0, time.UTC)}
+	if got := searchIDs(t, ix, dates); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("DateRangeQuery = %v, se esperaba [1]", got)
+	}
+	if got := searchIDs(t, ix, BoolQuery{MustNot: []Query{DateRangeQuery{To: time.Date(2001, 6, 1, 0, 0, 0, 0, time.UTC)}}}); !reflect.DeepEqual(got, []int{2, 3}) {
+		t.Errorf("documentos fuera del rango = %v, se esperaba [2 3]", got)
+	}
+}
+
+func TestIndexReopenAfterCommit(t *testing.T) {
+	analyzer, err := NewAnalyzer("spanish")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	writer, err := OpenWriter(dir, analyzer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Add(textDoc(1, "Reunión", "Presupuesto anual"), textDoc(2, "Viaje", "Reuniones en Madrid")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Hasta el Commit los cambios no se ven desde otro proceso
+	reader, err := Open(dir, analyzer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := reader.Count(); n != 0 {
+		t.Fatalf("se ven %d documentos antes del Commit", n)
+	}
+
+	if err := writer.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := Open(dir, analyzer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := reopened.Count(); n != 2 {
+		t.Fatalf("Count() = %d después de reabrir, se esperaba 2", n)
+	}
+	query := MatchQuery{Fields: []string{"subject", "body"}, Text: "reunion"}
+	if got := sortedIDs(searchIDs(t, reopened, query)); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("Search() = %v después de reabrir, se esperaba [1 2]", got)
+	}
+
+	// Reemplazar y eliminar documentos también se publica con el Commit
+	if err := writer.Add(textDoc(1, "Cena", "Sin novedades")); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Delete(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err = Open(dir, analyzer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := searchIDs(t, reopened, query); !reflect.DeepEqual(got, []int{}) {
+		t.Errorf("Search() = %v, los documentos reemplazados o eliminados no deberían aparecer", got)
+	}
+	if got := searchIDs(t, reopened, MatchQuery{Fields: []string{"subject"}, Text: "cena"}); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("Search() = %v, se esperaba el documento reemplazado", got)
+	}
+
+	// El índice no se puede abrir con otro idioma
+	english, err := NewAnalyzer("english")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(dir, english); err == nil {
+		t.Error("se esperaba un error al abrir el índice con otro idioma")
+	}
+}
+
+func TestIndexCreateReplacesContentOnCommit(t *testing.T) {
+	ix, dir := newTestIndex(t, "none", textDoc(1, "viejo", ""), textDoc(2, "viejo", ""))
+
+	rebuilt, err := Create(dir, ix.analyzer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rebuilt.Add(textDoc(3, "nuevo", "")); err != nil {
+		t.Fatal(err)
+	}
+	if n := ix.Count(); n != 2 {
+		t.Fatalf("Count() = %d durante la reconstrucción, se esperaba 2", n)
+	}
+	if err := rebuilt.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := Open(dir, ix.analyzer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := searchIDs(t, reopened, MatchAllQuery{}); !reflect.DeepEqual(got, []int{3}) {
+		t.Errorf("Search() = %v después de reconstruir, se esperaba [3]", got)
+	}
+}
+
+func TestSearchHighlightEscapesHTML(t *testing.T) {
+	ix, _ := newTestIndex(t, "none", textDoc(1, "", `<script>alert("merger")</script> merger & more`))
+
+	result, err := ix.Search(SearchRequest{
+		Query:     MatchQuery{Fields: []string{"body"}, Text: "merger"},
+		Size:      1,
+		Highlight: map[string]HighlightField{"body": {}},
+		PreTag:    "<mark>",
+		PostTag:   "</mark>",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"&lt;script&gt;alert(&#34;<mark>merger</mark>&#34;)&lt;/script&gt; <mark>merger</mark> &amp; more"}
+	if got := result.Hits[0].Highlights["body"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("Highlights = %q, se esperaba %q", got, want)
+	}
+}
+
+func TestIndexSingleWriter(t *testing.T) {
+	ix, dir := newTestIndex(t, "none", textDoc(1, "uno", ""))
+
+	writer, err := OpenWriter(dir, ix.analyzer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenWriter(dir, ix.analyzer); !errors.Is(err, ErrLocked) {
+		t.Errorf("OpenWriter() = %v con otro escritor, se esperaba ErrLocked", err)
+	}
+	if _, err := Create(dir, ix.analyzer); !errors.Is(err, ErrLocked) {
+		t.Errorf("Create() = %v con otro escritor, se esperaba ErrLocked", err)
+	}
+
+	// Los lectores no esperan al escritor, pero no pueden modificar el índice
+	reader, err := Open(dir, ix.analyzer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reader.Add(textDoc(2, "dos", "")); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Add() = %v en un lector, se esperaba ErrReadOnly", err)
+	}
+	if err := reader.Commit(); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Commit() = %v en un lector, se esperaba ErrReadOnly", err)
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	rebuilt, err := Create(dir, ix.analyzer)
+	if err != nil {
+		t.Fatalf("Create() = %v después de cerrar el escritor", err)
+	}
+	rebuilt.Close()
+}
+
+func TestIndexCommitRejectsStaleManifest(t *testing.T) {
+	ix, dir := newTestIndex(t, "none", textDoc(1, "uno", ""))
+
+	writer, err := OpenWriter(dir, ix.analyzer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	if err := writer.Add(textDoc(2, "dos", "")); err != nil {
+		t.Fatal(err)
+	}
+	// Como si otro proceso hubiera publicado sin respetar el lock
+	writer.generation--
+	if err := writer.Commit(); err == nil {
+		t.Fatal("se esperaba un error al publicar sobre un manifiesto que cambió")
+	}
+
+	reopened, err := Open(dir, ix.analyzer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := searchIDs(t, reopened, MatchAllQuery{}); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("Search() = %v, el índice publicado no debería cambiar", got)
+	}
+}
+
+func sortedIDs(ids []int) []int {
+	sorted := append([]int{}, ids...)
+	sort.Ints(sorted)
+	return sorted
+}
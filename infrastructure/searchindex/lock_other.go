@@ -0,0 +1,28 @@
+//go:build !unix
+
+package searchindex
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// lockFile crea el archivo de lock, que no debe existir. Sin flock, si el
+// proceso termina sin cerrarlo hay que borrar el archivo a mano.
+func lockFile(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		return nil, ErrLocked
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al tomar el lock del índice: %w", err)
+	}
+	return f, nil
+}
+
+// unlockFile libera el lock tomado con lockFile.
+func unlockFile(f *os.File) error {
+	f.Close()
+	return os.Remove(f.Name())
+}
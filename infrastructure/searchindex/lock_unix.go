@@ -0,0 +1,32 @@
+//go:build unix
+
+package searchindex
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// lockFile toma el lock exclusivo del archivo, sin esperar. El sistema lo
+// libera si el proceso termina sin cerrarlo.
+func lockFile(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("error al abrir el lock del índice: %w", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, ErrLocked
+		}
+		return nil, fmt.Errorf("error al tomar el lock del índice: %w", err)
+	}
+	return f, nil
+}
+
+// unlockFile libera el lock tomado con lockFile.
+func unlockFile(f *os.File) error {
+	return f.Close()
+}
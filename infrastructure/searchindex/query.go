@@ -0,0 +1,347 @@
+package searchindex
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// Parámetros de BM25
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Query es una condición sobre los documentos del índice.
+type Query interface {
+	query()
+}
+
+// MatchAllQuery devuelve todos los documentos.
+type MatchAllQuery struct{}
+
+// MatchQuery busca texto analizado en uno o más campos de texto. Todas las
+// palabras deben aparecer en un mismo campo; con Phrase deben aparecer juntas
+// y en el mismo orden. La relevancia es la del campo con mejor puntaje.
+type MatchQuery struct {
+	Fields []string
+	Text   string
+	Phrase bool
+}
+
+// TermQuery busca un valor exacto en un campo keyword.
+type TermQuery struct {
+	Field string
+	Value string
+}
+
+// ContainsQuery busca un valor como parte de un campo keyword, sin distinguir mayúsculas.
+type ContainsQuery struct {
+	Field string
+	Value string
+}
+
+// DateRangeQuery limita la fecha de los documentos. From es inclusive y To
+// exclusive; un límite cero no se aplica. Los documentos sin fecha no la cumplen.
+type DateRangeQuery struct {
+	From time.Time
+	To   time.Time
+}
+
+// BoolQuery combina consultas: se deben cumplir todas las de Must y Filter,
+// al menos una de Should (si hay) y ninguna de MustNot. Las de Filter no
+// influyen en la relevancia.
+type BoolQuery struct {
+	Must    []Query
+	Filter  []Query
+	Should  []Query
+	MustNot []Query
+}
+
+func (MatchAllQuery) query()  {}
+func (MatchQuery) query()     {}
+func (TermQuery) query()      {}
+func (ContainsQuery) query()  {}
+func (DateRangeQuery) query() {}
+func (BoolQuery) query()      {}
+
+// matches son los documentos de un segmento que cumplen una consulta, con su relevancia.
+type matches struct {
+	match []bool
+	score []float64
+}
+
+func newMatches(n int) matches {
+	return matches{match: make([]bool, n), score: make([]float64, n)}
+}
+
+// stats son las estadísticas de todo el índice que usa BM25.
+type stats struct {
+	docs       int                       // Documentos en todos los segmentos
+	docFreq    map[string]map[string]int // Documentos que contienen cada término, por campo
+	avgLength  map[string]float64        // Largo promedio de cada campo, en palabras
+	segments   []*segment
+	analyzer   *Analyzer
+	highlights map[string]map[string]bool // Términos buscados en cada campo, para resaltarlos
+}
+
+func newStats(segments []*segment, analyzer *Analyzer) *stats {
+	s := &stats{
+		docFreq:    make(map[string]map[string]int),
+		avgLength:  make(map[string]float64),
+		segments:   segments,
+		analyzer:   analyzer,
+		highlights: make(map[string]map[string]bool),
+	}
+	total := make(map[string]int64)
+	for _, seg := range segments {
+		s.docs += len(seg.Docs)
+		for field, postings := range seg.Fields {
+			total[field] += postings.Total
+		}
+	}
+	for field, sum := range total {
+		if s.docs > 0 {
+			s.avgLength[field] = float64(sum) / float64(s.docs)
+		}
+	}
+	return s
+}
+
+// idf devuelve la rareza del término en el campo según BM25.
+func (s *stats) idf(field, term string) float64 {
+	byTerm, ok := s.docFreq[field]
+	if !ok {
+		byTerm = make(map[string]int)
+		s.docFreq[field] = byTerm
+	}
+	df, ok := byTerm[term]
+	if !ok {
+		for _, seg := range s.segments {
+			if postings := seg.Fields[field]; postings != nil {
+				df += len(postings.Terms[term])
+			}
+		}
+		byTerm[term] = df
+	}
+	return math.Log(1 + (float64(s.docs)-float64(df)+0.5)/(float64(df)+0.5))
+}
+
+// bm25 devuelve la relevancia de un término que aparece freq veces en un campo de length palabras.
+func (s *stats) bm25(field string, idf float64, freq, length int) float64 {
+	avg := s.avgLength[field]
+	if avg == 0 {
+		avg = 1
+	}
+	tf := float64(freq)
+	return idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*float64(length)/avg))
+}
+
+// highlight registra los términos buscados en el campo.
+func (s *stats) highlight(field string, terms []string) {
+	if s.highlights[field] == nil {
+		s.highlights[field] = make(map[string]bool)
+	}
+	for _, term := range terms {
+		s.highlights[field][term] = true
+	}
+}
+
+// eval evalúa la consulta sobre un segmento. Con negated la consulta está
+// dentro de un MustNot y sus términos no se resaltan.
+func (s *stats) eval(seg *segment, q Query, negated bool) matches {
+	n := len(seg.Docs)
+	switch q := q.(type) {
+	case MatchAllQuery:
+		m := newMatches(n)
+		for i := range m.match {
+			m.match[i] = true
+		}
+		return m
+	case MatchQuery:
+		return s.evalMatch(seg, q, negated)
+	case TermQuery:
+		m := newMatches(n)
+		for _, doc := range seg.keywords[q.Field][q.Value] {
+			m.match[doc] = true
+		}
+		return m
+	case ContainsQuery:
+		m := newMatches(n)
+		value := strings.ToLower(q.Value)
+		for keyword, docs := range seg.keywords[q.Field] {
+			if strings.Contains(strings.ToLower(keyword), value) {
+				for _, doc := range docs {
+					m.match[doc] = true
+				}
+			}
+		}
+		return m
+	case DateRangeQuery:
+		m := newMatches(n)
+		for i, doc := range seg.Docs {
+			date := doc.Date
+			m.match[i] = !date.IsZero() && (q.From.IsZero() || !date.Before(q.From)) && (q.To.IsZero() || date.Before(q.To))
+		}
+		return m
+	case BoolQuery:
+		return s.evalBool(seg, q, negated)
+	}
+	return newMatches(n)
+}
+
+func (s *stats) evalBool(seg *segment, q BoolQuery, negated bool) matches {
+	n := len(seg.Docs)
+	m := newMatches(n)
+	for i := range m.match {
+		m.match[i] = true
+	}
+
+	for _, must := range q.Must {
+		child := s.eval(seg, must, negated)
+		for i := range m.match {
+			m.match[i] = m.match[i] && child.match[i]
+			m.score[i] += child.score[i]
+		}
+	}
+	for _, filter := range q.Filter {
+		child := s.eval(seg, filter, negated)
+		for i := range m.match {
+			m.match[i] = m.match[i] && child.match[i]
+		}
+	}
+	if len(q.Should) > 0 {
+		matched := make([]bool, n)
+		for _, should := range q.Should {
+			child := s.eval(seg, should, negated)
+			for i := range matched {
+				if child.match[i] {
+					matched[i] = true
+					m.score[i] += child.score[i]
+				}
+			}
+		}
+		for i := range m.match {
+			m.match[i] = m.match[i] && matched[i]
+		}
+	}
+	for _, mustNot := range q.MustNot {
+		child := s.eval(seg, mustNot, !negated)
+		for i := range m.match {
+			m.match[i] = m.match[i] && !child.match[i]
+		}
+	}
+
+	for i := range m.score {
+		if !m.match[i] {
+			m.score[i] = 0
+		}
+	}
+	return m
+}
+
+// evalMatch busca las palabras del texto en cada campo y se queda con el mejor puntaje.
+func (s *stats) evalMatch(seg *segment, q MatchQuery, negated bool) matches {
+	m := newMatches(len(seg.Docs))
+	terms := s.analyzer.Terms(q.Text)
+	if len(terms) == 0 {
+		return m
+	}
+
+	for _, field := range q.Fields {
+		if !negated {
+			s.highlight(field, terms)
+		}
+		postings := seg.Fields[field]
+		if postings == nil {
+			continue
+		}
+
+		var scores map[int32]float64
+		if q.Phrase && len(terms) > 1 {
+			scores = s.phraseScores(field, postings, terms)
+		} else {
+			scores = s.termScores(field, postings, terms)
+		}
+		for doc, score := range scores {
+			m.match[doc] = true
+			if score > m.score[doc] {
+				m.score[doc] = score
+			}
+		}
+	}
+	return m
+}
+
+// termScores devuelve los documentos que contienen todos los términos en el
+// campo, con la suma de sus puntajes.
+func (s *stats) termScores(field string, postings *fieldPostings, terms []string) map[int32]float64 {
+	var scores map[int32]float64
+	for i, term := range terms {
+		idf := s.idf(field, term)
+		next := make(map[int32]float64)
+		for _, p := range postings.Terms[term] {
+			if i > 0 {
+				if _, ok := scores[p.Doc]; !ok {
+					continue
+				}
+			}
+			next[p.Doc] = scores[p.Doc] + s.bm25(field, idf, len(p.Positions), int(postings.Lengths[p.Doc]))
+		}
+		scores = next
+		if len(scores) == 0 {
+			break
+		}
+	}
+	return scores
+}
+
+// phraseScores devuelve los documentos en los que los términos aparecen
+// seguidos en el campo. La frase puntúa como un término cuya rareza es la suma
+// de las rarezas de sus palabras.
+func (s *stats) phraseScores(field string, postings *fieldPostings, terms []string) map[int32]float64 {
+	idf := 0.0
+	positions := make([]map[int32][]int32, len(terms))
+	for i, term := range terms {
+		idf += s.idf(field, term)
+		positions[i] = make(map[int32][]int32)
+		for _, p := range postings.Terms[term] {
+			positions[i][p.Doc] = p.Positions
+		}
+	}
+
+	scores := make(map[int32]float64)
+	for doc, starts := range positions[0] {
+		freq := 0
+		for _, start := range starts {
+			found := true
+			for i := 1; i < len(terms) && found; i++ {
+				found = containsPosition(positions[i][doc], start+int32(i))
+			}
+			if found {
+				freq++
+			}
+		}
+		if freq > 0 {
+			scores[doc] = s.bm25(field, idf, freq, int(postings.Lengths[doc]))
+		}
+	}
+	return scores
+}
+
+// containsPosition busca una posición en una lista ordenada.
+func containsPosition(positions []int32, position int32) bool {
+	lo, hi := 0, len(positions)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		switch {
+		case positions[mid] == position:
+			return true
+		case positions[mid] < position:
+			lo = mid + 1
+		default:
+			hi = mid
+		}
+	}
+	return false
+}
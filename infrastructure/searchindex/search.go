@@ -0,0 +1,319 @@
+package searchindex
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// SearchRequest es una búsqueda sobre el índice.
+type SearchRequest struct {
+	Query   Query
+	From    int
+	Size    int          // Cantidad máxima de resultados; cero para obtener solo el total y los conteos
+	After   *SearchAfter // Devuelve los resultados posteriores a este; se usa en lugar de From
+	Reverse bool         // Ordena de menor a mayor relevancia y, a igual relevancia, por ID descendente
+
+	Highlight map[string]HighlightField // Campos de texto en los que se resaltan las palabras buscadas
+	PreTag    string
+	PostTag   string
+
+	Terms      map[string]TermsAggregation // Valores más frecuentes de campos keyword, por nombre
+	Histograms map[string]DateHistogram    // Documentos por intervalo de fecha, por nombre
+}
+
+// SearchAfter identifica un resultado por su relevancia y su ID.
+type SearchAfter struct {
+	Score float64
+	ID    int
+}
+
+// HighlightField configura los fragmentos resaltados de un campo. Con
+// FragmentSize cero se devuelve el campo completo.
+type HighlightField struct {
+	FragmentSize int // Largo aproximado de cada fragmento, en bytes
+	Fragments    int // Cantidad máxima de fragmentos
+}
+
+// TermsAggregation cuenta los valores de un campo keyword.
+type TermsAggregation struct {
+	Field string
+	Size  int
+}
+
+// DateHistogram cuenta los documentos por intervalo. Interval devuelve el
+// inicio del intervalo que contiene la fecha.
+type DateHistogram struct {
+	Interval func(time.Time) time.Time
+}
+
+// Hit es un documento encontrado.
+type Hit struct {
+	Document   Document
+	Score      float64
+	Highlights map[string][]string
+}
+
+// TermBucket es un valor de un campo keyword y la cantidad de documentos que lo tienen.
+type TermBucket struct {
+	Value string
+	Count int
+}
+
+// DateBucket es un intervalo de fechas y la cantidad de documentos que contiene.
+type DateBucket struct {
+	Start time.Time
+	Count int
+}
+
+// SearchResult es el resultado de una búsqueda.
+type SearchResult struct {
+	Hits       []Hit
+	Total      int
+	Terms      map[string][]TermBucket
+	Histograms map[string][]DateBucket
+}
+
+// candidate es un documento que cumple la consulta.
+type candidate struct {
+	seg   *segment
+	doc   int32
+	score float64
+}
+
+// Search busca los documentos que cumplen la consulta, ordenados por
+// relevancia y, a igual relevancia, por ID.
+func (ix *Index) Search(req SearchRequest) (*SearchResult, error) {
+	ix.refresh()
+	ix.mu.RLock()
+	segments := ix.segments
+	ix.mu.RUnlock()
+
+	query := req.Query
+	if query == nil {
+		query = MatchAllQuery{}
+	}
+	st := newStats(segments, ix.analyzer)
+	var candidates []candidate
+	for _, seg := range segments {
+		m := st.eval(seg, query, false)
+		for i, match := range m.match {
+			if match && !seg.deleted[i] {
+				candidates = append(candidates, candidate{seg: seg, doc: int32(i), score: m.score[i]})
+			}
+		}
+	}
+
+	result := &SearchResult{
+		Total:      len(candidates),
+		Terms:      make(map[string][]TermBucket, len(req.Terms)),
+		Histograms: make(map[string][]DateBucket, len(req.Histograms)),
+	}
+	for name, agg := range req.Terms {
+		result.Terms[name] = countTerms(candidates, agg)
+	}
+	for name, histogram := range req.Histograms {
+		result.Histograms[name] = countDates(candidates, histogram)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].before(candidates[j], req.Reverse)
+	})
+	if req.After != nil {
+		start := sort.Search(len(candidates), func(i int) bool {
+			return candidates[i].follows(*req.After, req.Reverse)
+		})
+		candidates = candidates[start:]
+	} else if req.From > 0 {
+		if req.From >= len(candidates) {
+			candidates = nil
+		} else {
+			candidates = candidates[req.From:]
+		}
+	}
+	if req.Size >= 0 && req.Size < len(candidates) {
+		candidates = candidates[:req.Size]
+	}
+
+	result.Hits = make([]Hit, len(candidates))
+	for i, c := range candidates {
+		doc := c.seg.Docs[c.doc]
+		hit := Hit{Document: doc, Score: c.score}
+		for field, cfg := range req.Highlight {
+			if fragments := highlightField(ix.analyzer, doc.Text[field], st.highlights[field], cfg, req.PreTag, req.PostTag); len(fragments) > 0 {
+				if hit.Highlights == nil {
+					hit.Highlights = make(map[string][]string)
+				}
+				hit.Highlights[field] = fragments
+			}
+		}
+		result.Hits[i] = hit
+	}
+	return result, nil
+}
+
+func (c candidate) id() int {
+	return c.seg.Docs[c.doc].ID
+}
+
+// before indica si el candidato va antes que otro en el orden de los resultados.
+func (c candidate) before(other candidate, reverse bool) bool {
+	if c.score != other.score {
+		return (c.score > other.score) != reverse
+	}
+	return (c.id() < other.id()) != reverse
+}
+
+// follows indica si el candidato va después del resultado indicado.
+func (c candidate) follows(after SearchAfter, reverse bool) bool {
+	if c.score != after.Score {
+		return (c.score < after.Score) != reverse
+	}
+	return (c.id() > after.ID) != reverse
+}
+
+// countTerms cuenta los valores del campo entre los candidatos, del más al menos frecuente.
+func countTerms(candidates []candidate, agg TermsAggregation) []TermBucket {
+	counts := make(map[string]int)
+	for _, c := range candidates {
+		values := c.seg.Docs[c.doc].Keywords[agg.Field]
+		for i, value := range values {
+			if !containsString(values[:i], value) {
+				counts[value]++
+			}
+		}
+	}
+
+	buckets := make([]TermBucket, 0, len(counts))
+	for value, count := range counts {
+		buckets = append(buckets, TermBucket{Value: value, Count: count})
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		if buckets[i].Count != buckets[j].Count {
+			return buckets[i].Count > buckets[j].Count
+		}
+		return buckets[i].Value < buckets[j].Value
+	})
+	if agg.Size > 0 && len(buckets) > agg.Size {
+		buckets = buckets[:agg.Size]
+	}
+	return buckets
+}
+
+// countDates cuenta los candidatos con fecha por intervalo, en orden cronológico.
+func countDates(candidates []candidate, histogram DateHistogram) []DateBucket {
+	counts := make(map[time.Time]int)
+	for _, c := range candidates {
+		if date := c.seg.Docs[c.doc].Date; !date.IsZero() {
+			counts[histogram.Interval(date)]++
+		}
+	}
+
+	buckets := make([]DateBucket, 0, len(counts))
+	for start, count := range counts {
+		buckets = append(buckets, DateBucket{Start: start, Count: count})
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].Start.Before(buckets[j].Start) })
+	return buckets
+}
+
+// highlightField devuelve los fragmentos del texto que contienen los términos
+// buscados, con esos términos entre las etiquetas. Los fragmentos son HTML: el
+// texto va escapado y solo las etiquetas quedan sin escapar.
+func highlightField(analyzer *Analyzer, text string, terms map[string]bool, cfg HighlightField, preTag, postTag string) []string {
+	if text == "" || len(terms) == 0 {
+		return nil
+	}
+	var marks []Token
+	for _, token := range analyzer.Tokens(text) {
+		if terms[token.Term] {
+			marks = append(marks, token)
+		}
+	}
+	if len(marks) == 0 {
+		return nil
+	}
+	if cfg.FragmentSize <= 0 {
+		return []string{markText(text, 0, len(text), marks, preTag, postTag)}
+	}
+
+	fragments := []string{}
+	end := 0
+	for _, mark := range marks {
+		if mark.Start < end {
+			continue
+		}
+		if cfg.Fragments > 0 && len(fragments) == cfg.Fragments {
+			break
+		}
+
+		// Se deja algo de contexto antes de la palabra y se completa el fragmento después
+		start := mark.Start - cfg.FragmentSize/3
+		if start < end {
+			start = end
+		}
+		if start = wordStart(text, start); start > mark.Start {
+			start = mark.Start
+		}
+		stop := start + cfg.FragmentSize
+		if stop < mark.End {
+			stop = mark.End
+		}
+		stop = wordEnd(text, stop)
+
+		fragment := markText(text, start, stop, marks, preTag, postTag)
+		fragments = append(fragments, strings.Join(strings.Fields(fragment), " "))
+		end = stop
+	}
+	return fragments
+}
+
+// markText devuelve text[start:stop] escapado como HTML, con las palabras
+// marcadas entre las etiquetas.
+func markText(text string, start, stop int, marks []Token, preTag, postTag string) string {
+	var b strings.Builder
+	pos := start
+	for _, mark := range marks {
+		if mark.Start < start || mark.End > stop {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[pos:mark.Start]))
+		b.WriteString(preTag)
+		b.WriteString(html.EscapeString(text[mark.Start:mark.End]))
+		b.WriteString(postTag)
+		pos = mark.End
+	}
+	b.WriteString(html.EscapeString(text[pos:stop]))
+	return b.String()
+}
+
+// wordStart avanza hasta el comienzo de la palabra siguiente si pos cae en medio de una.
+func wordStart(text string, pos int) int {
+	if pos <= 0 {
+		return 0
+	}
+	if pos >= len(text) {
+		return len(text)
+	}
+	for pos < len(text) && !isSpace(text[pos-1]) {
+		pos++
+	}
+	return pos
+}
+
+// wordEnd avanza hasta el final de la palabra si pos cae en medio de una.
+func wordEnd(text string, pos int) int {
+	if pos >= len(text) {
+		return len(text)
+	}
+	for pos < len(text) && !isSpace(text[pos]) {
+		pos++
+	}
+	return pos
+}
+
+func isSpace(c byte) bool {
+	return c < 0x80 && unicode.IsSpace(rune(c))
+}
@@ -0,0 +1,183 @@
+package searchindex
+
+import (
+	"bufio"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Extensión de los archivos de segmento
+const segmentExt = ".seg"
+
+// Document es un documento del índice.
+type Document struct {
+	ID       int                 // Identificador; indexar otro documento con el mismo ID lo reemplaza
+	Text     map[string]string   // Campos de texto: se analizan y se buscan por palabras o frases
+	Keywords map[string][]string // Campos keyword: se buscan por su valor exacto o por parte de él
+	Date     time.Time           // Fecha del documento; cero si no tiene
+	Stored   []byte              // Datos que se devuelven con cada resultado
+}
+
+// segment es una parte inmutable del índice guardada en un archivo. Solo
+// cambia la marca de los documentos eliminados, que se guarda en el manifiesto.
+type segment struct {
+	Docs   []Document
+	Fields map[string]*fieldPostings // Índice invertido de cada campo de texto
+
+	// Se calculan al cargar el segmento
+	name     string
+	keywords map[string]map[string][]int32 // Documentos con cada valor de los campos keyword
+	byID     map[int]int32                 // Posición de cada documento en Docs
+	deleted  []bool
+}
+
+// fieldPostings es el índice invertido de un campo de texto.
+type fieldPostings struct {
+	Terms   map[string][]posting // Documentos que contienen cada término, en orden
+	Lengths []int32              // Cantidad de palabras del campo en cada documento
+	Total   int64                // Suma de Lengths
+}
+
+// posting indica en qué posiciones de un documento aparece un término.
+type posting struct {
+	Doc       int32
+	Positions []int32
+}
+
+// buildSegment analiza los documentos y arma su índice invertido.
+func buildSegment(name string, docs []Document, analyzer *Analyzer) *segment {
+	seg := &segment{Docs: docs, Fields: make(map[string]*fieldPostings), name: name}
+	for i, doc := range docs {
+		for field, text := range doc.Text {
+			postings := seg.Fields[field]
+			if postings == nil {
+				postings = &fieldPostings{Terms: make(map[string][]posting), Lengths: make([]int32, len(docs))}
+				seg.Fields[field] = postings
+			}
+
+			tokens := analyzer.Tokens(text)
+			postings.Lengths[i] = int32(len(tokens))
+			postings.Total += int64(len(tokens))
+			for _, token := range tokens {
+				list := postings.Terms[token.Term]
+				if n := len(list); n > 0 && list[n-1].Doc == int32(i) {
+					list[n-1].Positions = append(list[n-1].Positions, int32(token.Position))
+				} else {
+					list = append(list, posting{Doc: int32(i), Positions: []int32{int32(token.Position)}})
+				}
+				postings.Terms[token.Term] = list
+			}
+		}
+	}
+	seg.prepare()
+	return seg
+}
+
+// prepare calcula los datos del segmento que no se guardan en el archivo.
+func (seg *segment) prepare() {
+	seg.keywords = make(map[string]map[string][]int32)
+	seg.byID = make(map[int]int32, len(seg.Docs))
+	for i, doc := range seg.Docs {
+		seg.byID[doc.ID] = int32(i)
+		for field, values := range doc.Keywords {
+			byValue := seg.keywords[field]
+			if byValue == nil {
+				byValue = make(map[string][]int32)
+				seg.keywords[field] = byValue
+			}
+			for _, value := range values {
+				if docs := byValue[value]; len(docs) == 0 || docs[len(docs)-1] != int32(i) {
+					byValue[value] = append(docs, int32(i))
+				}
+			}
+		}
+	}
+	seg.deleted = make([]bool, len(seg.Docs))
+}
+
+// live devuelve la cantidad de documentos que no fueron eliminados.
+func (seg *segment) live() int {
+	n := 0
+	for _, deleted := range seg.deleted {
+		if !deleted {
+			n++
+		}
+	}
+	return n
+}
+
+// deletedIDs devuelve los IDs de los documentos eliminados.
+func (seg *segment) deletedIDs() []int {
+	var ids []int
+	for i, deleted := range seg.deleted {
+		if deleted {
+			ids = append(ids, seg.Docs[i].ID)
+		}
+	}
+	return ids
+}
+
+// withDeleted devuelve una copia del segmento con los IDs indicados marcados
+// como eliminados. El segmento original no cambia, porque puede estar en uso
+// por una búsqueda.
+func (seg *segment) withDeleted(ids []int) *segment {
+	copied := *seg
+	copied.deleted = append([]bool(nil), seg.deleted...)
+	for _, id := range ids {
+		if i, ok := seg.byID[id]; ok {
+			copied.deleted[i] = true
+		}
+	}
+	return &copied
+}
+
+// writeSegment guarda el segmento en el directorio. Se escribe en un archivo
+// temporal que luego se renombra, para no dejar segmentos a medio escribir.
+func writeSegment(dir string, seg *segment) error {
+	path := filepath.Join(dir, seg.name+segmentExt)
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("error al crear el segmento %s: %w", seg.name, err)
+	}
+
+	w := bufio.NewWriter(f)
+	err = gob.NewEncoder(w).Encode(seg)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("error al guardar el segmento %s: %w", seg.name, err)
+	}
+	return nil
+}
+
+// readSegment carga un segmento del directorio.
+func readSegment(dir, name string) (*segment, error) {
+	f, err := os.Open(filepath.Join(dir, name+segmentExt))
+	if err != nil {
+		return nil, fmt.Errorf("error al abrir el segmento %s: %w", name, err)
+	}
+	defer f.Close()
+
+	seg := &segment{}
+	if err := gob.NewDecoder(bufio.NewReader(f)).Decode(seg); err != nil {
+		return nil, fmt.Errorf("error al leer el segmento %s: %w", name, err)
+	}
+	seg.name = name
+	seg.prepare()
+	return seg, nil
+}
@@ -0,0 +1,273 @@
+package searchindex
+
+import "strings"
+
+// Palabras que el algoritmo Porter2 trata como excepción antes de aplicar los pasos
+var englishExceptions = map[string]string{
+	"skis": "ski", "skies": "sky", "dying": "die", "lying": "lie", "tying": "tie",
+	"idly": "idl", "gently": "gentl", "ugly": "ugli", "early": "earli", "only": "onli", "singly": "singl",
+	"sky": "sky", "news": "news", "howe": "howe", "atlas": "atlas", "cosmos": "cosmos", "bias": "bias", "andes": "andes",
+}
+
+// Palabras que no cambian después del paso 1a
+var englishInvariant = map[string]bool{
+	"inning": true, "outing": true, "canning": true, "herring": true,
+	"earring": true, "proceed": true, "exceed": true, "succeed": true,
+}
+
+// Sufijos del paso 2 y su reemplazo, del más largo al más corto
+var englishStep2 = []suffixRule{
+	{"ization", "ize"}, {"ational", "ate"}, {"fulness", "ful"}, {"ousness", "ous"}, {"iveness", "ive"},
+	{"tional", "tion"}, {"biliti", "ble"}, {"lessli", "less"},
+	{"entli", "ent"}, {"ation", "ate"}, {"alism", "al"}, {"aliti", "al"}, {"ousli", "ous"}, {"iviti", "ive"}, {"fulli", "ful"},
+	{"enci", "ence"}, {"anci", "ance"}, {"abli", "able"}, {"izer", "ize"}, {"ator", "ate"}, {"alli", "al"},
+	{"bli", "ble"}, {"ogi", "og"}, {"li", ""},
+}
+
+// Sufijos del paso 3 y su reemplazo, del más largo al más corto
+var englishStep3 = []suffixRule{
+	{"ational", "ate"}, {"tional", "tion"}, {"alize", "al"}, {"icate", "ic"}, {"iciti", "ic"},
+	{"ative", ""}, {"ical", "ic"}, {"ness", ""}, {"ful", ""},
+}
+
+// Sufijos que el paso 4 elimina si están en R2, del más largo al más corto
+var englishStep4 = []string{
+	"ement", "ance", "ence", "able", "ible", "ment", "ant", "ent", "ism", "ate", "iti", "ous", "ive", "ize", "ion",
+	"al", "er", "ic",
+}
+
+// suffixRule reemplaza un sufijo por otro.
+type suffixRule struct {
+	suffix      string
+	replacement string
+}
+
+// stemEnglish reduce una palabra inglesa en minúsculas a su raíz con el
+// algoritmo Porter2 de Snowball.
+func stemEnglish(word string) string {
+	if len(word) <= 2 || !isASCII(word) {
+		return word
+	}
+	if stem, ok := englishExceptions[word]; ok {
+		return stem
+	}
+
+	w := []byte(strings.TrimPrefix(word, "'"))
+	if len(w) <= 2 {
+		return strings.TrimSuffix(string(w), "'")
+	}
+
+	// La y inicial o después de una vocal funciona como consonante (Y)
+	for i := range w {
+		if w[i] == 'y' && (i == 0 || isEnglishVowel(w[i-1])) {
+			w[i] = 'Y'
+		}
+	}
+
+	r1, r2 := englishRegions(w)
+
+	// Paso 0: apóstrofos
+	for _, suffix := range []string{"'s'", "'s", "'"} {
+		if hasSuffix(w, suffix) {
+			w = w[:len(w)-len(suffix)]
+			break
+		}
+	}
+
+	// Paso 1a: plurales
+	switch {
+	case hasSuffix(w, "sses"):
+		w = w[:len(w)-2]
+	case hasSuffix(w, "ied"), hasSuffix(w, "ies"):
+		if len(w) > 4 {
+			w = w[:len(w)-2]
+		} else {
+			w = w[:len(w)-1]
+		}
+	case hasSuffix(w, "us"), hasSuffix(w, "ss"):
+	case hasSuffix(w, "s"):
+		if containsVowel(w[:len(w)-2]) {
+			w = w[:len(w)-1]
+		}
+	}
+
+	if englishInvariant[string(w)] {
+		return string(w)
+	}
+
+	// Paso 1b: tiempos verbales
+	switch suffix := longestSuffix(w, "eedly", "ingly", "edly", "eed", "ing", "ed"); suffix {
+	case "eed", "eedly":
+		if len(w)-len(suffix) >= r1 {
+			w = append(w[:len(w)-len(suffix)], "ee"...)
+		}
+	case "":
+	default:
+		stem := w[:len(w)-len(suffix)]
+		if containsVowel(stem) {
+			w = stem
+			switch {
+			case hasSuffix(w, "at"), hasSuffix(w, "bl"), hasSuffix(w, "iz"):
+				w = append(w, 'e')
+			case endsWithDouble(w):
+				w = w[:len(w)-1]
+			case isShortEnglishWord(w, r1):
+				w = append(w, 'e')
+			}
+		}
+	}
+
+	// Paso 1c: y final después de una consonante que no es la primera letra
+	if n := len(w); n > 2 && (w[n-1] == 'y' || w[n-1] == 'Y') && !isEnglishVowel(w[n-2]) {
+		w[n-1] = 'i'
+	}
+
+	// Paso 2
+	for _, rule := range englishStep2 {
+		if !hasSuffix(w, rule.suffix) {
+			continue
+		}
+		stem := len(w) - len(rule.suffix)
+		if stem >= r1 {
+			switch rule.suffix {
+			case "ogi":
+				if stem > 0 && w[stem-1] == 'l' {
+					w = append(w[:stem], rule.replacement...)
+				}
+			case "li":
+				if stem > 0 && strings.IndexByte("cdeghkmnrt", w[stem-1]) >= 0 {
+					w = w[:stem]
+				}
+			default:
+				w = append(w[:stem], rule.replacement...)
+			}
+		}
+		break
+	}
+
+	// Paso 3
+	for _, rule := range englishStep3 {
+		if !hasSuffix(w, rule.suffix) {
+			continue
+		}
+		stem := len(w) - len(rule.suffix)
+		if stem >= r1 && (rule.suffix != "ative" || stem >= r2) {
+			w = append(w[:stem], rule.replacement...)
+		}
+		break
+	}
+
+	// Paso 4
+	if suffix := longestSuffix(w, englishStep4...); suffix != "" {
+		stem := len(w) - len(suffix)
+		if stem >= r2 && (suffix != "ion" || (stem > 0 && (w[stem-1] == 's' || w[stem-1] == 't'))) {
+			w = w[:stem]
+		}
+	}
+
+	// Paso 5
+	if n := len(w); n > 0 {
+		switch w[n-1] {
+		case 'e':
+			if n-1 >= r2 || (n-1 >= r1 && !endsWithShortSyllable(w[:n-1])) {
+				w = w[:n-1]
+			}
+		case 'l':
+			if n-1 >= r2 && n > 1 && w[n-2] == 'l' {
+				w = w[:n-1]
+			}
+		}
+	}
+
+	return strings.ToLower(string(w))
+}
+
+// englishRegions devuelve el inicio de las regiones R1 y R2 de Porter2.
+func englishRegions(w []byte) (int, int) {
+	r1 := len(w)
+	for _, prefix := range []string{"gener", "commun", "arsen"} {
+		if strings.HasPrefix(string(w), prefix) {
+			r1 = len(prefix)
+			break
+		}
+	}
+	if r1 == len(w) {
+		r1 = nextRegion(w, 0)
+	}
+	return r1, nextRegion(w, r1)
+}
+
+// nextRegion devuelve la posición que sigue a la primera consonante precedida
+// por una vocal a partir de start.
+func nextRegion(w []byte, start int) int {
+	for i := start + 1; i < len(w); i++ {
+		if !isEnglishVowel(w[i]) && isEnglishVowel(w[i-1]) {
+			return i + 1
+		}
+	}
+	return len(w)
+}
+
+func isEnglishVowel(c byte) bool {
+	return strings.IndexByte("aeiouy", c) >= 0
+}
+
+func containsVowel(w []byte) bool {
+	for _, c := range w {
+		if isEnglishVowel(c) {
+			return true
+		}
+	}
+	return false
+}
+
+func endsWithDouble(w []byte) bool {
+	n := len(w)
+	if n < 2 || w[n-1] != w[n-2] {
+		return false
+	}
+	return strings.IndexByte("bdfgmnprt", w[n-1]) >= 0
+}
+
+// endsWithShortSyllable indica si la palabra termina en vocal seguida de una
+// consonante que no es w, x ni Y, precedida a su vez por una consonante, o si
+// es una vocal inicial seguida de una consonante.
+func endsWithShortSyllable(w []byte) bool {
+	n := len(w)
+	if n == 2 {
+		return isEnglishVowel(w[0]) && !isEnglishVowel(w[1])
+	}
+	if n < 3 {
+		return false
+	}
+	return !isEnglishVowel(w[n-3]) && isEnglishVowel(w[n-2]) && !isEnglishVowel(w[n-1]) &&
+		w[n-1] != 'w' && w[n-1] != 'x' && w[n-1] != 'Y'
+}
+
+func isShortEnglishWord(w []byte, r1 int) bool {
+	return r1 >= len(w) && endsWithShortSyllable(w)
+}
+
+func hasSuffix(w []byte, suffix string) bool {
+	return len(w) >= len(suffix) && string(w[len(w)-len(suffix):]) == suffix
+}
+
+// longestSuffix devuelve el primer sufijo de la lista con el que termina la
+// palabra; las listas se ordenan del más largo al más corto.
+func longestSuffix(w []byte, suffixes ...string) string {
+	for _, suffix := range suffixes {
+		if hasSuffix(w, suffix) {
+			return suffix
+		}
+	}
+	return ""
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,40 @@
+package searchindex
+
+import "testing"
+
+func TestStemEnglish(t *testing.T) {
+	// Resultados del vocabulario de ejemplo de Snowball (Porter2)
+	tests := map[string]string{
+		"consign":     "consign",
+		"consigned":   "consign",
+		"consigning":  "consign",
+		"consignment": "consign",
+		"caresses":    "caress",
+		"ponies":      "poni",
+		"cats":        "cat",
+		"agreed":      "agre",
+		"hopping":     "hop",
+		"running":     "run",
+		"meetings":    "meet",
+		"happiness":   "happi",
+		"hopeful":     "hope",
+		"generously":  "generous",
+		"relational":  "relat",
+		"conditional": "condit",
+		"generate":    "generat",
+		"communism":   "communism",
+		"arsenal":     "arsenal",
+		"skies":       "sky",
+		"dying":       "die",
+		"news":        "news",
+		"don't":       "don't",
+		"'quoted":     "quot",
+		"an":          "an",
+		"électricité": "électricité",
+	}
+	for word, want := range tests {
+		if got := stemEnglish(word); got != want {
+			t.Errorf("stemEnglish(%q) = %q, se esperaba %q", word, got, want)
+		}
+	}
+}
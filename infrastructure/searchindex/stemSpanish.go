@@ -0,0 +1,287 @@
+package searchindex
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Pronombres que el paso 0 quita de los verbos, del más largo al más corto
+var spanishPronouns = []string{"selas", "selos", "sela", "selo", "las", "les", "los", "nos", "me", "se", "la", "le", "lo"}
+
+// Sufijos del paso 1 agrupados por la regla que les corresponde. Cada regla
+// recibe la palabra sin el sufijo y devuelve el resultado, o nil si el sufijo
+// no está en la región que exige.
+var spanishStep1 = []struct {
+	suffixes []string
+	rule     func(w []rune, r1, r2 int) []rune
+}{
+	{[]string{"amientos", "imientos", "amiento", "imiento", "anzas", "ismos", "ables", "ibles", "istas", "anza",
+		"icos", "icas", "ismo", "able", "ible", "ista", "osos", "osas", "ico", "ica", "oso", "osa"},
+		func(w []rune, r1, r2 int) []rune { return inRegion(w, r2) }},
+	{[]string{"aciones", "adoras", "adores", "ancias", "adora", "ación", "antes", "ancia", "ador", "ante"},
+		func(w []rune, r1, r2 int) []rune { return removeIfInR2(inRegion(w, r2), r2, "ic") }},
+	{[]string{"logías", "logía"}, func(w []rune, r1, r2 int) []rune { return appendIfInRegion(w, r2, "log") }},
+	{[]string{"uciones", "ución"}, func(w []rune, r1, r2 int) []rune { return appendIfInRegion(w, r2, "u") }},
+	{[]string{"encias", "encia"}, func(w []rune, r1, r2 int) []rune { return appendIfInRegion(w, r2, "ente") }},
+	{[]string{"amente"}, func(w []rune, r1, r2 int) []rune {
+		w = inRegion(w, r1)
+		if hasRuneSuffix(w, "iv") && len(w)-2 >= r2 {
+			return removeIfInR2(w[:len(w)-2], r2, "at")
+		}
+		return removeIfInR2(w, r2, "os", "ic", "ad")
+	}},
+	{[]string{"mente"}, func(w []rune, r1, r2 int) []rune { return removeIfInR2(inRegion(w, r2), r2, "ante", "able", "ible") }},
+	{[]string{"idades", "idad"}, func(w []rune, r1, r2 int) []rune { return removeIfInR2(inRegion(w, r2), r2, "abil", "ic", "iv") }},
+	{[]string{"ivas", "ivos", "iva", "ivo"}, func(w []rune, r1, r2 int) []rune { return removeIfInR2(inRegion(w, r2), r2, "at") }},
+}
+
+// Terminaciones del paso 2b después de las cuales se quita la u de "gu"
+var spanishGuSuffixes = []string{"emos", "éis", "en", "es"}
+
+// Terminaciones verbales del paso 2b que se eliminan si están en RV, del más
+// largo al más corto
+var spanishVerbSuffixes = []string{
+	"aríamos", "eríamos", "iríamos", "iéramos", "iésemos",
+	"aríais", "aremos", "eríais", "eremos", "iríais", "iremos", "ierais", "ieseis", "asteis", "isteis", "ábamos", "áramos", "ásemos",
+	"arían", "arías", "aréis", "erían", "erías", "eréis", "irían", "irías", "iréis", "ieran", "iesen", "ieron", "iendo", "ieras",
+	"ieses", "abais", "arais", "aseis", "íamos",
+	"arán", "arás", "aría", "erán", "erás", "ería", "irán", "irás", "iría", "iera", "iese", "aste", "iste", "aban", "aran", "asen",
+	"aron", "ando", "abas", "adas", "idas", "aras", "ases", "íais", "ados", "idos", "amos", "imos",
+	"ará", "aré", "erá", "eré", "irá", "iré", "aba", "ada", "ida", "ara", "ase", "ían", "ado", "ido", "ías", "áis",
+	"ía", "ad", "ed", "id", "an", "ió", "ar", "er", "ir", "as", "ís",
+}
+
+// stemSpanish reduce una palabra castellana en minúsculas a su raíz con el
+// algoritmo de Snowball para español.
+func stemSpanish(word string) string {
+	w := []rune(word)
+	if len(w) <= 2 {
+		return string(removeAccents(w, 0))
+	}
+	rv, r1, r2 := spanishRegions(w)
+
+	// Paso 0: pronombres enclíticos (por ejemplo "diciéndole")
+	if pronoun := longestRuneSuffix(w, spanishPronouns...); pronoun != "" {
+		stem := len(w) - runeLen(pronoun)
+		before := w[:stem]
+		switch verb := longestRuneSuffix(before, "iéndo", "ándo", "ár", "ér", "ír", "iendo", "ando", "ar", "er", "ir", "yendo"); {
+		case verb == "" || stem-runeLen(verb) < rv:
+		case verb == "yendo":
+			if start := stem - runeLen(verb); start > 0 && w[start-1] == 'u' {
+				w = w[:stem]
+			}
+		default:
+			w = removeAccents(w[:stem], stem-runeLen(verb))
+		}
+	}
+
+	// Paso 1: sufijos de sustantivos, adjetivos y adverbios
+	removed := false
+	if suffix := longestRuneSuffix(w, step1Suffixes...); suffix != "" {
+		for _, group := range spanishStep1 {
+			if containsString(group.suffixes, suffix) {
+				if stemmed := group.rule(w[:len(w)-runeLen(suffix)], r1, r2); stemmed != nil {
+					w, removed = stemmed, true
+				}
+				break
+			}
+		}
+	}
+
+	if !removed {
+		// Paso 2a: verbos con y (por ejemplo "oyendo")
+		if suffix := longestSuffixIn(w, rv, "yeron", "yendo", "yamos", "yais", "yan", "yen", "yas", "yes", "ya", "ye", "yo", "yó"); suffix != "" {
+			stem := len(w) - runeLen(suffix)
+			if stem > 0 && w[stem-1] == 'u' {
+				w = w[:stem]
+				removed = true
+			}
+		}
+	}
+
+	if !removed {
+		// Paso 2b: otras terminaciones verbales
+		if suffix := longestSuffixIn(w, rv, step2bSuffixes...); suffix != "" {
+			w = w[:len(w)-runeLen(suffix)]
+			if containsString(spanishGuSuffixes, suffix) && hasRuneSuffix(w, "gu") && len(w)-1 >= rv {
+				w = w[:len(w)-1]
+			}
+		}
+	}
+
+	// Paso 3: vocales finales
+	if suffix := longestRuneSuffix(w, "os", "a", "o", "á", "í", "ó"); suffix != "" && len(w)-runeLen(suffix) >= rv {
+		w = w[:len(w)-runeLen(suffix)]
+	} else if suffix := longestRuneSuffix(w, "e", "é"); suffix != "" && len(w)-1 >= rv {
+		w = w[:len(w)-1]
+		if hasRuneSuffix(w, "gu") && len(w)-1 >= rv {
+			w = w[:len(w)-1]
+		}
+	}
+
+	return string(removeAccents(w, 0))
+}
+
+// Sufijos de los pasos 1 y 2b ordenados del más largo al más corto
+var (
+	step1Suffixes = func() []string {
+		var suffixes []string
+		for _, group := range spanishStep1 {
+			suffixes = append(suffixes, group.suffixes...)
+		}
+		return sortByLength(suffixes)
+	}()
+	step2bSuffixes = sortByLength(append(append([]string(nil), spanishGuSuffixes...), spanishVerbSuffixes...))
+)
+
+// spanishRegions devuelve el inicio de las regiones RV, R1 y R2.
+func spanishRegions(w []rune) (int, int, int) {
+	rv := len(w)
+	switch {
+	case len(w) < 2:
+	case !isSpanishVowel(w[1]):
+		// Después de la siguiente vocal
+		for i := 2; i < len(w); i++ {
+			if isSpanishVowel(w[i]) {
+				rv = i + 1
+				break
+			}
+		}
+	case isSpanishVowel(w[0]):
+		// Después de la siguiente consonante
+		for i := 2; i < len(w); i++ {
+			if !isSpanishVowel(w[i]) {
+				rv = i + 1
+				break
+			}
+		}
+	default:
+		rv = 3
+	}
+	if rv > len(w) {
+		rv = len(w)
+	}
+
+	r1 := len(w)
+	for i := 1; i < len(w); i++ {
+		if !isSpanishVowel(w[i]) && isSpanishVowel(w[i-1]) {
+			r1 = i + 1
+			break
+		}
+	}
+	r2 := len(w)
+	for i := r1 + 1; i < len(w); i++ {
+		if !isSpanishVowel(w[i]) && isSpanishVowel(w[i-1]) {
+			r2 = i + 1
+			break
+		}
+	}
+	return rv, r1, r2
+}
+
+// inRegion devuelve la palabra si el sufijo que se le quitó empezaba en la
+// región, o nil si no.
+func inRegion(w []rune, region int) []rune {
+	if len(w) < region {
+		return nil
+	}
+	return w
+}
+
+// appendIfInRegion reemplaza el sufijo que se quitó por replacement si estaba en la región.
+func appendIfInRegion(w []rune, region int, replacement string) []rune {
+	if len(w) < region {
+		return nil
+	}
+	return append(w, []rune(replacement)...)
+}
+
+// removeIfInR2 quita el primero de los sufijos con el que termina la palabra
+// si está en R2.
+func removeIfInR2(w []rune, r2 int, suffixes ...string) []rune {
+	if w == nil {
+		return nil
+	}
+	for _, suffix := range suffixes {
+		if hasRuneSuffix(w, suffix) {
+			if stem := len(w) - runeLen(suffix); stem >= r2 {
+				return w[:stem]
+			}
+			return w
+		}
+	}
+	return w
+}
+
+// removeAccents reemplaza las vocales con tilde a partir de la posición start.
+func removeAccents(w []rune, start int) []rune {
+	for i := start; i < len(w); i++ {
+		switch w[i] {
+		case 'á':
+			w[i] = 'a'
+		case 'é':
+			w[i] = 'e'
+		case 'í':
+			w[i] = 'i'
+		case 'ó':
+			w[i] = 'o'
+		case 'ú':
+			w[i] = 'u'
+		}
+	}
+	return w
+}
+
+func isSpanishVowel(r rune) bool {
+	return strings.ContainsRune("aeiouáéíóúü", r)
+}
+
+func hasRuneSuffix(w []rune, suffix string) bool {
+	s := []rune(suffix)
+	if len(w) < len(s) {
+		return false
+	}
+	return string(w[len(w)-len(s):]) == suffix
+}
+
+// longestRuneSuffix devuelve el primer sufijo de la lista con el que termina
+// la palabra; las listas se ordenan del más largo al más corto.
+func longestRuneSuffix(w []rune, suffixes ...string) string {
+	for _, suffix := range suffixes {
+		if hasRuneSuffix(w, suffix) {
+			return suffix
+		}
+	}
+	return ""
+}
+
+// longestSuffixIn es como longestRuneSuffix pero solo considera los sufijos
+// que empiezan en la región.
+func longestSuffixIn(w []rune, region int, suffixes ...string) string {
+	for _, suffix := range suffixes {
+		if hasRuneSuffix(w, suffix) && len(w)-runeLen(suffix) >= region {
+			return suffix
+		}
+	}
+	return ""
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// sortByLength ordena los sufijos del más largo al más corto, en runas.
+func sortByLength(suffixes []string) []string {
+	sort.SliceStable(suffixes, func(i, j int) bool { return runeLen(suffixes[i]) > runeLen(suffixes[j]) })
+	return suffixes
+}
+
+func runeLen(s string) int {
+	return utf8.RuneCountInString(s)
+}
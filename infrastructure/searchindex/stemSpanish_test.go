@@ -0,0 +1,32 @@
+package searchindex
+
+import "testing"
+
+func TestStemSpanish(t *testing.T) {
+	// Resultados del vocabulario de ejemplo de Snowball, sin tildes
+	tests := map[string]string{
+		"reunión":        "reunion",
+		"reuniones":      "reunion",
+		"acción":         "accion",
+		"canciones":      "cancion",
+		"organizaciones": "organiz",
+		"chica":          "chic",
+		"cantaba":        "cant",
+		"cantando":       "cant",
+		"corriendo":      "corr",
+		"comprar":        "compr",
+		"compraremos":    "compr",
+		"perseguir":      "persegu",
+		"bibliotecas":    "bibliotec",
+		"abogados":       "abog",
+		"actualmente":    "actual",
+		"rápidamente":    "rapid",
+		"niño":           "niñ",
+		"niños":          "niñ",
+	}
+	for word, want := range tests {
+		if got := stemSpanish(word); got != want {
+			t.Errorf("stemSpanish(%q) = %q, se esperaba %q", word, got, want)
+		}
+	}
+}
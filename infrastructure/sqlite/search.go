@@ -0,0 +1,59 @@
+package sqlite
+
+import (
+	"project/domain/searchquery"
+	"project/infrastructure/sqlstore"
+	"strings"
+)
+
+// Columnas de emails_fts donde se busca el texto libre, como textFields en
+// searchquery (el índice no incluye los nombres de los adjuntos)
+const textColumns = "{subject body sender_name receiver_name}"
+
+// Relevancia de cada columna de emails_fts en bm25, en el orden de la tabla:
+// subject, body, sender, sender_name, receiver, receiver_name
+const bm25Weights = "2.0, 1.0, 1.0, 1.0, 1.0, 1.0"
+
+// Cantidad de palabras del fragmento resaltado del cuerpo
+const fragmentTokens = 24
+
+// textIndex busca con el índice FTS5 de la tabla emails_fts. La relevancia es
+// bm25 y los fragmentos resaltados los calcula FTS5.
+type textIndex struct{}
+
+// Match busca el término en las columnas de emails_fts del campo.
+func (textIndex) Match(c *sqlstore.SearchCompiler, field string, term searchquery.Term) string {
+	c.Args = append(c.Args, matchExpr(field, term))
+	return "emails.id IN (SELECT rowid FROM emails_fts WHERE emails_fts MATCH ?)"
+}
+
+// Rank calcula la relevancia y los fragmentos con una sola consulta MATCH que
+// reúne los términos de texto no negados.
+func (textIndex) Rank(terms []sqlstore.RankedTerm) (string, []interface{}) {
+	if len(terms) == 0 {
+		return `0 AS score, '' AS subject_hl, '' AS body_hl FROM emails`, nil
+	}
+	exprs := make([]string, len(terms))
+	for i, ranked := range terms {
+		exprs[i] = matchExpr(ranked.Field, ranked.Term)
+	}
+	return `COALESCE(r.score, 0) AS score, COALESCE(r.subject_hl, '') AS subject_hl, COALESCE(r.body_hl, '') AS body_hl
+			FROM emails LEFT JOIN (SELECT rowid AS fts_id, -bm25(emails_fts, ` + bm25Weights + `) AS score,
+				highlight(emails_fts, 0, ?, ?) AS subject_hl,
+				snippet(emails_fts, 1, ?, ?, '…', ?) AS body_hl
+				FROM emails_fts WHERE emails_fts MATCH ?) AS r ON r.fts_id = emails.id`,
+		[]interface{}{searchquery.HighlightPreTag, searchquery.HighlightPostTag,
+			searchquery.HighlightPreTag, searchquery.HighlightPostTag, fragmentTokens,
+			"(" + strings.Join(exprs, ") OR (") + ")"}
+}
+
+// matchExpr devuelve la expresión MATCH del término. El valor va entre
+// comillas para que FTS5 no interprete sus operadores; si tiene varias
+// palabras se busca como frase, igual que match_phrase en ZincSearch.
+func matchExpr(field string, term searchquery.Term) string {
+	columns := textColumns
+	if field != "" {
+		columns = "{" + field + "}"
+	}
+	return columns + ` : "` + strings.ReplaceAll(term.Value, `"`, `""`) + `"`
+}
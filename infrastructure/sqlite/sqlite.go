@@ -0,0 +1,82 @@
+// Package sqlite guarda los correos en un archivo SQLite, sin servidor de base
+// de datos. Usa un driver escrito en Go, por lo que el binario no necesita cgo.
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"project/infrastructure/sqlstore"
+	"strings"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Archivo por defecto de la base si no se configura SQLITE_PATH
+const defaultPath = "./data/emails.db"
+
+// Dialect describe la sintaxis de SQLite para sqlstore.
+var Dialect = sqlstore.Dialect{
+	Name:            "sqlite",
+	MaxPlaceholders: 32766, // SQLITE_MAX_VARIABLE_NUMBER desde la versión 3.32
+	InsertIgnore:    "INSERT OR IGNORE",
+	IgnoreConflict:  " ON CONFLICT DO NOTHING",
+	UpsertContact:   " ON CONFLICT(email) DO UPDATE SET name = CASE WHEN excluded.name = '' THEN contacts.name ELSE excluded.name END",
+	ForUpdate:       "", // Las transacciones toman el lock de escritura al empezar (_txlock=immediate)
+	IsTransient: func(err error) bool {
+		var sqliteErr *sqlite.Error
+		if !errors.As(err, &sqliteErr) {
+			return false
+		}
+		code := sqliteErr.Code() & 0xff // Código primario, sin el extendido
+		return code == sqlite3.SQLITE_BUSY || code == sqlite3.SQLITE_LOCKED
+	},
+	IsMissingTable: func(err error) bool {
+		return err != nil && strings.Contains(err.Error(), "no such table")
+	},
+	// Los textos que se filtran u ordenan usan NOCASE, como la intercalación de MySQL
+	SchemaTypes: map[string]string{
+		"id":               "INTEGER PRIMARY KEY AUTOINCREMENT",
+		"collate_binary":   "",
+		"collate_nocase":   " COLLATE NOCASE",
+		"json":             "TEXT",
+		"participant_role": "TEXT CHECK (role IN ('from', 'to', 'cc', 'bcc'))",
+	},
+	// Un solo proceso escribe a la vez en el archivo; no hace falta un lock propio
+	Lock:             nil,
+	TransactionalDDL: true,
+	TextIndex:        textIndex{},
+}
+
+// Open abre (o crea) la base del archivo configurado en SQLITE_PATH con el
+// dialecto de SQLite.
+func Open() (*sqlstore.DB, error) {
+	path := os.Getenv("SQLITE_PATH")
+	if path == "" {
+		path = defaultPath
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("error al crear el directorio de la base SQLite: %w", err)
+	}
+
+	// Claves foráneas activas, espera ante el lock de escritura en lugar de
+	// fallar y WAL para que las lecturas no bloqueen a la ingesta. Las fechas se
+	// guardan como texto ordenable, que es lo que comparan los filtros.
+	dsn := "file:" + path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)" +
+		"&_time_format=sqlite&_txlock=immediate"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("error al abrir la base SQLite: %w", err)
+	}
+
+	// Verificar conexión
+	if err := db.Ping(); err != nil {
+		return nil, fmt.Errorf("error al verificar la base SQLite %s: %w", path, err)
+	}
+
+	fmt.Printf("Base SQLite abierta en %s\n", path)
+	return &sqlstore.DB{DB: db, Dialect: Dialect}, nil
+}
@@ -0,0 +1,289 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"fmt"
+	"project/domain/model"
+	"project/domain/service"
+	"strings"
+)
+
+// Columnas de emails que escribe Insert
+var emailInsertColumns = []string{
+	"message_id", "content_hash", "sender", "sender_name", "receiver", "receiver_name", "cc", "bcc", "subject",
+	"mime_version", "content_type", "encoding", "folder", "body", "date", "date_raw", "date_offset",
+	"in_reply_to", "reference_ids", "headers",
+}
+
+// Insert guarda el lote en una única transacción con INSERTs de varias filas.
+// Los errores que el dialecto considera transitorios (deadlocks, esperas de
+// lock) se informan como service.ErrTransient.
+func (r *EmailRepository) Insert(emails []model.Email) (service.InsertResult, error) {
+	created, duplicates, err := r.insertTx(emails)
+	if err != nil {
+		if r.dialect.IsTransient(err) {
+			return service.InsertResult{}, fmt.Errorf("%w: %w", service.ErrTransient, err)
+		}
+		return service.InsertResult{}, err
+	}
+	return service.InsertResult{Created: created, Duplicates: duplicates}, nil
+}
+
+type messageKey struct {
+	messageID   string
+	contentHash string
+}
+
+func (r *EmailRepository) insertTx(emails []model.Email) ([]model.Email, int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	// Claves únicas del lote; las copias dentro del mismo lote se cuentan como duplicados
+	keys := make([]messageKey, 0, len(emails))
+	firstByKey := make(map[messageKey]int)
+	for i, email := range emails {
+		key := messageKey{email.MessageID, email.ContentHash}
+		if _, ok := firstByKey[key]; !ok {
+			firstByKey[key] = i
+			keys = append(keys, key)
+		}
+	}
+
+	existing, err := r.lookupEmailIDs(tx, keys)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var toInsert []model.Email
+	for _, key := range keys {
+		if _, ok := existing[key]; !ok {
+			toInsert = append(toInsert, emails[firstByKey[key]])
+		}
+	}
+
+	if err := r.insertEmails(tx, toInsert); err != nil {
+		return nil, 0, err
+	}
+
+	ids, err := r.lookupEmailIDs(tx, keys)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	created := make([]model.Email, 0, len(toInsert))
+	duplicates := 0
+	for i := range emails {
+		key := messageKey{emails[i].MessageID, emails[i].ContentHash}
+		id, ok := ids[key]
+		if !ok {
+			return nil, 0, fmt.Errorf("no se encontró el correo %s después de insertarlo", key.messageID)
+		}
+		emails[i].ID = id
+		if _, wasStored := existing[key]; wasStored || firstByKey[key] != i {
+			duplicates++
+			continue
+		}
+		created = append(created, emails[i])
+	}
+
+	if err := r.insertLocations(tx, emails); err != nil {
+		return nil, 0, err
+	}
+	if err := r.insertParticipants(tx, created); err != nil {
+		return nil, 0, err
+	}
+	if err := r.insertAttachments(tx, created); err != nil {
+		return nil, 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, 0, err
+	}
+	return created, duplicates, nil
+}
+
+// lookupEmailIDs devuelve los IDs de los correos ya guardados con las claves indicadas.
+func (r *EmailRepository) lookupEmailIDs(tx *sql.Tx, keys []messageKey) (map[messageKey]int, error) {
+	ids := make(map[messageKey]int)
+	for _, chunk := range r.chunkRange(len(keys), 2) {
+		args := make([]interface{}, 0, (chunk[1]-chunk[0])*2)
+		for _, key := range keys[chunk[0]:chunk[1]] {
+			args = append(args, key.messageID, key.contentHash)
+		}
+
+		rows, err := tx.Query(`SELECT id, message_id, content_hash FROM emails WHERE (message_id, content_hash) IN (`+
+			rowPlaceholders(chunk[1]-chunk[0], 2)+`)`, args...)
+		if err != nil {
+			return nil, fmt.Errorf("error al buscar correos existentes: %w", err)
+		}
+		for rows.Next() {
+			var id int
+			var key messageKey
+			if err := rows.Scan(&id, &key.messageID, &key.contentHash); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			ids[key] = id
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+func (r *EmailRepository) insertEmails(tx *sql.Tx, emails []model.Email) error {
+	columns := len(emailInsertColumns)
+	for _, chunk := range r.chunkRange(len(emails), columns) {
+		args := make([]interface{}, 0, (chunk[1]-chunk[0])*columns)
+		for _, email := range emails[chunk[0]:chunk[1]] {
+			args = append(args, email.MessageID, email.ContentHash, email.Sender, email.SenderName, email.Receiver,
+				email.ReceiverName, email.Cc, email.Bcc, email.Subject, email.MimeVersion, email.ContentType,
+				email.Encoding, email.Folder, email.Body, email.Date, email.DateRaw, email.DateOffset,
+				email.InReplyTo, email.References, email.Headers)
+		}
+
+		query := `INSERT INTO emails (` + strings.Join(emailInsertColumns, ", ") + `) VALUES ` +
+			rowPlaceholders(chunk[1]-chunk[0], columns) + r.dialect.IgnoreConflict
+		if _, err := tx.Exec(query, args...); err != nil {
+			return fmt.Errorf("error al guardar correos: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *EmailRepository) insertLocations(tx *sql.Tx, emails []model.Email) error {
+	var args []interface{}
+	rows := 0
+	for _, email := range emails {
+		for _, location := range email.Locations {
+			args = append(args, email.ID, location.Folder, location.FilePath, location.Owner)
+			rows++
+		}
+	}
+	return r.execRows(tx, r.dialect.InsertIgnore+` INTO message_locations (email_id, folder, file_path, owner) VALUES `, ``,
+		rows, 4, args, "ubicaciones")
+}
+
+func (r *EmailRepository) insertParticipants(tx *sql.Tx, emails []model.Email) error {
+	// Contactos únicos del lote con el último nombre visible no vacío. Las
+	// direcciones se guardan en minúsculas, como las devuelve address.ParseList
+	names := make(map[string]string)
+	var addresses []string
+	for _, email := range emails {
+		for _, participant := range email.Participants {
+			address := strings.ToLower(participant.Email)
+			name, seen := names[address]
+			if !seen {
+				addresses = append(addresses, address)
+			}
+			if participant.Name != "" || name == "" {
+				names[address] = participant.Name
+			}
+		}
+	}
+	if len(addresses) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, 0, len(addresses)*2)
+	for _, address := range addresses {
+		args = append(args, address, names[address])
+	}
+	err := r.execRows(tx, `INSERT INTO contacts (email, name) VALUES `,
+		r.dialect.UpsertContact,
+		len(addresses), 2, args, "contactos")
+	if err != nil {
+		return err
+	}
+
+	contactIDs := make(map[string]int, len(addresses))
+	for _, chunk := range r.chunkRange(len(addresses), 1) {
+		lookupArgs := make([]interface{}, 0, chunk[1]-chunk[0])
+		for _, address := range addresses[chunk[0]:chunk[1]] {
+			lookupArgs = append(lookupArgs, address)
+		}
+		rows, err := tx.Query(`SELECT id, email FROM contacts WHERE email IN (`+
+			rowPlaceholders(chunk[1]-chunk[0], 1)+`)`, lookupArgs...)
+		if err != nil {
+			return fmt.Errorf("error al buscar contactos: %w", err)
+		}
+		for rows.Next() {
+			var id int
+			var address string
+			if err := rows.Scan(&id, &address); err != nil {
+				rows.Close()
+				return err
+			}
+			contactIDs[strings.ToLower(address)] = id
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+	}
+
+	var participantArgs []interface{}
+	count := 0
+	for _, email := range emails {
+		for _, participant := range email.Participants {
+			contactID, ok := contactIDs[strings.ToLower(participant.Email)]
+			if !ok {
+				return fmt.Errorf("no se encontró el contacto %s", participant.Email)
+			}
+			participantArgs = append(participantArgs, email.ID, contactID, participant.Role)
+			count++
+		}
+	}
+	return r.execRows(tx, r.dialect.InsertIgnore+` INTO email_participants (email_id, contact_id, role) VALUES `, ``,
+		count, 3, participantArgs, "participantes")
+}
+
+func (r *EmailRepository) insertAttachments(tx *sql.Tx, emails []model.Email) error {
+	var args []interface{}
+	count := 0
+	for _, email := range emails {
+		for _, attachment := range email.Attachments {
+			args = append(args, email.ID, attachment.Filename, attachment.ContentType, attachment.Size,
+				attachment.Hash, attachment.ContentID)
+			count++
+		}
+	}
+	return r.execRows(tx, `INSERT INTO attachments (email_id, filename, content_type, size, hash, content_id) VALUES `, ``,
+		count, 6, args, "adjuntos")
+}
+
+// execRows ejecuta un INSERT de varias filas, dividiéndolo si supera el límite de parámetros.
+func (r *EmailRepository) execRows(tx *sql.Tx, prefix, suffix string, rows, columns int, args []interface{}, what string) error {
+	for _, chunk := range r.chunkRange(rows, columns) {
+		query := prefix + rowPlaceholders(chunk[1]-chunk[0], columns) + suffix
+		if _, err := tx.Exec(query, args[chunk[0]*columns:chunk[1]*columns]...); err != nil {
+			return fmt.Errorf("error al guardar %s: %w", what, err)
+		}
+	}
+	return nil
+}
+
+// chunkRange divide n filas en rangos [inicio, fin) que no superan el límite de parámetros del motor.
+func (r *EmailRepository) chunkRange(n, columns int) [][2]int {
+	perChunk := r.dialect.MaxPlaceholders / columns
+	var chunks [][2]int
+	for start := 0; start < n; start += perChunk {
+		end := start + perChunk
+		if end > n {
+			end = n
+		}
+		chunks = append(chunks, [2]int{start, end})
+	}
+	return chunks
+}
+
+// rowPlaceholders devuelve "(?, ?), (?, ?)" para rows filas de columns columnas.
+func rowPlaceholders(rows, columns int) string {
+	row := "(" + strings.TrimSuffix(strings.Repeat("?, ", columns), ", ") + ")"
+	return strings.TrimSuffix(strings.Repeat(row+", ", rows), ", ")
+}
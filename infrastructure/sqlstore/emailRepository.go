@@ -0,0 +1,402 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"project/domain/model"
+	"project/domain/service"
+	"strings"
+)
+
+// EmailColumns son las columnas de la tabla emails en el orden en que las lee ScanEmail.
+const EmailColumns = `id, message_id, content_hash, sender, sender_name, receiver, receiver_name, cc, bcc, subject,
+	mime_version, content_type, encoding, folder, body, date, date_raw, date_offset,
+	in_reply_to, reference_ids, thread_id, headers`
+
+// Columnas de la tabla attachments en el orden en que las lee scanAttachment
+const attachmentColumns = `id, email_id, filename, content_type, size, hash, content_id`
+
+// Columnas por las que se puede ordenar el listado de correos
+var sortColumns = map[string]string{
+	"id":      "emails.id",
+	"date":    "emails.date",
+	"sender":  "emails.sender",
+	"subject": "emails.subject",
+}
+
+// EmailRepository implementa service.EmailRepository sobre las tablas emails,
+// attachments, email_participants, contacts y message_locations.
+type EmailRepository struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// NewEmailRepository crea el repositorio de correos sobre la conexión indicada.
+func NewEmailRepository(db *sql.DB, dialect Dialect) *EmailRepository {
+	return &EmailRepository{db: db, dialect: dialect}
+}
+
+// rowScanner permite leer tanto *sql.Row como *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// ScanEmail mapea una fila con EmailColumns a la estructura Email. Las columnas
+// que siguen a EmailColumns en la consulta se leen en extra.
+func ScanEmail(row rowScanner, extra ...interface{}) (model.Email, error) {
+	var email model.Email
+	err := row.Scan(append([]interface{}{&email.ID, &email.MessageID, &email.ContentHash, &email.Sender, &email.SenderName,
+		&email.Receiver, &email.ReceiverName, &email.Cc, &email.Bcc, &email.Subject, &email.MimeVersion, &email.ContentType,
+		&email.Encoding, &email.Folder, &email.Body, &email.Date, &email.DateRaw, &email.DateOffset,
+		&email.InReplyTo, &email.References, &email.ThreadID, &email.Headers}, extra...)...)
+	return email, err
+}
+
+func scanAttachment(row rowScanner) (model.Attachment, error) {
+	var attachment model.Attachment
+	err := row.Scan(&attachment.ID, &attachment.EmailID, &attachment.Filename, &attachment.ContentType,
+		&attachment.Size, &attachment.Hash, &attachment.ContentID)
+	return attachment, err
+}
+
+// List devuelve los correos que cumplen el filtro en el orden indicado.
+func (r *EmailRepository) List(filter service.EmailFilter, order service.EmailSort, offset, limit int) ([]model.Email, error) {
+	where, args := filterWhere(filter)
+	query := `SELECT ` + EmailColumns + ` FROM emails` + where + orderBy(order)
+	if limit > 0 {
+		query += ` LIMIT ? OFFSET ?`
+		args = append(args, limit, offset)
+	}
+	return r.queryEmails(query, args...)
+}
+
+// ListAfter devuelve los correos que siguen al cursor, leyendo por índice desde
+// su posición en lugar de usar OFFSET.
+func (r *EmailRepository) ListAfter(filter service.EmailFilter, cursor service.Cursor, limit int) ([]model.Email, error) {
+	condition, cursorArgs, err := cursorCondition(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	where, args := filterWhere(filter)
+	if where == "" {
+		where = " WHERE " + condition
+	} else {
+		where += " AND " + condition
+	}
+	args = append(args, cursorArgs...)
+
+	query := `SELECT ` + EmailColumns + ` FROM emails` + where + orderBy(cursor.ReadOrder()) + ` LIMIT ?`
+	return r.queryEmails(query, append(args, limit)...)
+}
+
+func (r *EmailRepository) queryEmails(query string, args ...interface{}) ([]model.Email, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Leer los resultados de la consulta y mapearlos a la estructura Email
+	var emails []model.Email
+	for rows.Next() {
+		email, err := ScanEmail(rows)
+		if err != nil {
+			return nil, err
+		}
+		emails = append(emails, email)
+	}
+	return emails, rows.Err()
+}
+
+// Count devuelve la cantidad de correos que cumplen el filtro.
+func (r *EmailRepository) Count(filter service.EmailFilter) (int, error) {
+	where, args := filterWhere(filter)
+	var total int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM emails"+where, args...).Scan(&total)
+	return total, err
+}
+
+// Get devuelve el correo con sus adjuntos, participantes y ubicaciones, o nil si no existe.
+func (r *EmailRepository) Get(id int) (*model.Email, error) {
+	email, err := ScanEmail(r.db.QueryRow(`SELECT `+EmailColumns+` FROM emails WHERE id = ?`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	email.Attachments, err = r.Attachments(email.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	email.Participants, err = r.participants(email.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	email.Locations, err = r.locations(email.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &email, nil
+}
+
+// Attachments devuelve los adjuntos de un correo.
+func (r *EmailRepository) Attachments(emailID int) ([]model.Attachment, error) {
+	rows, err := r.db.Query(`SELECT `+attachmentColumns+` FROM attachments WHERE email_id = ? ORDER BY id`, emailID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los adjuntos: %w", err)
+	}
+	defer rows.Close()
+
+	attachments := []model.Attachment{}
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error al leer el adjunto: %w", err)
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, rows.Err()
+}
+
+// Attachment devuelve un adjunto concreto de un correo o nil si no existe.
+func (r *EmailRepository) Attachment(emailID int, attachmentID int) (*model.Attachment, error) {
+	attachment, err := scanAttachment(r.db.QueryRow(
+		`SELECT `+attachmentColumns+` FROM attachments WHERE email_id = ? AND id = ?`, emailID, attachmentID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error al obtener el adjunto: %w", err)
+	}
+
+	return &attachment, nil
+}
+
+// participants devuelve el remitente y los destinatarios de un correo.
+func (r *EmailRepository) participants(emailID int) ([]model.Participant, error) {
+	rows, err := r.db.Query(`
+		SELECT c.id, c.email, c.name, ep.role
+		FROM email_participants ep
+		JOIN contacts c ON c.id = ep.contact_id
+		WHERE ep.email_id = ?
+		ORDER BY CASE ep.role WHEN 'from' THEN 1 WHEN 'to' THEN 2 WHEN 'cc' THEN 3 ELSE 4 END, c.email`, emailID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los participantes: %w", err)
+	}
+	defer rows.Close()
+
+	participants := []model.Participant{}
+	for rows.Next() {
+		var participant model.Participant
+		if err := rows.Scan(&participant.ContactID, &participant.Email, &participant.Name, &participant.Role); err != nil {
+			return nil, fmt.Errorf("error al leer un participante: %w", err)
+		}
+		participants = append(participants, participant)
+	}
+
+	return participants, rows.Err()
+}
+
+// locations devuelve las carpetas y archivos en los que apareció un correo.
+func (r *EmailRepository) locations(emailID int) ([]model.MessageLocation, error) {
+	rows, err := r.db.Query(`SELECT id, email_id, folder, file_path, COALESCE(owner, '') FROM message_locations
+		WHERE email_id = ? ORDER BY id`, emailID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener las ubicaciones: %w", err)
+	}
+	defer rows.Close()
+
+	locations := []model.MessageLocation{}
+	for rows.Next() {
+		var location model.MessageLocation
+		if err := rows.Scan(&location.ID, &location.EmailID, &location.Folder, &location.FilePath, &location.Owner); err != nil {
+			return nil, fmt.Errorf("error al leer una ubicación: %w", err)
+		}
+		locations = append(locations, location)
+	}
+
+	return locations, rows.Err()
+}
+
+// FillLocationOwners completa el dueño de las ubicaciones registradas antes de
+// la migración 0010 a partir de su ruta dentro de baseDir, igual que la ingesta
+// para los correos nuevos. Las rutas que no están dentro de baseDir quedan sin
+// dueño. Devuelve la cantidad de ubicaciones actualizadas.
+func (r *EmailRepository) FillLocationOwners(baseDir string) (int, error) {
+	rows, err := r.db.Query(`SELECT id, file_path FROM message_locations WHERE owner IS NULL`)
+	if err != nil {
+		return 0, fmt.Errorf("error al obtener las ubicaciones sin dueño: %w", err)
+	}
+	owners := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var filePath string
+		if err := rows.Scan(&id, &filePath); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("error al leer una ubicación: %w", err)
+		}
+		if owner := service.MailboxOwner(baseDir, filePath); owner != "" {
+			owners[id] = owner
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(owners) == 0 {
+		return 0, nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	for id, owner := range owners {
+		if _, err := tx.Exec(`UPDATE message_locations SET owner = ? WHERE id = ?`, owner, id); err != nil {
+			return 0, fmt.Errorf("error al guardar el dueño de la ubicación %d: %w", id, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(owners), nil
+}
+
+// participantCondition filtra los correos con un participante en alguno de los roles indicados.
+func participantCondition(address string, roles ...string) (string, []interface{}) {
+	condition := `EXISTS (SELECT 1 FROM email_participants ep JOIN contacts c ON c.id = ep.contact_id
+			WHERE ep.email_id = emails.id AND c.email = ?`
+	args := []interface{}{strings.ToLower(address)}
+	if len(roles) > 0 {
+		condition += ` AND ep.role IN ` + rowPlaceholders(1, len(roles))
+		for _, role := range roles {
+			args = append(args, role)
+		}
+	}
+	return condition + `)`, args
+}
+
+// LikeEscaper escapa los comodines de LIKE en los valores del usuario. Se usa
+// "!" como carácter de escape porque la barra invertida se interpreta distinto
+// en los literales de MySQL y de SQLite.
+var LikeEscaper = strings.NewReplacer(`!`, `!!`, `%`, `!%`, `_`, `!_`)
+
+// filterWhere construye la cláusula WHERE parametrizada correspondiente al filtro.
+func filterWhere(f service.EmailFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, values ...interface{}) {
+		conditions = append(conditions, condition)
+		args = append(args, values...)
+	}
+
+	if f.Participant != "" {
+		var roles []string
+		if f.Role != "" {
+			roles = []string{f.Role}
+		}
+		condition, values := participantCondition(f.Participant, roles...)
+		add(condition, values...)
+	}
+	if f.Sender != "" {
+		condition, values := participantCondition(f.Sender, model.RoleFrom)
+		add(condition, values...)
+	}
+	if f.Receiver != "" {
+		condition, values := participantCondition(f.Receiver, model.RoleTo, model.RoleCc, model.RoleBcc)
+		add(condition, values...)
+	}
+	if f.Folder != "" {
+		// X-Folder suele ser una ruta (\usuario1_folder\inbox): se acepta también el último componente
+		folder := LikeEscaper.Replace(f.Folder)
+		add(`EXISTS (SELECT 1 FROM message_locations l WHERE l.email_id = emails.id
+			AND (l.folder = ? OR l.folder LIKE ? ESCAPE '!' OR l.folder LIKE ? ESCAPE '!'))`, f.Folder, `%\`+folder, `%/`+folder)
+	}
+	if f.Owner != "" {
+		add(`EXISTS (SELECT 1 FROM message_locations l WHERE l.email_id = emails.id AND l.owner = ?)`, f.Owner)
+	}
+	if !f.DateFrom.IsZero() {
+		add(`emails.date >= ?`, f.DateFrom.UTC())
+	}
+	if !f.DateTo.IsZero() {
+		add(`emails.date < ?`, f.DateTo.UTC())
+	}
+	if f.HasBody != nil {
+		if *f.HasBody {
+			add(`emails.body IS NOT NULL AND emails.body <> ''`)
+		} else {
+			add(`(emails.body IS NULL OR emails.body = '')`)
+		}
+	}
+	if f.ContentType != "" {
+		add(`emails.content_type LIKE ? ESCAPE '!'`, LikeEscaper.Replace(f.ContentType)+"%")
+	}
+	if f.ThreadID != 0 {
+		add(`emails.thread_id = ?`, f.ThreadID)
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// orderBy construye la cláusula ORDER BY. Siempre se desempata por id para que
+// las páginas no repitan ni salteen correos.
+func orderBy(s service.EmailSort) string {
+	direction := " ASC"
+	if s.Desc {
+		direction = " DESC"
+	}
+	column, ok := sortColumns[s.Field]
+	if !ok || column == "emails.id" {
+		return " ORDER BY emails.id" + direction
+	}
+	return " ORDER BY " + column + direction + ", emails.id" + direction
+}
+
+// cursorCondition devuelve la condición que selecciona los correos que siguen
+// al cursor en el orden cursor.ReadOrder(). MySQL y SQLite ubican los NULL
+// antes que cualquier valor en orden ascendente.
+func cursorCondition(c service.Cursor) (string, []interface{}, error) {
+	order := c.ReadOrder()
+	op := ">"
+	if order.Desc {
+		op = "<"
+	}
+	column, ok := sortColumns[order.Field]
+	if !ok || column == "emails.id" {
+		return "emails.id " + op + " ?", []interface{}{c.ID}, nil
+	}
+
+	if c.Value == nil {
+		if order.Desc {
+			return "(" + column + " IS NULL AND emails.id < ?)", []interface{}{c.ID}, nil
+		}
+		return "((" + column + " IS NULL AND emails.id > ?) OR " + column + " IS NOT NULL)", []interface{}{c.ID}, nil
+	}
+
+	var value interface{} = *c.Value
+	if order.Field == "date" {
+		date, err := c.Date()
+		if err != nil {
+			return "", nil, err
+		}
+		value = date
+	}
+
+	condition := "(" + column + " " + op + " ? OR (" + column + " = ? AND emails.id " + op + " ?)"
+	if order.Desc {
+		condition += " OR " + column + " IS NULL"
+	}
+	return condition + ")", []interface{}{value, value, c.ID}, nil
+}
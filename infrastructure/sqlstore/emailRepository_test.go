@@ -0,0 +1,204 @@
+package sqlstore_test
+
+import (
+	"database/sql"
+	"fmt"
+	"project/domain/model"
+	"project/domain/service"
+	"project/infrastructure/memory"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// listEmails crea correos con remitentes en distinta capitalización, fechas
+// nulas y repetidas, varias carpetas y dueños, y correos sin cuerpo.
+func listEmails() []model.Email {
+	senders := []string{"bob@example.com", "Alice@example.com", "carol@example.com", "alice@example.com", "BOB@example.com"}
+	owners := []string{"alice", "bob"}
+	folders := []string{`\alice\inbox`, `\alice\sent`, `\bob\inbox_old`, "bob/in%box"}
+	contentTypes := []string{"text/plain; charset=us-ascii", "text/html", "TEXT/PLAIN"}
+
+	var emails []model.Email
+	for i := 0; i < 30; i++ {
+		email := model.Email{
+			MessageID:   fmt.Sprintf("<%d@example.com>", i),
+			ContentHash: fmt.Sprint(i),
+			Sender:      senders[i%len(senders)],
+			Subject:     []string{"Presupuesto", "reunión", "Agenda", "presupuesto"}[i%4],
+			ContentType: contentTypes[i%len(contentTypes)],
+			Folder:      folders[i%len(folders)],
+		}
+		if i%7 != 0 {
+			email.Body = "Texto"
+		}
+		if i%5 != 0 {
+			email.Date = sql.NullTime{Time: time.Date(2001, 5, 1+i%4, i%2, 0, 0, 0, time.UTC), Valid: true}
+		}
+		email.Participants = []model.Participant{
+			{Email: email.Sender, Role: model.RoleFrom},
+			{Email: senders[(i+1)%len(senders)], Role: model.RoleTo},
+		}
+		if i%3 == 0 {
+			email.Participants = append(email.Participants, model.Participant{Email: "dave@example.com", Role: model.RoleCc})
+		}
+		email.Locations = []model.MessageLocation{{
+			Folder:   email.Folder,
+			FilePath: fmt.Sprintf("%s/%d.", owners[i%2], i),
+			Owner:    owners[i%2],
+		}}
+		emails = append(emails, email)
+	}
+	return emails
+}
+
+func emailIDs(emails []model.Email) []int {
+	result := make([]int, len(emails))
+	for i, email := range emails {
+		result[i] = email.ID
+	}
+	return result
+}
+
+// El repositorio en memoria que usan las pruebas de los servicios filtra,
+// ordena y pagina igual que las consultas SQL.
+func TestEmailRepositoryListMatchesMemory(t *testing.T) {
+	db := newTestDB(t)
+	repositories := map[string]service.EmailRepository{"sqlite": db.Emails(), "memoria": memory.NewEmailRepository()}
+	for name, repository := range repositories {
+		if _, err := repository.Insert(listEmails()); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+
+	noBody := false
+	filters := []struct {
+		name   string
+		filter service.EmailFilter
+	}{
+		{"sin filtro", service.EmailFilter{}},
+		{"participante", service.EmailFilter{Participant: "BOB@example.com"}},
+		{"participante en copia", service.EmailFilter{Participant: "dave@example.com", Role: model.RoleCc}},
+		{"remitente", service.EmailFilter{Sender: "alice@EXAMPLE.com"}},
+		{"destinatario", service.EmailFilter{Receiver: "dave@example.com"}},
+		{"último componente de la carpeta", service.EmailFilter{Folder: "inbox"}},
+		{"carpeta completa", service.EmailFilter{Folder: `\alice\sent`}},
+		{"comodines de LIKE", service.EmailFilter{Folder: "in_ox"}},
+		{"carpeta con %", service.EmailFilter{Folder: "in%box"}},
+		{"dueño", service.EmailFilter{Owner: "bob"}},
+		{"fechas", service.EmailFilter{
+			DateFrom: time.Date(2001, 5, 2, 0, 0, 0, 0, time.UTC),
+			DateTo:   time.Date(2001, 5, 4, 0, 0, 0, 0, time.UTC),
+		}},
+		{"sin cuerpo", service.EmailFilter{HasBody: &noBody}},
+		{"tipo de contenido", service.EmailFilter{ContentType: "text/plain"}},
+	}
+
+	for _, f := range filters {
+		for _, field := range service.SortFields() {
+			for _, desc := range []bool{false, true} {
+				order := service.EmailSort{Field: field, Desc: desc}
+				t.Run(fmt.Sprintf("%s/%s/%v", f.name, field, desc), func(t *testing.T) {
+					results := make(map[string][][]int)
+					for name, repository := range repositories {
+						all, err := repository.List(f.filter, order, 0, 0)
+						if err != nil {
+							t.Fatalf("%s: %v", name, err)
+						}
+						count, err := repository.Count(f.filter)
+						if err != nil {
+							t.Fatalf("%s: %v", name, err)
+						}
+						if count != len(all) {
+							t.Errorf("%s: Count() = %d, List() devolvió %d correos", name, count, len(all))
+						}
+						page, err := repository.List(f.filter, order, 3, 4)
+						if err != nil {
+							t.Fatalf("%s: %v", name, err)
+						}
+						result := [][]int{emailIDs(all), emailIDs(page)}
+
+						// Desde el tercer correo hacia adelante y hacia atrás
+						if len(all) > 3 {
+							after, err := repository.ListAfter(f.filter, order.CursorAfter(all[2]), 5)
+							if err != nil {
+								t.Fatalf("%s: %v", name, err)
+							}
+							before, err := repository.ListAfter(f.filter, order.CursorBefore(all[2]), 5)
+							if err != nil {
+								t.Fatalf("%s: %v", name, err)
+							}
+							result = append(result, emailIDs(after), emailIDs(before))
+						}
+						results[name] = result
+					}
+					if !reflect.DeepEqual(results["sqlite"], results["memoria"]) {
+						t.Errorf("\nsqlite  %v\nmemoria %v", results["sqlite"], results["memoria"])
+					}
+				})
+			}
+		}
+	}
+}
+
+// Las copias de un correo (mismo Message-ID y hash del cuerpo) se guardan una
+// sola vez y suman sus ubicaciones; el Message-ID distingue mayúsculas.
+func TestEmailRepositoryInsertDeduplicates(t *testing.T) {
+	db := newTestDB(t)
+	repositories := map[string]service.EmailRepository{"sqlite": db.Emails(), "memoria": memory.NewEmailRepository()}
+
+	copyOf := func(messageID, hash, filePath string) model.Email {
+		return model.Email{
+			MessageID:   messageID,
+			ContentHash: hash,
+			Locations:   []model.MessageLocation{{Folder: "inbox", FilePath: filePath}},
+		}
+	}
+	batches := []struct {
+		emails     []model.Email
+		created    int
+		duplicates int
+	}{
+		// La segunda copia llega en el mismo lote
+		{[]model.Email{copyOf("<1@enron.com>", "a", "alice/inbox/1."), copyOf("<1@enron.com>", "a", "bob/inbox/1.")}, 1, 1},
+		// Otra copia en un lote posterior, y el mismo archivo otra vez
+		{[]model.Email{copyOf("<1@enron.com>", "a", "carol/inbox/1."), copyOf("<1@enron.com>", "a", "alice/inbox/1.")}, 0, 2},
+		// Otro cuerpo u otra capitalización del Message-ID son correos distintos
+		{[]model.Email{copyOf("<1@enron.com>", "b", "alice/inbox/2."), copyOf("<1@ENRON.com>", "a", "alice/inbox/3.")}, 2, 0},
+	}
+
+	for name, repository := range repositories {
+		for i, batch := range batches {
+			result, err := repository.Insert(batch.emails)
+			if err != nil {
+				t.Fatalf("%s: lote %d: %v", name, i+1, err)
+			}
+			if len(result.Created) != batch.created || result.Duplicates != batch.duplicates {
+				t.Errorf("%s: lote %d: %d nuevos y %d copias, se esperaban %d y %d",
+					name, i+1, len(result.Created), result.Duplicates, batch.created, batch.duplicates)
+			}
+			for _, email := range batch.emails {
+				if email.ID == 0 {
+					t.Errorf("%s: lote %d: el correo %s no recibió su ID", name, i+1, email.MessageID)
+				}
+			}
+		}
+
+		if count, err := repository.Count(service.EmailFilter{}); err != nil || count != 3 {
+			t.Errorf("%s: Count() = %d, %v; se esperaban 3 correos", name, count, err)
+		}
+		email, err := repository.Get(batches[0].emails[0].ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var paths []string
+		for _, location := range email.Locations {
+			paths = append(paths, location.FilePath)
+		}
+		sort.Strings(paths)
+		if want := []string{"alice/inbox/1.", "bob/inbox/1.", "carol/inbox/1."}; !reflect.DeepEqual(paths, want) {
+			t.Errorf("%s: ubicaciones %v, se esperaba %v", name, paths, want)
+		}
+	}
+}
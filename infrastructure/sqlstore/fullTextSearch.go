@@ -0,0 +1,165 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"fmt"
+	"project/domain/model"
+	"project/domain/searchquery"
+	"project/domain/service"
+	"strings"
+)
+
+// Largo en caracteres del fragmento resaltado del cuerpo cuando el motor no
+// marca las coincidencias, como el de ZincSearch
+const highlightFragmentSize = 150
+
+// FullTextSearch busca correos con el índice de texto completo de la base de
+// datos, con la misma sintaxis de consulta y el mismo orden que ZincSearch. Se
+// usa como respaldo cuando ZincSearch no responde: filtra por las facetas
+// elegidas pero no calcula sus valores.
+type FullTextSearch struct {
+	db    *sql.DB
+	name  string
+	index TextIndex
+}
+
+// NewFullTextSearch crea la búsqueda sobre el índice de texto del motor.
+func NewFullTextSearch(db *sql.DB, name string, index TextIndex) *FullTextSearch {
+	return &FullTextSearch{db: db, name: name, index: index}
+}
+
+// FullTextSearch devuelve la búsqueda sobre el índice de texto de la
+// conexión, o nil si el motor no tiene uno.
+func (db *DB) FullTextSearch() *FullTextSearch {
+	if db.Dialect.TextIndex == nil {
+		return nil
+	}
+	return NewFullTextSearch(db.DB, db.Dialect.Name, db.Dialect.TextIndex)
+}
+
+// Name devuelve el nombre del motor de la base de datos.
+func (s *FullTextSearch) Name() string {
+	return s.name
+}
+
+// Search devuelve los correos que cumplen la consulta y las facetas elegidas,
+// de mayor a menor relevancia, con las coincidencias del asunto y el cuerpo
+// marcadas con searchquery.HighlightPreTag y HighlightPostTag.
+func (s *FullTextSearch) Search(req service.SearchRequest) (*service.SearchResult, error) {
+	c := &SearchCompiler{index: s.index}
+	where := c.Condition(req.Query, false)
+	if facets, facetArgs := facetWhere(req.Options); facets != "" {
+		where += " AND " + facets
+		c.Args = append(c.Args, facetArgs...)
+	}
+
+	result := &service.SearchResult{Hits: []model.SearchHit{}, Backend: s.name}
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM emails WHERE `+where, c.Args...).Scan(&result.Total); err != nil {
+		return nil, fmt.Errorf("error al contar los resultados de la búsqueda: %w", err)
+	}
+	if req.Options.Facets {
+		result.Facets = &model.Facets{
+			Senders:   []model.FacetBucket{},
+			Receivers: []model.FacetBucket{},
+			Folders:   []model.FacetBucket{},
+			Dates:     []model.FacetBucket{},
+		}
+	}
+
+	// Los correos que solo cumplen condiciones sobre otros campos quedan con relevancia 0
+	ranked, args := s.index.Rank(c.Ranked)
+	query, pageArgs := searchPage(`SELECT `+EmailColumns+`, `+ranked+` WHERE `+where, req)
+	args = append(append(args, c.Args...), pageArgs...)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar en el índice de texto: %w", err)
+	}
+	defer rows.Close()
+
+	words := searchquery.Words(req.Query)
+	for rows.Next() {
+		var hit model.SearchHit
+		var subject, body string
+		hit.Email, err = ScanEmail(rows, &hit.Score, &subject, &body)
+		if err != nil {
+			return nil, err
+		}
+		if subject == "" && body == "" && len(words) > 0 {
+			subject = searchquery.Mark(hit.Subject, words)
+			body = searchquery.Mark(searchquery.Snippet(hit.Body, []string{searchquery.Mark(hit.Body, words)}, highlightFragmentSize), words)
+		} else {
+			// Los fragmentos del índice marcan el texto original, sin escapar
+			subject = searchquery.EscapeHighlight(subject)
+			body = searchquery.EscapeHighlight(body)
+		}
+		hit.Highlights = make(map[string][]string)
+		if strings.Contains(subject, searchquery.HighlightPreTag) {
+			hit.Highlights["subject"] = []string{subject}
+		}
+		if strings.Contains(body, searchquery.HighlightPreTag) {
+			hit.Highlights["body"] = []string{body}
+		}
+		result.Hits = append(result.Hits, hit)
+	}
+	return result, rows.Err()
+}
+
+// searchPage ordena la consulta por relevancia y luego por id, y agrega la
+// posición del cursor (o el OFFSET) y el límite de la página.
+func searchPage(query string, req service.SearchRequest) (string, []interface{}) {
+	query = `SELECT * FROM (` + query + `) AS hits`
+	cursor := req.After
+	if cursor == nil {
+		return query + ` ORDER BY hits.score DESC, hits.id ASC LIMIT ? OFFSET ?`, []interface{}{req.Size, req.From}
+	}
+
+	args := []interface{}{cursor.Score, cursor.Score, cursor.ID, req.Size}
+	if cursor.Before {
+		return query + ` WHERE hits.score > ? OR (hits.score = ? AND hits.id < ?)
+			ORDER BY hits.score ASC, hits.id DESC LIMIT ?`, args
+	}
+	return query + ` WHERE hits.score < ? OR (hits.score = ? AND hits.id > ?)
+		ORDER BY hits.score DESC, hits.id ASC LIMIT ?`, args
+}
+
+// facetWhere convierte las facetas elegidas en condiciones sobre emails. Los
+// valores de una misma faceta se combinan con OR y las facetas entre sí con AND.
+func facetWhere(o service.SearchOptions) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	addFilter := func(parts []string) {
+		if len(parts) > 0 {
+			conditions = append(conditions, "("+strings.Join(parts, " OR ")+")")
+		}
+	}
+
+	participants := func(addresses []string, roles ...string) []string {
+		parts := make([]string, len(addresses))
+		for i, address := range addresses {
+			condition, values := participantCondition(address, roles...)
+			parts[i] = condition
+			args = append(args, values...)
+		}
+		return parts
+	}
+	addFilter(participants(o.Filters.Senders, model.RoleFrom))
+	addFilter(participants(o.Filters.Receivers, model.RoleTo, model.RoleCc))
+
+	folders := make([]string, len(o.Filters.Folders))
+	for i, folder := range o.Filters.Folders {
+		folders[i] = "emails.folder = ?"
+		args = append(args, folder)
+	}
+	addFilter(folders)
+
+	dates := make([]string, len(o.Filters.Dates))
+	for i, start := range o.Filters.Dates {
+		from, to := o.FacetDateRange(start)
+		dates[i] = "(emails.date >= ? AND emails.date < ?)"
+		args = append(args, from, to)
+	}
+	addFilter(dates)
+
+	return strings.Join(conditions, " AND "), args
+}
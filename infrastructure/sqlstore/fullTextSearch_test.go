@@ -0,0 +1,87 @@
+package sqlstore_test
+
+import (
+	"database/sql"
+	"fmt"
+	"project/domain/model"
+	"project/domain/searchquery"
+	"project/domain/service"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// La búsqueda en la base filtra por las facetas elegidas igual que ZincSearch:
+// los valores de una faceta con OR y las facetas entre sí con AND.
+func TestFullTextSearchFacetFilters(t *testing.T) {
+	db := newTestDB(t)
+
+	date := func(month int) sql.NullTime {
+		return sql.NullTime{Time: time.Date(2001, time.Month(month), 15, 0, 0, 0, 0, time.UTC), Valid: true}
+	}
+	email := func(i int, sender, receiver, folder string, date sql.NullTime) model.Email {
+		return model.Email{
+			MessageID:   fmt.Sprintf("<%d@enron.com>", i),
+			ContentHash: fmt.Sprint(i),
+			Sender:      sender,
+			Folder:      folder,
+			Subject:     "contract",
+			Date:        date,
+			Participants: []model.Participant{
+				{Email: sender, Role: model.RoleFrom},
+				{Email: receiver, Role: model.RoleTo},
+			},
+		}
+	}
+	emails := []model.Email{
+		email(1, "alice@enron.com", "bob@enron.com", "inbox", date(1)),
+		email(2, "bob@enron.com", "alice@enron.com", "sent", date(4)),
+		email(3, "alice@enron.com", "carol@enron.com", "sent", date(5)),
+		email(4, "carol@enron.com", "bob@enron.com", "inbox", sql.NullTime{}),
+		email(5, "alice@enron.com", "bob@enron.com", "archive", date(7)),
+	}
+	if _, err := db.Emails().Insert(emails); err != nil {
+		t.Fatal(err)
+	}
+
+	query, err := searchquery.Parse("contract")
+	if err != nil {
+		t.Fatal(err)
+	}
+	quarter := time.Date(2001, 4, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		filters service.FacetFilters
+		want    []int
+	}{
+		{"sin filtros", service.FacetFilters{}, []int{1, 2, 3, 4, 5}},
+		{"remitente sin distinguir mayúsculas", service.FacetFilters{Senders: []string{"ALICE@enron.com"}}, []int{1, 3, 5}},
+		{"destinatario", service.FacetFilters{Receivers: []string{"bob@enron.com"}}, []int{1, 4, 5}},
+		{"dos carpetas", service.FacetFilters{Folders: []string{"inbox", "archive"}}, []int{1, 4, 5}},
+		{"trimestre", service.FacetFilters{Dates: []time.Time{quarter}}, []int{2, 3}},
+		{"remitente y carpeta", service.FacetFilters{Senders: []string{"alice@enron.com"}, Folders: []string{"sent", "inbox"}}, []int{1, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := db.FullTextSearch().Search(service.SearchRequest{
+				Query:   query,
+				Size:    10,
+				Options: service.SearchOptions{Facets: true, Interval: "quarter", Filters: tt.filters},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []int
+			for _, hit := range result.Hits {
+				got = append(got, hit.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) || result.Total != len(tt.want) {
+				t.Errorf("resultados %v (total %d), se esperaba %v", got, result.Total, tt.want)
+			}
+			// La base no calcula los valores de las facetas
+			if result.Facets == nil || len(result.Facets.Senders) != 0 {
+				t.Errorf("facetas %+v, se esperaban vacías", result.Facets)
+			}
+		})
+	}
+}
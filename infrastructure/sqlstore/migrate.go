@@ -0,0 +1,445 @@
+package sqlstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Nombre de los archivos: NNNN_nombre.up.sql, o NNNN_nombre.<motor>.up.sql para
+// la versión de un solo motor, que reemplaza a la común.
+var migrationFileRe = regexp.MustCompile(`^(\d+)_(\w+)(?:\.([a-z0-9]+))?\.(up|down)\.sql$`)
+
+// Marcadores {{nombre}} de las migraciones comunes
+var schemaTypeRe = regexp.MustCompile(`\{\{(\w*)\}\}`)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migration es un cambio versionado del esquema.
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string // SHA-256 del script up ya adaptado al motor
+}
+
+// MigrationStatus indica si una migración está aplicada.
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt sql.NullTime
+}
+
+// Migrations devuelve las migraciones embebidas para el dialecto, ordenadas
+// por versión. Las migraciones son comunes a todos los
+// motores: los marcadores {{nombre}} se reemplazan con Dialect.SchemaTypes, y
+// lo que no se puede escribir igual en todos (índices de texto completo, DROP
+// INDEX) va en archivos NNNN_nombre.<motor>.up.sql o .down.sql, que reemplazan
+// al archivo común de esa versión.
+func Migrations(dialect Dialect) ([]Migration, error) {
+	return loadMigrations(migrationFiles, dialect)
+}
+
+// loadMigrations lee las migraciones del directorio migrations de fsys.
+func loadMigrations(fsys fs.FS, dialect Dialect) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo las migraciones: %w", err)
+	}
+
+	// Archivo elegido para cada versión y dirección (up o down)
+	type script struct {
+		file string
+		own  bool // Exclusivo del motor: tiene prioridad sobre el común
+	}
+	byVersion := make(map[int]*Migration)
+	scripts := make(map[int]map[string]script)
+	for _, entry := range entries {
+		m := migrationFileRe.FindStringSubmatch(entry.Name())
+		if m == nil {
+			return nil, fmt.Errorf("nombre de migración inválido: %s", entry.Name())
+		}
+		if m[3] != "" && m[3] != dialect.Name {
+			continue
+		}
+
+		version, _ := strconv.Atoi(m[1])
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = migration
+			scripts[version] = make(map[string]script)
+		} else if migration.Name != m[2] {
+			return nil, fmt.Errorf("la versión %d tiene nombres distintos: %s y %s", version, migration.Name, m[2])
+		}
+
+		if current, ok := scripts[version][m[4]]; !ok || !current.own {
+			scripts[version][m[4]] = script{file: entry.Name(), own: m[3] != ""}
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for version, migration := range byVersion {
+		for direction, s := range scripts[version] {
+			content, err := fs.ReadFile(fsys, path.Join("migrations", s.file))
+			if err != nil {
+				return nil, fmt.Errorf("error leyendo %s: %w", s.file, err)
+			}
+			rendered, err := renderMigration(string(content), dialect)
+			if err != nil {
+				return nil, fmt.Errorf("error en %s: %w", s.file, err)
+			}
+
+			if direction == "up" {
+				migration.Up = rendered
+				sum := sha256.Sum256([]byte(rendered))
+				migration.Checksum = hex.EncodeToString(sum[:])
+			} else {
+				migration.Down = rendered
+			}
+		}
+
+		if migration.Up == "" {
+			return nil, fmt.Errorf("la migración %d no tiene archivo up para %s", migration.Version, dialect.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// renderMigration reemplaza los marcadores {{nombre}} del script con los
+// valores del dialecto. Un marcador que el dialecto no define es un error,
+// para no ejecutar una sentencia incompleta.
+func renderMigration(content string, dialect Dialect) (string, error) {
+	var missing string
+	rendered := schemaTypeRe.ReplaceAllStringFunc(content, func(marker string) string {
+		name := schemaTypeRe.FindStringSubmatch(marker)[1]
+		value, ok := dialect.SchemaTypes[name]
+		if !ok && missing == "" {
+			missing = name
+		}
+		return value
+	})
+	if missing != "" {
+		return "", fmt.Errorf("el dialecto %s no define el marcador {{%s}}", dialect.Name, missing)
+	}
+	return rendered, nil
+}
+
+// Migrator aplica y revierte migraciones sobre una base de datos.
+//
+// Si el motor admite DDL transaccional (SQLite), cada migración y su registro
+// en schema_migrations se aplican en una misma transacción: si una sentencia
+// falla, el esquema queda como estaba. En MySQL cada sentencia DDL confirma la
+// transacción en curso, por lo que una migración que falla a mitad deja el
+// esquema cambiado en parte y sin registrar; por eso las migraciones de MySQL
+// deben ser idempotentes (IF NOT EXISTS, IF EXISTS) o tener una sola sentencia
+// DDL, para poder volver a ejecutarlas después de corregir el error.
+type Migrator struct {
+	db            *sql.DB
+	migrations    []Migration
+	lock          Locker
+	transactional bool
+}
+
+// NewMigrator crea un Migrator con las migraciones embebidas, adaptadas al
+// dialecto, y su lock.
+func NewMigrator(db *sql.DB, dialect Dialect) (*Migrator, error) {
+	migrations, err := Migrations(dialect)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations, lock: dialect.Lock, transactional: dialect.TransactionalDDL}, nil
+}
+
+// Status devuelve el estado de cada migración y verifica que las aplicadas no
+// hayan cambiado desde que se ejecutaron.
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	ctx := context.Background()
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := ensureMigrationsTable(ctx, conn); err != nil {
+		return nil, err
+	}
+	return m.status(ctx, conn)
+}
+
+// Up aplica las migraciones pendientes hasta la versión indicada (0 = todas).
+// Devuelve las migraciones aplicadas.
+func (m *Migrator) Up(target int) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(func(ctx context.Context, conn *sql.Conn) error {
+		statuses, err := m.status(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, status := range statuses {
+			if status.Applied || (target > 0 && status.Version > target) {
+				continue
+			}
+			err := m.run(ctx, conn, status.Up, `INSERT INTO schema_migrations (version, name, checksum, applied_at)
+				VALUES (?, ?, ?, ?)`, status.Version, status.Name, status.Checksum, time.Now().UTC())
+			if err != nil {
+				return fmt.Errorf("error aplicando la migración %d_%s: %w", status.Version, status.Name, err)
+			}
+			applied = append(applied, status.Migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down revierte las últimas steps migraciones aplicadas.
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.withLock(func(ctx context.Context, conn *sql.Conn) error {
+		statuses, err := m.status(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(statuses) - 1; i >= 0 && len(reverted) < steps; i-- {
+			status := statuses[i]
+			if !status.Applied {
+				continue
+			}
+			if status.Down == "" {
+				return fmt.Errorf("la migración %d_%s no se puede revertir", status.Version, status.Name)
+			}
+			err := m.run(ctx, conn, status.Down, `DELETE FROM schema_migrations WHERE version = ?`, status.Version)
+			if err != nil {
+				return fmt.Errorf("error revirtiendo la migración %d_%s: %w", status.Version, status.Name, err)
+			}
+			reverted = append(reverted, status.Migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// withLock ejecuta fn con el lock de migraciones tomado en una única conexión.
+func (m *Migrator) withLock(fn func(ctx context.Context, conn *sql.Conn) error) error {
+	ctx := context.Background()
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if m.lock != nil {
+		release, err := m.lock(ctx, conn)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+
+	if err := ensureMigrationsTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(ctx, conn)
+}
+
+func (m *Migrator) status(ctx context.Context, conn *sql.Conn) ([]MigrationStatus, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	type appliedMigration struct {
+		checksum  string
+		appliedAt sql.NullTime
+	}
+	applied := make(map[int]appliedMigration)
+	for rows.Next() {
+		var version int
+		var a appliedMigration
+		if err := rows.Scan(&version, &a.checksum, &a.appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = a
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	known := make(map[int]bool)
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = true
+		status := MigrationStatus{Migration: migration}
+		if a, ok := applied[migration.Version]; ok {
+			if a.checksum != migration.Checksum {
+				return nil, fmt.Errorf("la migración %d_%s cambió después de aplicarse (checksum %s, esperado %s)",
+					migration.Version, migration.Name, a.checksum, migration.Checksum)
+			}
+			status.Applied = true
+			status.AppliedAt = a.appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	for version := range applied {
+		if !known[version] {
+			return nil, fmt.Errorf("la base de datos tiene aplicada la migración %d, que esta versión no conoce", version)
+		}
+	}
+
+	return statuses, nil
+}
+
+func ensureMigrationsTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		checksum CHAR(64) NOT NULL,
+		applied_at DATETIME NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("error creando schema_migrations: %w", err)
+	}
+	return nil
+}
+
+// execer es una conexión o una transacción.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// run ejecuta el script de una migración y la sentencia que lo registra en
+// schema_migrations, en una misma transacción si el motor lo admite.
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, script, record string, args ...interface{}) error {
+	if !m.transactional {
+		if err := execScript(ctx, conn, script); err != nil {
+			return err
+		}
+		if _, err := conn.ExecContext(ctx, record, args...); err != nil {
+			return fmt.Errorf("error registrando en schema_migrations: %w", err)
+		}
+		return nil
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := execScript(ctx, tx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return fmt.Errorf("error registrando en schema_migrations: %w", err)
+	}
+	return tx.Commit()
+}
+
+// execScript ejecuta cada sentencia del script por separado, ya que el driver
+// no admite varias sentencias en una misma llamada sin multiStatements.
+func execScript(ctx context.Context, conn execer, script string) error {
+	for _, statement := range splitStatements(script) {
+		if _, err := conn.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("%w\n%s", err, statement)
+		}
+	}
+	return nil
+}
+
+// splitStatements separa un script SQL en sentencias por ";" y descarta los
+// comentarios "--". No corta dentro de literales, identificadores entre
+// comillas ni bloques BEGIN … END, como el cuerpo de un trigger; CASE … END
+// cuenta como bloque para que su END no cierre el BEGIN.
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	flush := func() {
+		if statement := strings.TrimSpace(current.String()); statement != "" {
+			statements = append(statements, statement)
+		}
+		current.Reset()
+	}
+
+	depth := 0     // Bloques BEGIN o CASE abiertos
+	var quote byte // Comilla del literal o identificador abierto
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case quote != 0:
+			current.WriteByte(c)
+			if c == quote {
+				// Dos comillas seguidas son una comilla escapada
+				if i+1 < len(script) && script[i+1] == quote {
+					current.WriteByte(quote)
+					i++
+				} else {
+					quote = 0
+				}
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			current.WriteByte(c)
+		case c == '-' && strings.HasPrefix(script[i:], "--"):
+			for i+1 < len(script) && script[i+1] != '\n' {
+				i++
+			}
+		case isWordByte(c):
+			start := i
+			for i+1 < len(script) && isWordByte(script[i+1]) {
+				i++
+			}
+			word := script[start : i+1]
+			switch strings.ToUpper(word) {
+			case "BEGIN", "CASE":
+				depth++
+			case "END":
+				if depth > 0 {
+					depth--
+				}
+			}
+			current.WriteString(word)
+		case c == ';' && depth == 0:
+			flush()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	flush()
+
+	return statements
+}
+
+// isWordByte indica si c puede ser parte de una palabra clave o un identificador.
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// Pending devuelve las migraciones que faltan aplicar.
+func Pending(statuses []MigrationStatus) []MigrationStatus {
+	var pending []MigrationStatus
+	for _, status := range statuses {
+		if !status.Applied {
+			pending = append(pending, status)
+		}
+	}
+	return pending
+}
@@ -0,0 +1,120 @@
+package sqlstore
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestMigrationsDialectFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/0001_base.up.sql":         {Data: []byte("CREATE TABLE t (id {{id}});")},
+		"migrations/0001_base.down.sql":       {Data: []byte("DROP TABLE t;")},
+		"migrations/0002_index.up.sql":        {Data: []byte("CREATE INDEX i ON t (id);")},
+		"migrations/0002_index.a.down.sql":    {Data: []byte("DROP INDEX i ON t;")},
+		"migrations/0002_index.b.down.sql":    {Data: []byte("DROP INDEX IF EXISTS i;")},
+		"migrations/0003_search.a.up.sql":     {Data: []byte("ALTER TABLE t ADD FULLTEXT INDEX f (id);")},
+		"migrations/0003_search.b.up.sql":     {Data: []byte("CREATE VIRTUAL TABLE f USING fts5(id);")},
+		"migrations/0003_search.down.sql":     {Data: []byte("-- nada")},
+		"migrations/0004_override.up.sql":     {Data: []byte("común")},
+		"migrations/0004_override.a.up.sql":   {Data: []byte("propio")},
+		"migrations/0004_override.down.sql":   {Data: []byte("común")},
+		"migrations/0004_override.b.down.sql": {Data: []byte("propio de b")},
+	}
+
+	tests := []struct {
+		dialect string
+		want    map[int][2]string // Up y Down de cada versión
+	}{
+		{"a", map[int][2]string{
+			1: {"CREATE TABLE t (id INT AUTO_INCREMENT);", "DROP TABLE t;"},
+			2: {"CREATE INDEX i ON t (id);", "DROP INDEX i ON t;"},
+			3: {"ALTER TABLE t ADD FULLTEXT INDEX f (id);", "-- nada"},
+			4: {"propio", "común"},
+		}},
+		{"b", map[int][2]string{
+			1: {"CREATE TABLE t (id INTEGER);", "DROP TABLE t;"},
+			2: {"CREATE INDEX i ON t (id);", "DROP INDEX IF EXISTS i;"},
+			3: {"CREATE VIRTUAL TABLE f USING fts5(id);", "-- nada"},
+			4: {"común", "propio de b"},
+		}},
+	}
+	types := map[string]string{"a": "INT AUTO_INCREMENT", "b": "INTEGER"}
+
+	for _, tt := range tests {
+		t.Run(tt.dialect, func(t *testing.T) {
+			dialect := Dialect{Name: tt.dialect, SchemaTypes: map[string]string{"id": types[tt.dialect]}}
+			migrations, err := loadMigrations(fsys, dialect)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(migrations) != len(tt.want) {
+				t.Fatalf("se obtuvieron %d migraciones, se esperaban %d", len(migrations), len(tt.want))
+			}
+			for _, migration := range migrations {
+				want := tt.want[migration.Version]
+				if migration.Up != want[0] || migration.Down != want[1] {
+					t.Errorf("migración %d: up %q, down %q; se esperaba %q, %q",
+						migration.Version, migration.Up, migration.Down, want[0], want[1])
+				}
+			}
+		})
+	}
+}
+
+func TestMigrationsUnknownMarker(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/0001_base.up.sql": {Data: []byte("CREATE TABLE t (data {{json}});")},
+	}
+	_, err := loadMigrations(fsys, Dialect{Name: "a"})
+	if err == nil || !strings.Contains(err.Error(), "{{json}}") {
+		t.Fatalf("se esperaba un error por el marcador sin definir, se obtuvo %v", err)
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{
+			"comentarios y líneas vacías",
+			"-- Tabla\nCREATE TABLE t (id INT); -- fin\n\nCREATE INDEX i ON t (id);\n",
+			[]string{"CREATE TABLE t (id INT)", "CREATE INDEX i ON t (id)"},
+		},
+		{
+			"sentencia en varias líneas sin punto y coma final",
+			"ALTER TABLE t\n    ADD COLUMN a INT,\n    ADD COLUMN b INT",
+			[]string{"ALTER TABLE t\n    ADD COLUMN a INT,\n    ADD COLUMN b INT"},
+		},
+		{
+			"punto y coma y guiones en literales",
+			"INSERT INTO t VALUES ('a;b', 'it''s; -- no es comentario', \"c;d\");\nSELECT 1;",
+			[]string{"INSERT INTO t VALUES ('a;b', 'it''s; -- no es comentario', \"c;d\")", "SELECT 1"},
+		},
+		{
+			"trigger con varias sentencias",
+			"CREATE TRIGGER tr AFTER UPDATE ON t BEGIN\n    DELETE FROM u WHERE id = old.id;\n    INSERT INTO u VALUES (new.id);\nEND;\nSELECT 1;",
+			[]string{"CREATE TRIGGER tr AFTER UPDATE ON t BEGIN\n    DELETE FROM u WHERE id = old.id;\n    INSERT INTO u VALUES (new.id);\nEND", "SELECT 1"},
+		},
+		{
+			"CASE dentro de un trigger",
+			"CREATE TRIGGER tr AFTER INSERT ON t BEGIN\n    UPDATE t SET a = CASE WHEN new.b THEN 1 ELSE 0 END;\nEND;\nUPDATE t SET end_date = NULL;",
+			[]string{"CREATE TRIGGER tr AFTER INSERT ON t BEGIN\n    UPDATE t SET a = CASE WHEN new.b THEN 1 ELSE 0 END;\nEND", "UPDATE t SET end_date = NULL"},
+		},
+		{
+			"solo comentarios",
+			"-- Nada que hacer\n",
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitStatements(tt.script); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitStatements() = %q, se esperaba %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+-- Esquema inicial: la tabla emails tal como la crea el README. Las bases que ya
+-- la tienen solo registran esta migración; las columnas, tablas e índices que
+-- se agregaron después vienen en las migraciones siguientes.
+-- Los marcadores entre llaves dobles los reemplaza cada motor (ver Dialect.SchemaTypes):
+-- collate_nocase compara sin distinguir mayúsculas, como la intercalación por
+-- defecto de MySQL, en la que no agrega nada.
+
+CREATE TABLE IF NOT EXISTS emails (
+    id {{id}},
+    message_id VARCHAR(255) NOT NULL,
+    sender VARCHAR(255){{collate_nocase}},
+    receiver VARCHAR(255),
+    subject TEXT{{collate_nocase}},
+    mime_version VARCHAR(50),
+    content_type VARCHAR(255){{collate_nocase}},
+    encoding VARCHAR(50),
+    folder VARCHAR(255){{collate_nocase}},
+    body TEXT,
+    date DATETIME
+);
@@ -0,0 +1,15 @@
+ALTER TABLE emails
+    DROP COLUMN headers,
+    DROP COLUMN thread_id,
+    DROP COLUMN reference_ids,
+    DROP COLUMN in_reply_to,
+    DROP COLUMN date_offset,
+    DROP COLUMN date_raw,
+    DROP COLUMN bcc,
+    DROP COLUMN cc,
+    DROP COLUMN receiver_name,
+    DROP COLUMN sender_name,
+    DROP COLUMN content_hash,
+    MODIFY body TEXT,
+    MODIFY receiver VARCHAR(255),
+    MODIFY message_id VARCHAR(255) NOT NULL;
@@ -0,0 +1,18 @@
+-- Columnas de los encabezados que se guardan desde que la ingesta los interpreta.
+-- Los Message-ID distinguen mayúsculas: la clave única los compara byte a byte.
+-- Es una sola sentencia para que un error no deje la tabla cambiada en parte.
+ALTER TABLE emails
+    MODIFY message_id VARCHAR(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL,
+    MODIFY receiver TEXT,
+    MODIFY body MEDIUMTEXT,
+    ADD COLUMN content_hash CHAR(64) NOT NULL DEFAULT '' AFTER message_id,
+    ADD COLUMN sender_name VARCHAR(255) AFTER sender,
+    ADD COLUMN receiver_name TEXT AFTER receiver,
+    ADD COLUMN cc TEXT AFTER receiver_name,
+    ADD COLUMN bcc TEXT AFTER cc,
+    ADD COLUMN date_raw VARCHAR(255),
+    ADD COLUMN date_offset SMALLINT,
+    ADD COLUMN in_reply_to VARCHAR(255),
+    ADD COLUMN reference_ids TEXT,
+    ADD COLUMN thread_id INT,
+    ADD COLUMN headers JSON;
@@ -0,0 +1,11 @@
+ALTER TABLE emails DROP COLUMN headers;
+ALTER TABLE emails DROP COLUMN thread_id;
+ALTER TABLE emails DROP COLUMN reference_ids;
+ALTER TABLE emails DROP COLUMN in_reply_to;
+ALTER TABLE emails DROP COLUMN date_offset;
+ALTER TABLE emails DROP COLUMN date_raw;
+ALTER TABLE emails DROP COLUMN bcc;
+ALTER TABLE emails DROP COLUMN cc;
+ALTER TABLE emails DROP COLUMN receiver_name;
+ALTER TABLE emails DROP COLUMN sender_name;
+ALTER TABLE emails DROP COLUMN content_hash;
@@ -0,0 +1,14 @@
+-- Columnas de los encabezados que se guardan desde que la ingesta los interpreta.
+-- SQLite no distingue el largo de los tipos de texto y compara byte a byte por
+-- defecto, por lo que message_id, receiver y body no cambian.
+ALTER TABLE emails ADD COLUMN content_hash CHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE emails ADD COLUMN sender_name VARCHAR(255);
+ALTER TABLE emails ADD COLUMN receiver_name TEXT;
+ALTER TABLE emails ADD COLUMN cc TEXT;
+ALTER TABLE emails ADD COLUMN bcc TEXT;
+ALTER TABLE emails ADD COLUMN date_raw VARCHAR(255);
+ALTER TABLE emails ADD COLUMN date_offset SMALLINT;
+ALTER TABLE emails ADD COLUMN in_reply_to VARCHAR(255);
+ALTER TABLE emails ADD COLUMN reference_ids TEXT;
+ALTER TABLE emails ADD COLUMN thread_id INT;
+ALTER TABLE emails ADD COLUMN headers TEXT;
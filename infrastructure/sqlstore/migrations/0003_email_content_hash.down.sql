@@ -0,0 +1 @@
+-- Los hashes calculados y las copias eliminadas no se restauran.
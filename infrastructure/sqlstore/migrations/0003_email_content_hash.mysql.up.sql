@@ -0,0 +1,16 @@
+-- Completa content_hash de los correos cargados con el esquema del README, con
+-- la misma regla que la ingesta (ver contentHash): el SHA-256 del cuerpo, o del
+-- remitente, la fecha original y el cuerpo si el correo no tiene Message-ID.
+-- Después elimina las copias repetidas que impedirían crear la clave única y
+-- conserva la de menor id. Ambas sentencias se pueden repetir sin efecto.
+UPDATE emails
+SET content_hash = SHA2(CASE
+        WHEN message_id = '' THEN CONCAT(COALESCE(sender, ''), CHAR(10), COALESCE(date_raw, ''), CHAR(10), COALESCE(body, ''))
+        ELSE COALESCE(body, '')
+    END, 256)
+WHERE content_hash = '';
+
+DELETE FROM emails
+WHERE id NOT IN (
+    SELECT id FROM (SELECT MIN(id) AS id FROM emails GROUP BY message_id, content_hash) AS kept
+);
@@ -0,0 +1,2 @@
+-- Las bases SQLite se crean con estas migraciones y no tienen correos del
+-- esquema del README que completar.
@@ -0,0 +1 @@
+DROP INDEX uq_emails_message ON emails;
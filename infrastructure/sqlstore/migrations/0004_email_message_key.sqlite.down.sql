@@ -0,0 +1 @@
+DROP INDEX IF EXISTS uq_emails_message;
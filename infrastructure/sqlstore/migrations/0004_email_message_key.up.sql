@@ -0,0 +1,3 @@
+-- Un mismo mensaje (Message-ID y contenido) se guarda una sola vez. La clave
+-- también es el índice para buscar por message_id.
+CREATE UNIQUE INDEX uq_emails_message ON emails (message_id, content_hash);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS message_locations;
+DROP TABLE IF EXISTS threads;
+DROP TABLE IF EXISTS email_participants;
+DROP TABLE IF EXISTS contacts;
+DROP TABLE IF EXISTS attachments;
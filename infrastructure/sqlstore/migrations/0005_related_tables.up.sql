@@ -0,0 +1,49 @@
+-- Adjuntos, contactos, participantes, hilos y ubicaciones de los correos. Cada
+-- sentencia es idempotente, por lo que la migración se puede reintentar en MySQL.
+
+CREATE TABLE IF NOT EXISTS attachments (
+    id {{id}},
+    email_id INT NOT NULL,
+    filename VARCHAR(255),
+    content_type VARCHAR(255),
+    size BIGINT,
+    hash CHAR(64) NOT NULL,
+    content_id VARCHAR(255),
+    FOREIGN KEY (email_id) REFERENCES emails(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS contacts (
+    id {{id}},
+    email VARCHAR(255){{collate_nocase}} NOT NULL UNIQUE,
+    name VARCHAR(255) NOT NULL DEFAULT ''
+);
+
+-- La clave única sobre (contact_id, role, email_id) es el índice para buscar
+-- los correos de un contacto; se declara en la tabla para que la sentencia
+-- siga siendo idempotente en MySQL, que no admite CREATE INDEX IF NOT EXISTS.
+CREATE TABLE IF NOT EXISTS email_participants (
+    email_id INT NOT NULL,
+    contact_id INT NOT NULL,
+    role {{participant_role}} NOT NULL,
+    PRIMARY KEY (email_id, contact_id, role),
+    CONSTRAINT uq_participants_contact UNIQUE (contact_id, role, email_id),
+    FOREIGN KEY (email_id) REFERENCES emails(id) ON DELETE CASCADE,
+    FOREIGN KEY (contact_id) REFERENCES contacts(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS threads (
+    id {{id}},
+    root_message_id VARCHAR(255){{collate_binary}} NOT NULL UNIQUE,
+    subject TEXT,
+    message_count INT NOT NULL DEFAULT 0,
+    first_date DATETIME,
+    last_date DATETIME
+);
+
+CREATE TABLE IF NOT EXISTS message_locations (
+    id {{id}},
+    email_id INT NOT NULL,
+    folder VARCHAR(255){{collate_nocase}},
+    file_path VARCHAR(512) NOT NULL UNIQUE,
+    FOREIGN KEY (email_id) REFERENCES emails(id) ON DELETE CASCADE
+);
@@ -0,0 +1 @@
+DROP INDEX idx_emails_sender ON emails;
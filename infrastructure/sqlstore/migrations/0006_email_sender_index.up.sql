@@ -0,0 +1,2 @@
+-- Filtro y orden por remitente
+CREATE INDEX idx_emails_sender ON emails (sender);
@@ -0,0 +1 @@
+DROP INDEX idx_emails_folder ON emails;
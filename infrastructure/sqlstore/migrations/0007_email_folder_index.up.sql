@@ -0,0 +1,2 @@
+-- Filtro por carpeta
+CREATE INDEX idx_emails_folder ON emails (folder);
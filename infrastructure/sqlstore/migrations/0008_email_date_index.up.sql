@@ -0,0 +1,2 @@
+-- Filtro y orden por fecha
+CREATE INDEX idx_emails_date ON emails (date);
@@ -0,0 +1,8 @@
+-- Índice versionado de ZincSearch activo para cada nombre lógico (ZINC_INDEX)
+CREATE TABLE IF NOT EXISTS search_indexes (
+    alias VARCHAR(255) PRIMARY KEY,
+    active_index VARCHAR(255) NOT NULL,
+    previous_index VARCHAR(255),
+    doc_count INT NOT NULL DEFAULT 0,
+    switched_at DATETIME NOT NULL
+);
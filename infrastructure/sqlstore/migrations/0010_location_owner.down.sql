@@ -0,0 +1 @@
+ALTER TABLE message_locations DROP COLUMN owner;
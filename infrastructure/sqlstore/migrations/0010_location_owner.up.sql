@@ -0,0 +1,4 @@
+-- Dueño del buzón (primer directorio bajo BASE_DIR) de cada copia del mensaje.
+-- Las filas existentes se completan en la próxima ingesta, que conoce BASE_DIR
+-- (ver EmailRepository.FillLocationOwners).
+ALTER TABLE message_locations ADD COLUMN owner VARCHAR(255){{collate_nocase}};
@@ -0,0 +1 @@
+DROP INDEX idx_locations_owner ON message_locations;
@@ -0,0 +1,2 @@
+-- Filtro por dueño del buzón
+CREATE INDEX idx_locations_owner ON message_locations (owner);
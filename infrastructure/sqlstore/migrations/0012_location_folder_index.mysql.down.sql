@@ -0,0 +1 @@
+DROP INDEX idx_locations_folder ON message_locations;
@@ -0,0 +1,2 @@
+-- Filtro por carpeta de cada copia del mensaje
+CREATE INDEX idx_locations_folder ON message_locations (folder);
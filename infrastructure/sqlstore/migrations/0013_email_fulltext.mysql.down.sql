@@ -0,0 +1 @@
+DROP INDEX ft_emails_subject_body ON emails;
@@ -0,0 +1,3 @@
+-- Índice de texto completo sobre el asunto y el cuerpo, para buscar en la base
+-- cuando ZincSearch no está disponible. En tablas grandes puede tardar varios minutos.
+ALTER TABLE emails ADD FULLTEXT INDEX ft_emails_subject_body (subject, body);
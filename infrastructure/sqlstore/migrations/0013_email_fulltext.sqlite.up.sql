@@ -0,0 +1,27 @@
+-- Índice de texto completo (FTS5) sobre los emails para buscar sin ZincSearch.
+-- Usa la tabla emails como contenido y se mantiene al día con triggers.
+CREATE VIRTUAL TABLE IF NOT EXISTS emails_fts USING fts5(
+    subject, body, sender, sender_name, receiver, receiver_name,
+    content = 'emails', content_rowid = 'id', tokenize = 'unicode61 remove_diacritics 2'
+);
+
+INSERT INTO emails_fts (emails_fts) VALUES ('rebuild');
+
+CREATE TRIGGER IF NOT EXISTS emails_fts_insert AFTER INSERT ON emails BEGIN
+    INSERT INTO emails_fts (rowid, subject, body, sender, sender_name, receiver, receiver_name)
+    VALUES (new.id, new.subject, new.body, new.sender, new.sender_name, new.receiver, new.receiver_name);
+END;
+
+CREATE TRIGGER IF NOT EXISTS emails_fts_delete AFTER DELETE ON emails BEGIN
+    INSERT INTO emails_fts (emails_fts, rowid, subject, body, sender, sender_name, receiver, receiver_name)
+    VALUES ('delete', old.id, old.subject, old.body, old.sender, old.sender_name, old.receiver, old.receiver_name);
+END;
+
+-- Solo se actualiza cuando cambian las columnas indexadas (no al asignar thread_id)
+CREATE TRIGGER IF NOT EXISTS emails_fts_update
+    AFTER UPDATE OF subject, body, sender, sender_name, receiver, receiver_name ON emails BEGIN
+    INSERT INTO emails_fts (emails_fts, rowid, subject, body, sender, sender_name, receiver, receiver_name)
+    VALUES ('delete', old.id, old.subject, old.body, old.sender, old.sender_name, old.receiver, old.receiver_name);
+    INSERT INTO emails_fts (rowid, subject, body, sender, sender_name, receiver, receiver_name)
+    VALUES (new.id, new.subject, new.body, new.sender, new.sender_name, new.receiver, new.receiver_name);
+END;
@@ -0,0 +1,102 @@
+package sqlstore_test
+
+import (
+	"project/domain/model"
+	"project/infrastructure/mysql"
+	"project/infrastructure/sqlite"
+	"project/infrastructure/sqlstore"
+	"strings"
+	"testing"
+)
+
+// Las migraciones son comunes: cada motor debe tener las mismas versiones,
+// todas con su script down y sin marcadores pendientes.
+func TestMigrationsSameVersionsForEveryDialect(t *testing.T) {
+	dialects := []sqlstore.Dialect{mysql.Dialect, sqlite.Dialect}
+
+	var reference []sqlstore.Migration
+	for _, dialect := range dialects {
+		migrations, err := sqlstore.Migrations(dialect)
+		if err != nil {
+			t.Fatalf("%s: %v", dialect.Name, err)
+		}
+		for _, migration := range migrations {
+			for _, script := range []string{migration.Up, migration.Down} {
+				if strings.Contains(script, "{{") {
+					t.Errorf("%s: la migración %d_%s tiene marcadores sin reemplazar", dialect.Name, migration.Version, migration.Name)
+				}
+			}
+			if migration.Down == "" {
+				t.Errorf("%s: la migración %d_%s no tiene down", dialect.Name, migration.Version, migration.Name)
+			}
+		}
+
+		if reference == nil {
+			reference = migrations
+			continue
+		}
+		if len(migrations) != len(reference) {
+			t.Fatalf("%s tiene %d migraciones, %s tiene %d", dialect.Name, len(migrations), dialects[0].Name, len(reference))
+		}
+		for i := range migrations {
+			if migrations[i].Version != reference[i].Version || migrations[i].Name != reference[i].Name {
+				t.Errorf("%s: migración %d_%s, %s: %d_%s", dialect.Name, migrations[i].Version, migrations[i].Name,
+					dialects[0].Name, reference[i].Version, reference[i].Name)
+			}
+		}
+	}
+}
+
+// Una base creada con el esquema del README se actualiza sin perder sus
+// correos, acepta los correos nuevos y se puede revertir y volver a migrar.
+func TestMigrationsUpgradeReadmeSchema(t *testing.T) {
+	t.Setenv("SQLITE_PATH", t.TempDir()+"/emails.db")
+	db, err := sqlite.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	readme := `CREATE TABLE emails (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, message_id VARCHAR(255) NOT NULL, sender VARCHAR(255),
+		receiver VARCHAR(255), subject TEXT, mime_version VARCHAR(50), content_type VARCHAR(255),
+		encoding VARCHAR(50), folder VARCHAR(255), body TEXT, date DATETIME)`
+	if _, err := db.Exec(readme); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO emails (message_id, sender, subject, body) VALUES ('<1@enron.com>', 'a@enron.com', 'Hola', 'Texto')`); err != nil {
+		t.Fatal(err)
+	}
+
+	migrator, err := db.Migrator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied, err := migrator.Up(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var subject string
+	if err := db.QueryRow(`SELECT subject FROM emails WHERE message_id = '<1@enron.com>'`).Scan(&subject); err != nil || subject != "Hola" {
+		t.Fatalf("correo anterior: %q, %v", subject, err)
+	}
+	email := model.Email{MessageID: "<2@enron.com>", ContentHash: "hash", Sender: "b@enron.com", DateRaw: "Mon, 14 May 2001 16:39:00 -0700",
+		Locations: []model.MessageLocation{{Folder: "inbox", FilePath: "b/inbox/1.", Owner: "b"}}}
+	if result, err := db.Emails().Insert([]model.Email{email}); err != nil || len(result.Created) != 1 {
+		t.Fatalf("Insert() = %+v, %v", result, err)
+	}
+
+	// El índice FTS5 incluye los correos anteriores y los triggers agregan los nuevos
+	var matches int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM emails_fts WHERE emails_fts MATCH 'hola OR "b@enron.com"'`).Scan(&matches); err != nil || matches != 2 {
+		t.Fatalf("emails_fts: %d coincidencias, %v; se esperaban 2", matches, err)
+	}
+
+	if _, err := migrator.Down(len(applied)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(0); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,105 @@
+package sqlstore
+
+import (
+	"project/domain/searchquery"
+	"strings"
+	"time"
+)
+
+// TextIndex es el índice de texto completo de un motor. La sintaxis de la
+// consulta y los campos son los de la búsqueda en ZincSearch; cada motor
+// decide cómo buscar los términos de texto y cómo calcular la relevancia.
+type TextIndex interface {
+	// Match devuelve la condición sobre emails que busca el término en el
+	// campo de texto indicado ("" para el texto libre, subject, body,
+	// sender_name o receiver_name) y agrega sus argumentos al compilador.
+	Match(c *SearchCompiler, field string, term searchquery.Term) string
+	// Rank devuelve las columnas score, subject_hl y body_hl seguidas del FROM
+	// sobre emails, con sus argumentos, a partir de los términos de texto no
+	// negados. Si el motor no marca las coincidencias, subject_hl y body_hl
+	// quedan vacías y se marcan después de leer los correos.
+	Rank(terms []RankedTerm) (string, []interface{})
+}
+
+// RankedTerm es un término de texto no negado, que suma a la relevancia.
+type RankedTerm struct {
+	Field string
+	Term  searchquery.Term
+}
+
+// SearchCompiler convierte el árbol de la consulta en una condición sobre emails.
+type SearchCompiler struct {
+	Args   []interface{}
+	Ranked []RankedTerm
+
+	index TextIndex
+}
+
+// Condition devuelve la condición del nodo. negated indica si el nodo está
+// dentro de un número impar de negaciones.
+func (c *SearchCompiler) Condition(node searchquery.Node, negated bool) string {
+	switch n := node.(type) {
+	case searchquery.And:
+		parts := make([]string, len(n.Nodes))
+		for i, child := range n.Nodes {
+			parts[i] = c.Condition(child, negated)
+		}
+		return "(" + strings.Join(parts, " AND ") + ")"
+	case searchquery.Or:
+		parts := make([]string, len(n.Nodes))
+		for i, child := range n.Nodes {
+			parts[i] = c.Condition(child, negated)
+		}
+		return "(" + strings.Join(parts, " OR ") + ")"
+	case searchquery.Not:
+		return "NOT " + c.Condition(n.Node, !negated)
+	case searchquery.Term:
+		return c.term(n, negated)
+	}
+	return "1 = 1"
+}
+
+func (c *SearchCompiler) term(term searchquery.Term, negated bool) string {
+	switch term.Field {
+	case "":
+		return c.match("", term, negated)
+	case "from":
+		return "(" + c.Contains("sender", term.Value) + " OR " + c.match("sender_name", term, negated) + ")"
+	case "to":
+		return "(" + c.Contains("receiver", term.Value) + " OR " + c.Contains("cc", term.Value) + " OR " +
+			c.match("receiver_name", term, negated) + ")"
+	case "cc", "bcc":
+		return c.Contains(term.Field, term.Value)
+	case "subject", "body":
+		return c.match(term.Field, term, negated)
+	case "folder":
+		return c.Contains("folder", term.Value)
+	case "after", "before":
+		// validateTerm ya normalizó la fecha
+		date, err := time.Parse("2006-01-02", term.Value)
+		if err != nil {
+			return "1 = 0"
+		}
+		c.Args = append(c.Args, date)
+		if term.Field == "after" {
+			return "emails.date >= ?"
+		}
+		return "emails.date < ?"
+	case "has":
+		return "EXISTS (SELECT 1 FROM attachments WHERE attachments.email_id = emails.id)"
+	}
+	return "1 = 1"
+}
+
+func (c *SearchCompiler) match(field string, term searchquery.Term, negated bool) string {
+	if !negated {
+		c.Ranked = append(c.Ranked, RankedTerm{Field: field, Term: term})
+	}
+	return c.index.Match(c, field, term)
+}
+
+// Contains busca el valor como parte de una columna de emails, sin distinguir mayúsculas.
+func (c *SearchCompiler) Contains(column, value string) string {
+	c.Args = append(c.Args, "%"+LikeEscaper.Replace(value)+"%")
+	return "emails." + column + " LIKE ? ESCAPE '!'"
+}
@@ -0,0 +1,66 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SearchIndexStore guarda en la tabla search_indexes qué índice versionado de
+// ZincSearch está activo para cada nombre lógico. Como todos los procesos leen
+// el mismo registro, cambiarlo es un cambio atómico para la API y la ingesta.
+type SearchIndexStore struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// NewSearchIndexStore crea el registro de índices sobre la conexión indicada.
+func NewSearchIndexStore(db *sql.DB, dialect Dialect) *SearchIndexStore {
+	return &SearchIndexStore{db: db, dialect: dialect}
+}
+
+// ActiveIndex devuelve el índice activo para el nombre lógico, o "" si nunca
+// se cambió o si todavía no se aplicó la migración que crea la tabla.
+func (s *SearchIndexStore) ActiveIndex(alias string) (string, error) {
+	var index string
+	err := s.db.QueryRow("SELECT active_index FROM search_indexes WHERE alias = ?", alias).Scan(&index)
+	if errors.Is(err, sql.ErrNoRows) || (err != nil && s.dialect.IsMissingTable(err)) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error consultando el índice activo de %s: %w", alias, err)
+	}
+	return index, nil
+}
+
+// SwitchIndex apunta el nombre lógico al nuevo índice en una transacción y
+// devuelve el índice que estaba activo antes ("" si no había ninguno).
+func (s *SearchIndexStore) SwitchIndex(alias, index string, docCount int) (string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var previous string
+	err = tx.QueryRow("SELECT active_index FROM search_indexes WHERE alias = ?"+s.dialect.ForUpdate, alias).Scan(&previous)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		_, err = tx.Exec(`INSERT INTO search_indexes (alias, active_index, doc_count, switched_at) VALUES (?, ?, ?, ?)`,
+			alias, index, docCount, time.Now().UTC())
+	case err != nil:
+		return "", fmt.Errorf("error consultando el índice activo de %s: %w", alias, err)
+	default:
+		_, err = tx.Exec(`UPDATE search_indexes SET previous_index = active_index, active_index = ?, doc_count = ?,
+			switched_at = ? WHERE alias = ?`, index, docCount, time.Now().UTC(), alias)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error cambiando el índice activo de %s: %w", alias, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	return previous, nil
+}
@@ -0,0 +1,55 @@
+// Package sqlstore guarda los correos en una base de datos SQL. El código es
+// común a MySQL y SQLite; las diferencias de sintaxis de cada motor se
+// describen en un Dialect, que proveen los paquetes mysql y sqlite.
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Locker toma un lock exclusivo sobre la conexión mientras se aplican las
+// migraciones. Devuelve la función que lo libera.
+type Locker func(ctx context.Context, conn *sql.Conn) (func(), error)
+
+// Dialect reúne las diferencias de SQL entre los motores soportados.
+type Dialect struct {
+	Name             string            // mysql o sqlite
+	MaxPlaceholders  int               // Parámetros admitidos por sentencia
+	InsertIgnore     string            // INSERT que descarta las filas con una clave duplicada
+	IgnoreConflict   string            // Sufijo de INSERT que no modifica la fila si la clave ya existe
+	UpsertContact    string            // Sufijo del INSERT de contacts que conserva el último nombre no vacío
+	ForUpdate        string            // Bloqueo de las filas leídas en una transacción; vacío si el motor no lo admite
+	IsTransient      func(error) bool  // Errores que se resuelven reintentando la transacción
+	IsMissingTable   func(error) bool  // Error al consultar una tabla que todavía no existe
+	SchemaTypes      map[string]string // Valor de cada marcador {{nombre}} de las migraciones (ver Migrations)
+	Lock             Locker            // Lock de las migraciones; nil si el motor no lo necesita
+	TransactionalDDL bool              // Las sentencias DDL se revierten con la transacción (ver Migrator)
+	TextIndex        TextIndex         // Índice de texto completo de los correos; nil si el motor no tiene uno
+}
+
+// DB es una conexión abierta junto con el dialecto de su motor.
+type DB struct {
+	*sql.DB
+	Dialect Dialect
+}
+
+// Emails devuelve el repositorio de correos sobre la conexión.
+func (db *DB) Emails() *EmailRepository {
+	return NewEmailRepository(db.DB, db.Dialect)
+}
+
+// Threads devuelve el repositorio de hilos sobre la conexión.
+func (db *DB) Threads() *ThreadRepository {
+	return NewThreadRepository(db.DB, db.Dialect)
+}
+
+// SearchIndexes devuelve el registro de índices de ZincSearch sobre la conexión.
+func (db *DB) SearchIndexes() *SearchIndexStore {
+	return NewSearchIndexStore(db.DB, db.Dialect)
+}
+
+// Migrator devuelve el Migrator con las migraciones del motor.
+func (db *DB) Migrator() (*Migrator, error) {
+	return NewMigrator(db.DB, db.Dialect)
+}
@@ -0,0 +1,178 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"project/domain/model"
+	"project/domain/service"
+	"project/domain/threading"
+	"strings"
+)
+
+// Cantidad máxima de IDs por sentencia UPDATE ... WHERE id IN (...)
+const threadUpdateChunk = 500
+
+// Columnas de la tabla threads en el orden en que las lee scanThread
+const threadColumns = `id, root_message_id, subject, message_count, first_date, last_date`
+
+// ThreadRepository implementa service.ThreadRepository sobre la tabla threads
+// y la columna thread_id de emails.
+type ThreadRepository struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// NewThreadRepository crea el repositorio de hilos sobre la conexión indicada.
+func NewThreadRepository(db *sql.DB, dialect Dialect) *ThreadRepository {
+	return &ThreadRepository{db: db, dialect: dialect}
+}
+
+func scanThread(row rowScanner) (model.Thread, error) {
+	var thread model.Thread
+	err := row.Scan(&thread.ID, &thread.RootMessageID, &thread.Subject, &thread.MessageCount, &thread.FirstDate, &thread.LastDate)
+	return thread, err
+}
+
+// Messages devuelve los datos de todos los correos que hacen falta para agruparlos en hilos.
+func (r *ThreadRepository) Messages() ([]threading.Message, error) {
+	rows, err := r.db.Query(`SELECT id, message_id, in_reply_to, reference_ids, subject, date FROM emails ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []threading.Message
+	for rows.Next() {
+		var msg threading.Message
+		var inReplyTo, references string
+		var date sql.NullTime
+		if err := rows.Scan(&msg.ID, &msg.MessageID, &inReplyTo, &references, &msg.Subject, &date); err != nil {
+			return nil, fmt.Errorf("error al leer un correo: %w", err)
+		}
+		msg.References = threading.ReferenceChain(references, inReplyTo)
+		msg.Date = date.Time
+		messages = append(messages, msg)
+	}
+	return messages, rows.Err()
+}
+
+// Replace guarda los hilos y asigna a cada correo el suyo en una transacción.
+func (r *ThreadRepository) Replace(threads []service.ThreadRecord) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE threads SET message_count = 0`); err != nil {
+		return fmt.Errorf("error al reiniciar los hilos: %w", err)
+	}
+
+	for _, thread := range threads {
+		threadID, err := saveThread(tx, thread)
+		if err != nil {
+			return fmt.Errorf("error al guardar el hilo %s: %w", thread.RootMessageID, err)
+		}
+
+		for start := 0; start < len(thread.EmailIDs); start += threadUpdateChunk {
+			end := start + threadUpdateChunk
+			if end > len(thread.EmailIDs) {
+				end = len(thread.EmailIDs)
+			}
+			args := []interface{}{threadID}
+			for _, id := range thread.EmailIDs[start:end] {
+				args = append(args, id)
+			}
+			query := `UPDATE emails SET thread_id = ? WHERE id IN (` + placeholders(end-start) + `)`
+			if _, err := tx.Exec(query, args...); err != nil {
+				return fmt.Errorf("error al asignar el hilo %d: %w", threadID, err)
+			}
+		}
+	}
+
+	// Los hilos que quedaron sin correos ya no existen
+	if _, err := tx.Exec(`DELETE FROM threads WHERE message_count = 0`); err != nil {
+		return fmt.Errorf("error al eliminar hilos vacíos: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// saveThread actualiza el hilo con la misma raíz o lo crea si no existe y
+// devuelve su ID. Se consulta antes de escribir, en lugar de usar la sintaxis de
+// upsert de cada motor, para que funcione igual en MySQL y en SQLite.
+func saveThread(tx *sql.Tx, thread service.ThreadRecord) (int64, error) {
+	count := len(thread.EmailIDs)
+
+	var threadID int64
+	err := tx.QueryRow(`SELECT id FROM threads WHERE root_message_id = ?`, thread.RootMessageID).Scan(&threadID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		result, err := tx.Exec(`
+			INSERT INTO threads (root_message_id, subject, message_count, first_date, last_date)
+			VALUES (?, ?, ?, ?, ?)`,
+			thread.RootMessageID, thread.Subject, count, thread.FirstDate, thread.LastDate)
+		if err != nil {
+			return 0, err
+		}
+		return result.LastInsertId()
+	case err != nil:
+		return 0, err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE threads SET subject = ?, message_count = ?, first_date = ?, last_date = ?
+		WHERE id = ?`,
+		thread.Subject, count, thread.FirstDate, thread.LastDate, threadID)
+	return threadID, err
+}
+
+// List devuelve los hilos ordenados por su último correo junto con el total.
+func (r *ThreadRepository) List(offset, limit int) ([]model.Thread, int, error) {
+	rows, err := r.db.Query(`SELECT `+threadColumns+` FROM threads ORDER BY last_date DESC, id DESC LIMIT ? OFFSET ?`,
+		limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var threads []model.Thread
+	for rows.Next() {
+		thread, err := scanThread(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		threads = append(threads, thread)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM threads").Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	return threads, total, nil
+}
+
+// Get devuelve un hilo sin sus correos o nil si no existe.
+func (r *ThreadRepository) Get(id int) (*model.Thread, error) {
+	thread, err := scanThread(r.db.QueryRow(`SELECT `+threadColumns+` FROM threads WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &thread, nil
+}
+
+// placeholders devuelve "?, ?, ..." con n marcadores.
+func placeholders(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return strings.Repeat("?, ", n-1) + "?"
+}
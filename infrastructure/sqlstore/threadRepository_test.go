@@ -0,0 +1,115 @@
+package sqlstore_test
+
+import (
+	"database/sql"
+	"fmt"
+	"project/domain/model"
+	"project/domain/service"
+	"project/infrastructure/memory"
+	"project/infrastructure/sqlite"
+	"project/infrastructure/sqlstore"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newTestDB abre una base SQLite temporal con todas las migraciones aplicadas.
+func newTestDB(t *testing.T) *sqlstore.DB {
+	t.Helper()
+	t.Setenv("SQLITE_PATH", t.TempDir()+"/emails.db")
+	db, err := sqlite.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := db.Migrator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(0); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// describeThreads resume los hilos con los Message-ID de sus correos, para
+// comparar las dos implementaciones sin depender de los IDs.
+func describeThreads(t *testing.T, ts *service.ThreadService) []string {
+	t.Helper()
+	threads, total, err := ts.GetThreadsWithPagination(0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != len(threads) {
+		t.Fatalf("total %d, se listaron %d hilos", total, len(threads))
+	}
+
+	formatDate := func(date sql.NullTime) string {
+		if !date.Valid {
+			return "NULL"
+		}
+		return date.Time.UTC().Format(time.DateOnly)
+	}
+	result := make([]string, len(threads))
+	for i, summary := range threads {
+		thread, err := ts.GetThreadByID(summary.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var messageIDs []string
+		for _, email := range thread.Emails {
+			if email.ThreadID == nil || *email.ThreadID != thread.ID {
+				t.Errorf("el correo %s tiene thread_id %v, se esperaba %d", email.MessageID, email.ThreadID, thread.ID)
+			}
+			messageIDs = append(messageIDs, email.MessageID)
+		}
+		result[i] = fmt.Sprintf("%s %q %d %s..%s %q", thread.RootMessageID, thread.Subject, thread.MessageCount,
+			formatDate(thread.FirstDate), formatDate(thread.LastDate), messageIDs)
+	}
+	return result
+}
+
+// Los hilos se guardan igual en SQLite que en el repositorio en memoria que
+// usan las pruebas de los servicios.
+func TestThreadRepositoryMatchesMemory(t *testing.T) {
+	db := newTestDB(t)
+	memoryEmails := memory.NewEmailRepository()
+	services := map[string]*service.ThreadService{
+		"sqlite":  service.NewThreadService(db.Threads(), db.Emails()),
+		"memoria": service.NewThreadService(memory.NewThreadRepository(memoryEmails), memoryEmails),
+	}
+	repositories := map[string]service.EmailRepository{"sqlite": db.Emails(), "memoria": memoryEmails}
+
+	date := func(d int) sql.NullTime {
+		return sql.NullTime{Time: time.Date(2001, 5, d, 10, 0, 0, 0, time.UTC), Valid: true}
+	}
+	batches := [][]model.Email{
+		{
+			{MessageID: "<a>", ContentHash: "a", Subject: "Presupuesto", Date: date(1)},
+			{MessageID: "<b>", ContentHash: "b", Subject: "Re: Presupuesto", Date: date(3), InReplyTo: "<a>"},
+			{MessageID: "<c>", ContentHash: "c", Subject: "Re: Presupuesto", References: "<a> <b>"},
+			{MessageID: "<d>", ContentHash: "d", Subject: "Reunión", Date: date(2)},
+		},
+		{
+			{MessageID: "<e>", ContentHash: "e", Subject: "Re: Reunión", Date: date(5), InReplyTo: "<d>"},
+			{MessageID: "", ContentHash: "f", Subject: "Sin Message-ID"},
+		},
+	}
+
+	for i, batch := range batches {
+		results := make(map[string][]string)
+		for name, ts := range services {
+			if _, err := repositories[name].Insert(append([]model.Email(nil), batch...)); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if _, err := ts.RebuildThreads(); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			results[name] = describeThreads(t, ts)
+		}
+		if !reflect.DeepEqual(results["sqlite"], results["memoria"]) {
+			t.Errorf("reconstrucción %d:\nsqlite  %q\nmemoria %q", i+1, results["sqlite"], results["memoria"])
+		}
+	}
+}
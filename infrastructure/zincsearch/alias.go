@@ -0,0 +1,81 @@
+package zincsearch
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IndexPointer indica qué índice versionado está activo para un nombre lógico.
+// ActiveIndex devuelve "" si el nombre lógico todavía no apunta a ninguna versión.
+type IndexPointer interface {
+	ActiveIndex(alias string) (string, error)
+}
+
+var (
+	pointerMu   sync.Mutex
+	pointer     IndexPointer
+	pointerTTL  time.Duration
+	cachedIndex string
+	cachedAt    time.Time
+)
+
+// UseIndexPointer hace que IndexName consulte el puntero indicado, guardando
+// el resultado durante ttl. Sin puntero se usa directamente ZINC_INDEX.
+func UseIndexPointer(p IndexPointer, ttl time.Duration) {
+	pointerMu.Lock()
+	defer pointerMu.Unlock()
+	pointer = p
+	pointerTTL = ttl
+	cachedIndex = ""
+	cachedAt = time.Time{}
+}
+
+// IndexName devuelve el índice físico donde se leen y escriben los correos: el
+// índice versionado activo para ZINC_INDEX o, si no hay ninguno, ZINC_INDEX.
+func IndexName() string {
+	alias := IndexAlias()
+
+	pointerMu.Lock()
+	defer pointerMu.Unlock()
+	if pointer == nil {
+		return alias
+	}
+	if cachedIndex != "" && time.Since(cachedAt) < pointerTTL {
+		return cachedIndex
+	}
+
+	index, err := pointer.ActiveIndex(alias)
+	if err != nil {
+		fmt.Printf("Error consultando el índice activo, se usa %s: %v\n", alias, err)
+		if cachedIndex != "" {
+			return cachedIndex
+		}
+		return alias
+	}
+	if index == "" {
+		index = alias
+	}
+	cachedIndex, cachedAt = index, time.Now()
+	return index
+}
+
+// VersionedIndexName devuelve el nombre de la versión indicada del índice, por ejemplo emails_v3.
+func VersionedIndexName(alias string, version int) string {
+	return fmt.Sprintf("%s_v%d", alias, version)
+}
+
+// NextIndexVersion devuelve la versión siguiente a la del índice activo.
+func NextIndexVersion(alias, active string) int {
+	suffix := strings.TrimPrefix(active, alias+"_v")
+	if suffix == active {
+		return 1
+	}
+	version, err := strconv.Atoi(suffix)
+	if err != nil {
+		return 1
+	}
+	return version + 1
+}
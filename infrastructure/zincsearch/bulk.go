@@ -0,0 +1,241 @@
+package zincsearch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"project/domain/model"
+	"time"
+)
+
+// BulkConfig configura el indexador por lotes.
+type BulkConfig struct {
+	BatchSize  int           // Documentos por solicitud a _bulk
+	MaxRetries int           // Reintentos de los documentos que fallan
+	Backoff    time.Duration // Espera inicial entre reintentos; se duplica en cada intento
+}
+
+// DefaultBulkConfig devuelve la configuración por defecto.
+func DefaultBulkConfig() BulkConfig {
+	return BulkConfig{BatchSize: 500, MaxRetries: 3, Backoff: 500 * time.Millisecond}
+}
+
+// BulkItemError es un documento que ZincSearch no pudo indexar.
+type BulkItemError struct {
+	ID     string
+	Status int
+	Reason string
+}
+
+func (e BulkItemError) Error() string {
+	return fmt.Sprintf("documento %s: %d %s", e.ID, e.Status, e.Reason)
+}
+
+// retryable indica si el error puede ser pasajero: los de la solicitud completa
+// (sin Status), 429 y 5xx. Los demás 4xx, como un documento que no respeta el
+// mapping, fallarían igual al reintentar.
+func (e BulkItemError) retryable() bool {
+	return e.Status == 0 || e.Status == http.StatusTooManyRequests || e.Status >= 500
+}
+
+// BulkStats resume el resultado de una o varias solicitudes _bulk.
+type BulkStats struct {
+	Indexed int             // Documentos indexados correctamente
+	Retries int             // Reintentos realizados
+	Failed  []BulkItemError // Documentos que fallaron después de todos los reintentos
+}
+
+type bulkItem struct {
+	id  string
+	doc interface{}
+}
+
+// BulkIndexer acumula documentos y los envía a ZincSearch con la API _bulk
+// compatible con Elasticsearch, que informa el resultado de cada documento.
+// No es seguro para uso concurrente.
+type BulkIndexer struct {
+	client *ZincSearchClient
+	index  string
+	cfg    BulkConfig
+	buffer []bulkItem
+	stats  BulkStats
+}
+
+// NewBulkIndexer crea un indexador por lotes sobre el índice de correos.
+func (zsc *ZincSearchClient) NewBulkIndexer(cfg BulkConfig) *BulkIndexer {
+	def := DefaultBulkConfig()
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = def.BatchSize
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = def.MaxRetries
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = def.Backoff
+	}
+	return &BulkIndexer{client: zsc, index: zsc.index, cfg: cfg}
+}
+
+// Add agrega un documento con el _id indicado y envía el lote si está completo.
+func (b *BulkIndexer) Add(id string, doc interface{}) error {
+	b.buffer = append(b.buffer, bulkItem{id: id, doc: doc})
+	if len(b.buffer) >= b.cfg.BatchSize {
+		return b.Flush()
+	}
+	return nil
+}
+
+// Flush envía los documentos pendientes. Los que fallan por un error pasajero
+// se reintentan con espera exponencial; los demás se informan como fallidos
+// sin reintentar. Solo devuelve error si la solicitud completa falla.
+func (b *BulkIndexer) Flush() error {
+	pending := b.buffer
+	b.buffer = nil
+
+	backoff := b.cfg.Backoff
+	for attempt := 0; len(pending) > 0; attempt++ {
+		failed, err := b.send(pending)
+		if err != nil {
+			failed = make([]BulkItemError, len(pending))
+			for i, item := range pending {
+				failed[i] = BulkItemError{ID: item.id, Reason: err.Error()}
+			}
+		}
+
+		b.stats.Indexed += len(pending) - len(failed)
+		var transient []BulkItemError
+		for _, f := range failed {
+			if f.retryable() {
+				transient = append(transient, f)
+			} else {
+				b.stats.Failed = append(b.stats.Failed, f)
+			}
+		}
+		if len(transient) == 0 {
+			return nil
+		}
+
+		if attempt >= b.cfg.MaxRetries {
+			b.stats.Failed = append(b.stats.Failed, transient...)
+			if err != nil {
+				return err
+			}
+			return nil
+		}
+
+		// Solo se reintentan los documentos que fallaron por un error pasajero
+		failedIDs := make(map[string]bool, len(transient))
+		for _, f := range transient {
+			failedIDs[f.ID] = true
+		}
+		var retry []bulkItem
+		for _, item := range pending {
+			if failedIDs[item.id] {
+				retry = append(retry, item)
+			}
+		}
+		pending = retry
+		b.stats.Retries++
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+	return nil
+}
+
+// Stats devuelve el resumen acumulado desde que se creó el indexador.
+func (b *BulkIndexer) Stats() BulkStats {
+	return b.stats
+}
+
+// send envía una solicitud _bulk en formato NDJSON y devuelve los documentos que fallaron.
+func (b *BulkIndexer) send(items []bulkItem) ([]BulkItemError, error) {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, item := range items {
+		action := map[string]map[string]string{"index": {"_index": b.index, "_id": item.id}}
+		if err := encoder.Encode(action); err != nil {
+			return nil, fmt.Errorf("error serializando la acción: %w", err)
+		}
+		if err := encoder.Encode(item.doc); err != nil {
+			return nil, fmt.Errorf("error serializando el documento %s: %w", item.id, err)
+		}
+	}
+
+	req, err := http.NewRequest("POST", b.client.esURL("/_bulk"), &body)
+	if err != nil {
+		return nil, fmt.Errorf("error al crear la solicitud: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.SetBasicAuth(b.client.username, b.client.password)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error al hacer la solicitud: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error al leer el cuerpo de la respuesta: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error en la respuesta de ZincSearch: %v: %s", resp.Status, string(respBody))
+	}
+
+	return parseBulkResponse(respBody, items)
+}
+
+// parseBulkResponse interpreta la respuesta de _bulk con el formato de
+// Elasticsearch, que informa el resultado de cada documento en items. Si la
+// respuesta no trae ese detalle no se puede saber qué documentos fallaron, por
+// lo que se devuelve un error y se reintenta el lote completo.
+func parseBulkResponse(body []byte, items []bulkItem) ([]BulkItemError, error) {
+	var result struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			ID     string          `json:"_id"`
+			Status int             `json:"status"`
+			Error  json.RawMessage `json:"error"`
+		} `json:"items"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("error al decodificar la respuesta: %v", err)
+	}
+	if result.Error != "" {
+		return nil, fmt.Errorf("error de ZincSearch: %s", result.Error)
+	}
+	if len(result.Items) != len(items) {
+		return nil, fmt.Errorf("la respuesta de ZincSearch informa %d de %d documentos", len(result.Items), len(items))
+	}
+
+	var failed []BulkItemError
+	for i, item := range result.Items {
+		for _, r := range item {
+			// Un documento indexado puede informar "error": null
+			hasError := len(r.Error) > 0 && string(bytes.TrimSpace(r.Error)) != "null"
+			if r.Status >= 200 && r.Status < 300 && !hasError {
+				continue
+			}
+			id := r.ID
+			if id == "" {
+				id = items[i].id
+			}
+			failed = append(failed, BulkItemError{ID: id, Status: r.Status, Reason: string(r.Error)})
+		}
+	}
+	return failed, nil
+}
+
+// AddEmail agrega un correo usando su ID de MySQL como _id.
+func (b *BulkIndexer) AddEmail(email model.Email) error {
+	id, err := DocumentID(email)
+	if err != nil {
+		return err
+	}
+	return b.Add(id, NewEmailDocument(email))
+}
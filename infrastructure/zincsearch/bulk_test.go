@@ -0,0 +1,139 @@
+package zincsearch
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseBulkResponse(t *testing.T) {
+	items := []bulkItem{{id: "1"}, {id: "2"}}
+
+	tests := []struct {
+		name    string
+		body    string
+		want    []BulkItemError
+		wantErr bool
+	}{
+		{
+			name: "todos indexados",
+			body: `{"errors":false,"items":[{"index":{"_id":"1","status":200}},{"index":{"_id":"2","status":201}}]}`,
+		},
+		{
+			name: "error null",
+			body: `{"errors":false,"items":[{"index":{"_id":"1","status":200,"error":null}},{"index":{"_id":"2","status":200,"error": null }}]}`,
+		},
+		{
+			name: "un documento rechazado",
+			body: `{"errors":true,"items":[{"index":{"_id":"1","status":200}},{"index":{"_id":"2","status":400,"error":"mapping"}}]}`,
+			want: []BulkItemError{{ID: "2", Status: 400, Reason: `"mapping"`}},
+		},
+		{
+			name: "error sin _id",
+			body: `{"errors":true,"items":[{"index":{"status":503,"error":{"type":"unavailable"}}},{"index":{"_id":"2","status":200}}]}`,
+			want: []BulkItemError{{ID: "1", Status: 503, Reason: `{"type":"unavailable"}`}},
+		},
+		{
+			name:    "sin detalle por documento",
+			body:    `{"message":"bulk data inserted","record_count":2}`,
+			wantErr: true,
+		},
+		{
+			name:    "error de la solicitud",
+			body:    `{"error":"index not found"}`,
+			wantErr: true,
+		},
+		{
+			name:    "respuesta inválida",
+			body:    `<html>`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseBulkResponse([]byte(tt.body), items)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseBulkResponse() error = %v, se esperaba error: %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseBulkResponse() = %+v, se esperaba %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// bulkServer responde a _bulk con el estado que status devuelve para cada _id
+// y cuenta cuántas veces se envió cada documento.
+func bulkServer(t *testing.T, status func(id string, attempt int) int) (*ZincSearchClient, map[string]int) {
+	t.Helper()
+	sent := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var items []map[string]interface{}
+		scanner := bufio.NewScanner(r.Body)
+		for line := 0; scanner.Scan(); line++ {
+			if line%2 == 1 {
+				continue // Documento
+			}
+			var action map[string]map[string]string
+			if err := json.Unmarshal(scanner.Bytes(), &action); err != nil {
+				t.Errorf("acción inválida: %v", err)
+				return
+			}
+			id := action["index"]["_id"]
+			sent[id]++
+			result := map[string]interface{}{"_id": id, "status": status(id, sent[id])}
+			if code := result["status"].(int); code >= 300 {
+				result["error"] = fmt.Sprintf("estado %d", code)
+			}
+			items = append(items, map[string]interface{}{"index": result})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"errors": true, "items": items})
+	}))
+	t.Cleanup(server.Close)
+	return &ZincSearchClient{baseURL: server.URL + "/api", index: "emails"}, sent
+}
+
+func TestBulkIndexerRetriesOnlyTransientErrors(t *testing.T) {
+	client, sent := bulkServer(t, func(id string, attempt int) int {
+		switch id {
+		case "mapping":
+			return http.StatusBadRequest
+		case "ocupado":
+			if attempt == 1 {
+				return http.StatusTooManyRequests
+			}
+		case "caido":
+			return http.StatusServiceUnavailable
+		}
+		return http.StatusOK
+	})
+	indexer := client.NewBulkIndexer(BulkConfig{BatchSize: 10, MaxRetries: 2, Backoff: time.Millisecond})
+
+	for _, id := range []string{"ok", "mapping", "ocupado", "caido"} {
+		if err := indexer.Add(id, map[string]string{"id": id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := indexer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]int{"ok": 1, "mapping": 1, "ocupado": 2, "caido": 3}
+	if !reflect.DeepEqual(sent, want) {
+		t.Errorf("envíos por documento %v, se esperaba %v", sent, want)
+	}
+	stats := indexer.Stats()
+	var failed []string
+	for _, f := range stats.Failed {
+		failed = append(failed, f.ID)
+	}
+	if stats.Indexed != 2 || strings.Join(failed, ",") != "mapping,caido" || stats.Retries != 2 {
+		t.Errorf("Stats() = %+v, se esperaban 2 indexados, fallidos mapping y caido, y 2 reintentos", stats)
+	}
+}
@@ -3,17 +3,50 @@ package zincsearch
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"project/domain/model"
+	"project/infrastructure/zincsearch/query"
+	"strings"
+	"time"
 )
 
+// Índice donde se guardan los correos si no se define ZINC_INDEX
+const DefaultIndexName = "emails_prueba"
+
+// IndexAlias devuelve el nombre lógico del índice de correos configurado en ZINC_INDEX.
+func IndexAlias() string {
+	if index := os.Getenv("ZINC_INDEX"); index != "" {
+		return index
+	}
+	return DefaultIndexName
+}
+
+// Cliente HTTP compartido por todas las solicitudes, para reutilizar las conexiones
+var httpClient = &http.Client{
+	Timeout: 60 * time.Second,
+	Transport: &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 20,
+		IdleConnTimeout:     90 * time.Second,
+	},
+}
+
+// HTTPClient devuelve el cliente HTTP compartido para hablar con ZincSearch.
+func HTTPClient() *http.Client {
+	return httpClient
+}
+
 type ZincSearchClient struct {
 	baseURL  string
 	username string
 	password string
+	index    string
 }
 
 // NewZincSearchClient crea una nueva instancia de ZincSearchClient
@@ -31,32 +64,93 @@ func NewZincSearchClient() *ZincSearchClient {
 		baseURL:  baseURL,
 		username: username,
 		password: password,
+		index:    IndexName(),
 	}
 }
 
-// SearchEmails realiza la búsqueda de emails en ZincSearch con paginación
-func (zsc *ZincSearchClient) SearchEmailsWithPagination(searchQuery string) ([]model.Email, int, error) {
-	req, err := http.NewRequest("POST", zsc.baseURL+"/emails_prueba/_search", bytes.NewBuffer([]byte(searchQuery)))
+// ResponseError es una respuesta de ZincSearch con un código de error.
+type ResponseError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("error en la respuesta de ZincSearch: %v", e.Status)
+}
+
+// IsUnavailable indica si el error se debe a que ZincSearch no respondió o
+// falló al procesar la solicitud (5xx), y no a la solicitud en sí.
+func IsUnavailable(err error) bool {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return true
+	}
+	var respErr *ResponseError
+	return errors.As(err, &respErr) && respErr.StatusCode >= 500
+}
+
+// esURL devuelve la URL de la API compatible con Elasticsearch, que ZincSearch
+// sirve en /es junto a /api (ZINC_URL apunta a /api).
+func (zsc *ZincSearchClient) esURL(path string) string {
+	root := strings.TrimSuffix(strings.TrimSuffix(zsc.baseURL, "/"), "/api")
+	return root + "/es" + path
+}
+
+// Index devuelve el nombre del índice que usa el cliente.
+func (zsc *ZincSearchClient) Index() string {
+	return zsc.index
+}
+
+// WithIndex devuelve una copia del cliente que trabaja sobre otro índice.
+func (zsc *ZincSearchClient) WithIndex(index string) *ZincSearchClient {
+	copy := *zsc
+	copy.index = index
+	return &copy
+}
+
+// AggregationBucket es un grupo de una agregación terms o date_histogram.
+// Key es el valor del grupo; en los histogramas de fechas es el inicio del
+// intervalo en milisegundos y KeyAsString su versión formateada, si existe.
+type AggregationBucket struct {
+	Key         interface{} `json:"key"`
+	KeyAsString string      `json:"key_as_string"`
+	DocCount    int         `json:"doc_count"`
+}
+
+// SearchResults es el resultado de una búsqueda.
+type SearchResults struct {
+	Hits         []model.SearchHit
+	Total        int
+	Aggregations map[string][]AggregationBucket // Grupos de cada agregación pedida, por nombre
+}
+
+// Search realiza la búsqueda de emails en ZincSearch con paginación y las agregaciones pedidas.
+func (zsc *ZincSearchClient) Search(search query.Search) (*SearchResults, error) {
+	body, err := json.Marshal(search)
+	if err != nil {
+		return nil, fmt.Errorf("error serializando la consulta: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", zsc.baseURL+"/"+zsc.index+"/_search", bytes.NewBuffer(body))
 	if err != nil {
-		return nil, 0, fmt.Errorf("error al crear la solicitud: %v", err)
+		return nil, fmt.Errorf("error al crear la solicitud: %v", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.SetBasicAuth(zsc.username, zsc.password)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
-		return nil, 0, fmt.Errorf("error al hacer la solicitud: %v", err)
+		return nil, fmt.Errorf("error al hacer la solicitud: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, 0, fmt.Errorf("error en la respuesta de ZincSearch: %v", resp.Status)
+		return nil, &ResponseError{StatusCode: resp.StatusCode, Status: resp.Status}
 	}
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, 0, fmt.Errorf("error al leer el cuerpo de la respuesta: %v", err)
+		return nil, fmt.Errorf("error al leer el cuerpo de la respuesta: %v", err)
 	}
 
 	var results struct {
@@ -64,21 +158,57 @@ func (zsc *ZincSearchClient) SearchEmailsWithPagination(searchQuery string) ([]m
 			Total struct {
 				Value int `json:"value"`
 			} `json:"total"`
-			Hits []struct {
-				Source model.Email `json:"_source"`
-			} `json:"hits"`
+			Hits []SearchHit `json:"hits"`
 		} `json:"hits"`
+		Aggregations map[string]struct {
+			Buckets []AggregationBucket `json:"buckets"`
+		} `json:"aggregations"`
 	}
 
 	if err := json.Unmarshal(respBody, &results); err != nil {
-		return nil, 0, fmt.Errorf("error al decodificar la respuesta: %v", err)
+		return nil, fmt.Errorf("error al decodificar la respuesta: %v", err)
 	}
 
-	emails := make([]model.Email, len(results.Hits.Hits))
+	hits := make([]model.SearchHit, len(results.Hits.Hits))
 	for i, hit := range results.Hits.Hits {
-		emails[i] = hit.Source
+		hits[i] = hit.ToSearchHit()
+	}
+
+	aggregations := make(map[string][]AggregationBucket, len(results.Aggregations))
+	for name, aggregation := range results.Aggregations {
+		aggregations[name] = aggregation.Buckets
+	}
+
+	return &SearchResults{Hits: hits, Total: results.Hits.Total.Value, Aggregations: aggregations}, nil
+}
+
+// DocumentCount devuelve la cantidad de documentos del índice de correos.
+// También sirve para comprobar que ZincSearch responde.
+func (zsc *ZincSearchClient) DocumentCount() (int, error) {
+	req, err := http.NewRequest("GET", zsc.baseURL+"/index/"+zsc.index, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error al crear la solicitud: %v", err)
+	}
+	req.SetBasicAuth(zsc.username, zsc.password)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("error al hacer la solicitud: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("error en la respuesta de ZincSearch: %v", resp.Status)
+	}
+
+	var index struct {
+		Stats struct {
+			DocNum int `json:"doc_num"`
+		} `json:"stats"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&index); err != nil {
+		return 0, fmt.Errorf("error al decodificar la respuesta: %v", err)
 	}
 
-	total := results.Hits.Total.Value
-	return emails, total, nil
+	return index.Stats.DocNum, nil
 }
@@ -0,0 +1,178 @@
+package zincsearch
+
+import (
+	"database/sql"
+	"fmt"
+	"project/domain/address"
+	"project/domain/header"
+	"project/domain/model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AttachmentDocument son los metadatos de un adjunto dentro del documento indexado.
+type AttachmentDocument struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+}
+
+// EmailDocument es la representación de un correo en el índice de ZincSearch.
+type EmailDocument struct {
+	ID              int                  `json:"id"` // ID de MySQL; también es el _id del documento
+	MessageID       string               `json:"message_id"`
+	Sender          string               `json:"sender"`
+	SenderName      string               `json:"sender_name"`
+	Receiver        string               `json:"receiver"`
+	ReceiverName    string               `json:"receiver_name"`
+	Cc              string               `json:"cc"`
+	Bcc             string               `json:"bcc"`
+	Subject         string               `json:"subject"`
+	MimeVersion     string               `json:"mime_version"`
+	ContentType     string               `json:"content_type"`
+	Encoding        string               `json:"encoding"`
+	Folder          string               `json:"folder"`
+	Body            string               `json:"body"`
+	Date            string               `json:"date,omitempty"`       // RFC 3339 en UTC
+	Timestamp       string               `json:"@timestamp,omitempty"` // Campo de fecha nativo de ZincSearch
+	DateRaw         string               `json:"date_raw"`
+	DateOffset      int                  `json:"date_offset"`
+	Headers         header.Header        `json:"headers"`
+	Attachments     []AttachmentDocument `json:"attachments"`
+	AttachmentNames string               `json:"attachment_names"`
+	HasAttachment   bool                 `json:"has_attachment"`
+	// Direcciones normalizadas en minúsculas, una por elemento, para filtrar y
+	// agrupar; los campos anteriores conservan las cabeceras como llegaron
+	SenderAddresses   []string `json:"sender_addresses"`   // Direcciones de From
+	ReceiverAddresses []string `json:"receiver_addresses"` // Direcciones de To
+	CcAddresses       []string `json:"cc_addresses"`
+	BccAddresses      []string `json:"bcc_addresses"`
+	Recipients        []string `json:"recipients"` // Direcciones de To y Cc
+}
+
+// NewEmailDocument construye el documento a indexar a partir de un correo.
+func NewEmailDocument(email model.Email) EmailDocument {
+	doc := EmailDocument{
+		ID:           email.ID,
+		MessageID:    email.MessageID,
+		Sender:       email.Sender,
+		SenderName:   email.SenderName,
+		Receiver:     email.Receiver,
+		ReceiverName: email.ReceiverName,
+		Cc:           email.Cc,
+		Bcc:          email.Bcc,
+		Subject:      email.Subject,
+		MimeVersion:  email.MimeVersion,
+		ContentType:  email.ContentType,
+		Encoding:     email.Encoding,
+		Folder:       email.Folder,
+		Body:         email.Body,
+		DateRaw:      email.DateRaw,
+		DateOffset:   email.DateOffset,
+		Headers:      email.Headers,
+		Attachments:  make([]AttachmentDocument, 0, len(email.Attachments)),
+	}
+
+	if email.Date.Valid {
+		doc.Date = email.Date.Time.UTC().Format(time.RFC3339)
+		doc.Timestamp = doc.Date
+	}
+
+	// Los nombres de los adjuntos se indexan también como texto para poder buscarlos
+	names := make([]string, 0, len(email.Attachments))
+	for _, attachment := range email.Attachments {
+		doc.Attachments = append(doc.Attachments, AttachmentDocument{
+			Filename:    attachment.Filename,
+			ContentType: attachment.ContentType,
+			Size:        attachment.Size,
+		})
+		names = append(names, attachment.Filename)
+	}
+	doc.AttachmentNames = strings.Join(names, " ")
+	doc.HasAttachment = len(email.Attachments) > 0
+
+	// Cada dirección por separado, para filtrar y agrupar por dirección
+	doc.SenderAddresses = address.Emails(email.Sender)
+	doc.ReceiverAddresses = address.Emails(email.Receiver)
+	doc.CcAddresses = address.Emails(email.Cc)
+	doc.BccAddresses = address.Emails(email.Bcc)
+	doc.Recipients = address.Emails(email.Receiver, email.Cc)
+
+	return doc
+}
+
+// ToEmail convierte el documento indexado nuevamente en un correo.
+func (doc EmailDocument) ToEmail() model.Email {
+	email := model.Email{
+		ID:           doc.ID,
+		MessageID:    doc.MessageID,
+		Sender:       doc.Sender,
+		SenderName:   doc.SenderName,
+		Receiver:     doc.Receiver,
+		ReceiverName: doc.ReceiverName,
+		Cc:           doc.Cc,
+		Bcc:          doc.Bcc,
+		Subject:      doc.Subject,
+		MimeVersion:  doc.MimeVersion,
+		ContentType:  doc.ContentType,
+		Encoding:     doc.Encoding,
+		Folder:       doc.Folder,
+		Body:         doc.Body,
+		DateRaw:      doc.DateRaw,
+		DateOffset:   doc.DateOffset,
+		Headers:      doc.Headers,
+	}
+
+	if date, err := time.Parse(time.RFC3339, doc.Date); err == nil {
+		email.Date = sql.NullTime{Time: date.UTC(), Valid: true}
+	}
+
+	for _, attachment := range doc.Attachments {
+		email.Attachments = append(email.Attachments, model.Attachment{
+			Filename:    attachment.Filename,
+			ContentType: attachment.ContentType,
+			Size:        attachment.Size,
+		})
+	}
+
+	return email
+}
+
+// DocumentID devuelve el _id de un correo: su ID de MySQL. Así indexar dos
+// veces el mismo correo actualiza el documento en lugar de duplicarlo.
+func DocumentID(email model.Email) (string, error) {
+	if email.ID <= 0 {
+		return "", fmt.Errorf("el correo %q no tiene ID de MySQL y no se puede indexar", email.MessageID)
+	}
+	return strconv.Itoa(email.ID), nil
+}
+
+// SearchHit es un resultado de búsqueda de ZincSearch.
+type SearchHit struct {
+	ID        string              `json:"_id"`
+	Score     float64             `json:"_score"`
+	Source    EmailDocument       `json:"_source"`
+	Highlight map[string][]string `json:"highlight"`
+}
+
+// ToEmail convierte el resultado en un correo con su ID de MySQL. Si el
+// documento no incluye el campo id se usa el _id, que es el mismo valor.
+func (hit SearchHit) ToEmail() model.Email {
+	email := hit.Source.ToEmail()
+	if email.ID == 0 {
+		if id, err := strconv.Atoi(hit.ID); err == nil {
+			email.ID = id
+		}
+	}
+	return email
+}
+
+// ToSearchHit convierte el resultado en un correo con su relevancia y los fragmentos resaltados.
+func (hit SearchHit) ToSearchHit() model.SearchHit {
+	return model.SearchHit{
+		Email:      hit.ToEmail(),
+		Score:      hit.Score,
+		Highlights: hit.Highlight,
+	}
+}
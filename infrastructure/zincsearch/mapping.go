@@ -0,0 +1,243 @@
+package zincsearch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Analizador usado en los campos de texto libre
+const textAnalyzer = "standard"
+
+// Formato de los campos de fecha (layout de Go, igual al RFC 3339 de EmailDocument.Date)
+const dateFormat = "2006-01-02T15:04:05Z07:00"
+
+// FieldMapping es la definición de un campo en el mapping de ZincSearch.
+type FieldMapping struct {
+	Type          string `json:"type"`
+	Index         bool   `json:"index"`
+	Store         bool   `json:"store"`
+	Sortable      bool   `json:"sortable"`
+	Aggregatable  bool   `json:"aggregatable"`
+	Highlightable bool   `json:"highlightable"`
+	Analyzer      string `json:"analyzer,omitempty"`
+	Format        string `json:"format,omitempty"`
+}
+
+func keywordField() FieldMapping {
+	return FieldMapping{Type: "keyword", Index: true, Sortable: true, Aggregatable: true}
+}
+
+func textField() FieldMapping {
+	return FieldMapping{Type: "text", Index: true, Highlightable: true, Analyzer: textAnalyzer}
+}
+
+// EmailMapping devuelve el mapping esperado del índice de correos. Las
+// direcciones y la carpeta son keyword para filtrar y agrupar por el valor
+// exacto (los campos *_addresses y recipients guardan una dirección por
+// elemento); el asunto y el cuerpo son texto analizado.
+func EmailMapping() map[string]FieldMapping {
+	return map[string]FieldMapping{
+		"id":                 {Type: "numeric", Index: true, Sortable: true, Aggregatable: true},
+		"message_id":         keywordField(),
+		"sender":             keywordField(),
+		"sender_name":        textField(),
+		"receiver":           keywordField(),
+		"receiver_name":      textField(),
+		"cc":                 keywordField(),
+		"bcc":                keywordField(),
+		"subject":            textField(),
+		"mime_version":       keywordField(),
+		"content_type":       keywordField(),
+		"encoding":           keywordField(),
+		"folder":             keywordField(),
+		"body":               textField(),
+		"date":               {Type: "date", Index: true, Sortable: true, Aggregatable: true, Format: dateFormat},
+		"date_raw":           {Type: "keyword", Index: false, Store: true},
+		"date_offset":        {Type: "numeric", Index: true},
+		"attachment_names":   textField(),
+		"has_attachment":     {Type: "bool", Index: true, Aggregatable: true},
+		"sender_addresses":   keywordField(),
+		"receiver_addresses": keywordField(),
+		"cc_addresses":       keywordField(),
+		"bcc_addresses":      keywordField(),
+		"recipients":         keywordField(),
+	}
+}
+
+// FieldDrift es un campo cuyo tipo en el índice no coincide con el esperado.
+type FieldDrift struct {
+	Field    string
+	Expected string
+	Actual   string
+}
+
+// MappingDriftError indica que el mapping del índice difiere del esperado.
+// ZincSearch no permite cambiar el tipo de un campo existente, por lo que
+// hay que reconstruir el índice con el comando reindex.
+type MappingDriftError struct {
+	Index  string
+	Fields []FieldDrift
+}
+
+func (e *MappingDriftError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = fmt.Sprintf("%s (se esperaba %s, el índice tiene %s)", f.Field, f.Expected, f.Actual)
+	}
+	return fmt.Sprintf("el mapping del índice %s no coincide con el esperado: %s", e.Index, strings.Join(parts, ", "))
+}
+
+// EnsureIndex crea el índice con el mapping esperado si no existe, agrega los
+// campos que falten y devuelve un *MappingDriftError si algún campo tiene otro tipo.
+func (zsc *ZincSearchClient) EnsureIndex() error {
+	exists, err := zsc.IndexExists()
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return zsc.createIndex()
+	}
+
+	missing, err := zsc.CheckMapping()
+	if err != nil {
+		return err
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	properties := make(map[string]FieldMapping, len(missing))
+	expected := EmailMapping()
+	for _, field := range missing {
+		properties[field] = expected[field]
+	}
+	_, err = zsc.request("PUT", "/"+zsc.index+"/_mapping", map[string]interface{}{"properties": properties})
+	if err != nil {
+		return fmt.Errorf("error actualizando el mapping del índice %s: %w", zsc.index, err)
+	}
+	return nil
+}
+
+// CheckMapping compara el mapping del índice con el esperado. Devuelve los
+// campos que faltan y un *MappingDriftError si algún campo tiene otro tipo.
+func (zsc *ZincSearchClient) CheckMapping() ([]string, error) {
+	body, err := zsc.request("GET", "/"+zsc.index+"/_mapping", nil)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo el mapping del índice %s: %w", zsc.index, err)
+	}
+
+	var result map[string]struct {
+		Mappings struct {
+			Properties map[string]FieldMapping `json:"properties"`
+		} `json:"mappings"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("error al decodificar el mapping: %v", err)
+	}
+	actual := result[zsc.index].Mappings.Properties
+
+	var missing []string
+	drift := &MappingDriftError{Index: zsc.index}
+	for field, want := range EmailMapping() {
+		got, ok := actual[field]
+		if !ok {
+			missing = append(missing, field)
+			continue
+		}
+		if got.Type != want.Type {
+			drift.Fields = append(drift.Fields, FieldDrift{Field: field, Expected: want.Type, Actual: got.Type})
+		} else if want.Analyzer != "" && got.Analyzer != "" && got.Analyzer != want.Analyzer {
+			drift.Fields = append(drift.Fields, FieldDrift{Field: field, Expected: "analizador " + want.Analyzer, Actual: "analizador " + got.Analyzer})
+		}
+	}
+	sort.Strings(missing)
+
+	if len(drift.Fields) > 0 {
+		sort.Slice(drift.Fields, func(i, j int) bool { return drift.Fields[i].Field < drift.Fields[j].Field })
+		return missing, drift
+	}
+	return missing, nil
+}
+
+// IndexExists indica si el índice del cliente existe.
+func (zsc *ZincSearchClient) IndexExists() (bool, error) {
+	req, err := http.NewRequest("HEAD", zsc.baseURL+"/index/"+zsc.index, nil)
+	if err != nil {
+		return false, fmt.Errorf("error al crear la solicitud: %v", err)
+	}
+	req.SetBasicAuth(zsc.username, zsc.password)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("error al hacer la solicitud: %w", err)
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, &ResponseError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+}
+
+func (zsc *ZincSearchClient) createIndex() error {
+	_, err := zsc.request("POST", "/index", map[string]interface{}{
+		"name":         zsc.index,
+		"storage_type": "disk",
+		"mappings":     map[string]interface{}{"properties": EmailMapping()},
+	})
+	if err != nil {
+		return fmt.Errorf("error creando el índice %s: %w", zsc.index, err)
+	}
+	return nil
+}
+
+// request envía una solicitud JSON a la API de ZincSearch y devuelve el cuerpo de la respuesta.
+func (zsc *ZincSearchClient) request(method, path string, payload interface{}) ([]byte, error) {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("error serializando la solicitud: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, zsc.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("error al crear la solicitud: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(zsc.username, zsc.password)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error al hacer la solicitud: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error al leer el cuerpo de la respuesta: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s", &ResponseError{StatusCode: resp.StatusCode, Status: resp.Status}, string(respBody))
+	}
+	return respBody, nil
+}
+
+// DeleteIndex elimina el índice del cliente con todos sus documentos.
+func (zsc *ZincSearchClient) DeleteIndex() error {
+	if _, err := zsc.request("DELETE", "/index/"+zsc.index, nil); err != nil {
+		return fmt.Errorf("error eliminando el índice %s: %w", zsc.index, err)
+	}
+	return nil
+}
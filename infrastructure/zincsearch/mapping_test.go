@@ -0,0 +1,149 @@
+package zincsearch
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestEnsureIndexUnavailable(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	forbidden := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer forbidden.Close()
+
+	tests := []struct {
+		name        string
+		url         string
+		unavailable bool
+	}{
+		{"5xx", down.URL, true},
+		{"sin conexión", closed.URL, true},
+		{"credenciales inválidas", forbidden.URL, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &ZincSearchClient{baseURL: tt.url + "/api", index: "emails"}
+			err := client.EnsureIndex()
+			if err == nil {
+				t.Fatal("se esperaba un error")
+			}
+			if got := IsUnavailable(err); got != tt.unavailable {
+				t.Errorf("IsUnavailable(%v) = %v, se esperaba %v", err, got, tt.unavailable)
+			}
+		})
+	}
+}
+
+// fakeMappingServer simula los endpoints de índices y mappings de ZincSearch
+// y registra las solicitudes que modifican el índice.
+type fakeMappingServer struct {
+	properties map[string]FieldMapping // nil si el índice no existe
+	writes     []string                // Método y ruta de cada escritura
+	payloads   []map[string]interface{}
+}
+
+func (f *fakeMappingServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == "HEAD" && r.URL.Path == "/api/index/emails":
+		if f.properties == nil {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case r.Method == "GET" && r.URL.Path == "/api/emails/_mapping":
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"emails": map[string]interface{}{"mappings": map[string]interface{}{"properties": f.properties}},
+		})
+	case (r.Method == "POST" && r.URL.Path == "/api/index") || (r.Method == "PUT" && r.URL.Path == "/api/emails/_mapping"):
+		var payload map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&payload)
+		f.writes = append(f.writes, r.Method+" "+r.URL.Path)
+		f.payloads = append(f.payloads, payload)
+	default:
+		http.Error(w, "ruta inesperada", http.StatusBadRequest)
+	}
+}
+
+func TestEnsureIndex(t *testing.T) {
+	// mapping devuelve el mapping esperado con los campos de changed reemplazados y sin los de missing
+	mapping := func(changed map[string]FieldMapping, missing ...string) map[string]FieldMapping {
+		properties := EmailMapping()
+		for field, fieldMapping := range changed {
+			properties[field] = fieldMapping
+		}
+		for _, field := range missing {
+			delete(properties, field)
+		}
+		return properties
+	}
+	otherAnalyzer := textField()
+	otherAnalyzer.Analyzer = "simple"
+
+	tests := []struct {
+		name       string
+		properties map[string]FieldMapping
+		writes     []string
+		added      []string // Campos que se agregan al mapping existente
+		drift      []FieldDrift
+	}{
+		{"índice inexistente", nil, []string{"POST /api/index"}, nil, nil},
+		{"mapping completo", mapping(nil), nil, nil, nil},
+		{"campos faltantes", mapping(nil, "recipients", "has_attachment"),
+			[]string{"PUT /api/emails/_mapping"}, []string{"has_attachment", "recipients"}, nil},
+		{"tipo distinto", mapping(map[string]FieldMapping{"date": keywordField()}), nil, nil,
+			[]FieldDrift{{Field: "date", Expected: "date", Actual: "keyword"}}},
+		{"analizador distinto", mapping(map[string]FieldMapping{"body": otherAnalyzer}), nil, nil,
+			[]FieldDrift{{Field: "body", Expected: "analizador standard", Actual: "analizador simple"}}},
+		// Con un campo incompatible no se agregan los que faltan
+		{"tipo distinto y campos faltantes", mapping(map[string]FieldMapping{"sender": textField()}, "recipients"), nil, nil,
+			[]FieldDrift{{Field: "sender", Expected: "keyword", Actual: "text"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeMappingServer{properties: tt.properties}
+			server := httptest.NewServer(fake)
+			defer server.Close()
+
+			client := &ZincSearchClient{baseURL: server.URL + "/api", index: "emails"}
+			err := client.EnsureIndex()
+
+			var drift *MappingDriftError
+			if tt.drift == nil && err != nil {
+				t.Fatal(err)
+			}
+			if tt.drift != nil {
+				if !errors.As(err, &drift) {
+					t.Fatalf("EnsureIndex() = %v, se esperaba un MappingDriftError", err)
+				}
+				if !reflect.DeepEqual(drift.Fields, tt.drift) {
+					t.Errorf("campos distintos %+v, se esperaba %+v", drift.Fields, tt.drift)
+				}
+			}
+			if !reflect.DeepEqual(fake.writes, tt.writes) {
+				t.Fatalf("escrituras %v, se esperaba %v", fake.writes, tt.writes)
+			}
+
+			if tt.added != nil {
+				properties, _ := fake.payloads[0]["properties"].(map[string]interface{})
+				var added []string
+				for field := range properties {
+					added = append(added, field)
+				}
+				sort.Strings(added)
+				if !reflect.DeepEqual(added, tt.added) {
+					t.Errorf("se agregaron %v, se esperaba %v", added, tt.added)
+				}
+			}
+		})
+	}
+}
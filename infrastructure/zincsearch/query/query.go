@@ -0,0 +1,192 @@
+// Package query arma las consultas que se envían a la API _search de
+// ZincSearch. Cada tipo se serializa con encoding/json, por lo que los valores
+// ingresados por los usuarios nunca se insertan como texto dentro del JSON.
+package query
+
+import (
+	"encoding/json"
+)
+
+// Query es una consulta que se puede usar sola o dentro de Bool.
+type Query interface {
+	json.Marshaler
+	query()
+}
+
+// object serializa {"nombre": cuerpo}, la forma de todas las consultas.
+func object(name string, body interface{}) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{name: body})
+}
+
+// MatchAllQuery devuelve todos los documentos.
+type MatchAllQuery struct{}
+
+// MatchAll crea una consulta que devuelve todos los documentos.
+func MatchAll() MatchAllQuery {
+	return MatchAllQuery{}
+}
+
+func (MatchAllQuery) query() {}
+
+func (MatchAllQuery) MarshalJSON() ([]byte, error) {
+	return object("match_all", struct{}{})
+}
+
+// MatchQuery busca texto analizado en un campo. Con Phrase los términos deben
+// aparecer juntos y en el mismo orden (match_phrase).
+type MatchQuery struct {
+	Field  string
+	Text   string
+	Phrase bool
+}
+
+// Match crea una consulta match sobre un campo.
+func Match(field, text string) MatchQuery {
+	return MatchQuery{Field: field, Text: text}
+}
+
+// MatchPhrase crea una consulta match_phrase sobre un campo.
+func MatchPhrase(field, text string) MatchQuery {
+	return MatchQuery{Field: field, Text: text, Phrase: true}
+}
+
+func (MatchQuery) query() {}
+
+func (q MatchQuery) MarshalJSON() ([]byte, error) {
+	name := "match"
+	if q.Phrase {
+		name = "match_phrase"
+	}
+	return object(name, map[string]string{q.Field: q.Text})
+}
+
+// MultiMatchQuery busca texto analizado en varios campos a la vez.
+type MultiMatchQuery struct {
+	Text   string
+	Fields []string
+	Phrase bool
+}
+
+// MultiMatch crea una consulta multi_match sobre los campos indicados.
+func MultiMatch(text string, fields ...string) MultiMatchQuery {
+	return MultiMatchQuery{Text: text, Fields: fields}
+}
+
+func (MultiMatchQuery) query() {}
+
+func (q MultiMatchQuery) MarshalJSON() ([]byte, error) {
+	body := map[string]interface{}{"query": q.Text, "fields": q.Fields}
+	if q.Phrase {
+		body["type"] = "phrase"
+	}
+	return object("multi_match", body)
+}
+
+// TermQuery busca el valor exacto en un campo keyword, numérico o booleano.
+type TermQuery struct {
+	Field string
+	Value interface{}
+}
+
+// Term crea una consulta term.
+func Term(field string, value interface{}) TermQuery {
+	return TermQuery{Field: field, Value: value}
+}
+
+func (TermQuery) query() {}
+
+func (q TermQuery) MarshalJSON() ([]byte, error) {
+	return object("term", map[string]interface{}{q.Field: q.Value})
+}
+
+// WildcardQuery busca un patrón con * y ? en un campo keyword.
+type WildcardQuery struct {
+	Field   string
+	Pattern string
+}
+
+// Wildcard crea una consulta wildcard. El patrón se envía tal cual; para
+// buscar texto del usuario use Contains.
+func Wildcard(field, pattern string) WildcardQuery {
+	return WildcardQuery{Field: field, Pattern: pattern}
+}
+
+// Contains crea una consulta wildcard que busca el texto en cualquier parte del
+// campo, escapando los comodines que contenga.
+func Contains(field, text string) WildcardQuery {
+	return WildcardQuery{Field: field, Pattern: "*" + EscapeWildcard(text) + "*"}
+}
+
+func (WildcardQuery) query() {}
+
+func (q WildcardQuery) MarshalJSON() ([]byte, error) {
+	return object("wildcard", map[string]string{q.Field: q.Pattern})
+}
+
+// RangeQuery limita un campo numérico o de fecha. Los límites nil no se envían.
+type RangeQuery struct {
+	Field string
+	Gt    interface{}
+	Gte   interface{}
+	Lt    interface{}
+	Lte   interface{}
+}
+
+// Range crea una consulta range sin límites; se completan con los campos Gt, Gte, Lt y Lte.
+func Range(field string) RangeQuery {
+	return RangeQuery{Field: field}
+}
+
+func (RangeQuery) query() {}
+
+func (q RangeQuery) MarshalJSON() ([]byte, error) {
+	bounds := map[string]interface{}{}
+	for name, value := range map[string]interface{}{"gt": q.Gt, "gte": q.Gte, "lt": q.Lt, "lte": q.Lte} {
+		if value != nil {
+			bounds[name] = value
+		}
+	}
+	return object("range", map[string]interface{}{q.Field: bounds})
+}
+
+// BoolQuery combina consultas. Las de Filter se cumplen como Must pero no
+// influyen en la relevancia.
+type BoolQuery struct {
+	Must               []Query
+	Filter             []Query
+	Should             []Query
+	MustNot            []Query
+	MinimumShouldMatch int
+}
+
+// Bool crea una consulta bool vacía.
+func Bool() BoolQuery {
+	return BoolQuery{}
+}
+
+// AnyOf crea una consulta bool que exige que se cumpla al menos una de las consultas.
+func AnyOf(queries ...Query) BoolQuery {
+	return BoolQuery{Should: queries, MinimumShouldMatch: 1}
+}
+
+func (BoolQuery) query() {}
+
+func (q BoolQuery) MarshalJSON() ([]byte, error) {
+	body := map[string]interface{}{}
+	if len(q.Must) > 0 {
+		body["must"] = q.Must
+	}
+	if len(q.Filter) > 0 {
+		body["filter"] = q.Filter
+	}
+	if len(q.Should) > 0 {
+		body["should"] = q.Should
+	}
+	if len(q.MustNot) > 0 {
+		body["must_not"] = q.MustNot
+	}
+	if q.MinimumShouldMatch > 0 {
+		body["minimum_should_match"] = q.MinimumShouldMatch
+	}
+	return object("bool", body)
+}
@@ -0,0 +1,110 @@
+package query
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Search es el cuerpo completo de una solicitud _search.
+type Search struct {
+	Query       Query                  `json:"query"`
+	From        int                    `json:"from"`
+	Size        int                    `json:"size"`
+	Sort        []Sort                 `json:"sort,omitempty"`
+	Highlight   *Highlight             `json:"highlight,omitempty"`
+	Aggs        map[string]Aggregation `json:"aggs,omitempty"`
+	SearchAfter []interface{}          `json:"search_after,omitempty"` // Valores de Sort del último resultado leído; reemplaza a From
+}
+
+// Sort ordena los resultados por un campo.
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// Asc ordena de forma ascendente por el campo.
+func Asc(field string) Sort {
+	return Sort{Field: field}
+}
+
+// Desc ordena de forma descendente por el campo.
+func Desc(field string) Sort {
+	return Sort{Field: field, Desc: true}
+}
+
+func (s Sort) MarshalJSON() ([]byte, error) {
+	order := "asc"
+	if s.Desc {
+		order = "desc"
+	}
+	return object(s.Field, map[string]string{"order": order})
+}
+
+// Highlight pide a ZincSearch los fragmentos donde coincidió la búsqueda.
+type Highlight struct {
+	PreTags  []string                  `json:"pre_tags,omitempty"`
+	PostTags []string                  `json:"post_tags,omitempty"`
+	Fields   map[string]HighlightField `json:"fields"`
+}
+
+// HighlightField configura los fragmentos de un campo.
+type HighlightField struct {
+	FragmentSize      int `json:"fragment_size,omitempty"`
+	NumberOfFragments int `json:"number_of_fragments,omitempty"`
+}
+
+// Aggregation es una agregación que se calcula sobre los resultados.
+type Aggregation interface {
+	json.Marshaler
+	aggregation()
+}
+
+// TermsAggregation cuenta los documentos por cada valor de un campo keyword.
+type TermsAggregation struct {
+	Field string
+	Size  int
+}
+
+// Terms crea una agregación terms con los size valores más frecuentes.
+func Terms(field string, size int) TermsAggregation {
+	return TermsAggregation{Field: field, Size: size}
+}
+
+func (TermsAggregation) aggregation() {}
+
+func (a TermsAggregation) MarshalJSON() ([]byte, error) {
+	body := map[string]interface{}{"field": a.Field}
+	if a.Size > 0 {
+		body["size"] = a.Size
+	}
+	return object("terms", body)
+}
+
+// DateHistogramAggregation cuenta los documentos por intervalo de fecha.
+type DateHistogramAggregation struct {
+	Field    string
+	Interval string // Intervalo de calendario: day, week, month, year, ...
+	Format   string
+}
+
+// DateHistogram crea un histograma de fechas con el intervalo de calendario indicado.
+func DateHistogram(field, interval string) DateHistogramAggregation {
+	return DateHistogramAggregation{Field: field, Interval: interval}
+}
+
+func (DateHistogramAggregation) aggregation() {}
+
+func (a DateHistogramAggregation) MarshalJSON() ([]byte, error) {
+	body := map[string]interface{}{"field": a.Field, "calendar_interval": a.Interval}
+	if a.Format != "" {
+		body["format"] = a.Format
+	}
+	return object("date_histogram", body)
+}
+
+var wildcardEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`)
+
+// EscapeWildcard escapa los comodines de un texto para usarlo dentro de Wildcard.
+func EscapeWildcard(text string) string {
+	return wildcardEscaper.Replace(text)
+}